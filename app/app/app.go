@@ -8,14 +8,20 @@ import (
 
 	"github.com/odwrtw/errors"
 	"github.com/odwrtw/polochon/app/auth"
+	"github.com/odwrtw/polochon/app/backfill"
 	"github.com/odwrtw/polochon/app/cleaner"
+	"github.com/odwrtw/polochon/app/diskguard"
 	"github.com/odwrtw/polochon/app/downloader"
+	"github.com/odwrtw/polochon/app/health"
 	"github.com/odwrtw/polochon/app/organizer"
 	"github.com/odwrtw/polochon/app/safeguard"
 	"github.com/odwrtw/polochon/app/server"
 	"github.com/odwrtw/polochon/app/subapp"
+	"github.com/odwrtw/polochon/app/verifier"
 	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/history"
 	"github.com/odwrtw/polochon/lib/library"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 )
 
@@ -34,6 +40,15 @@ type App struct {
 	// safeguard
 	safeguard *safeguard.Safeguard
 
+	// health holds the state reported by the health probes
+	health *health.Health
+
+	// library is built when the sub apps are started
+	library *library.Library
+
+	// config is closed when the sub apps are stopped
+	config *configuration.Config
+
 	reload chan subapp.App
 
 	// wait group sync the goroutines launched by the app
@@ -41,15 +56,20 @@ type App struct {
 
 	// app logger
 	logger *logrus.Logger
+
+	// collectors holds the prometheus collectors registered by the app
+	collectors []prometheus.Collector
 }
 
 // NewApp create a new app from the given configuration path
 func NewApp(configPath, authManagerPath string) (*App, error) {
 	// Create the app
+	s := safeguard.New()
 	app := &App{
 		configPath:     configPath,
 		authConfigPath: authManagerPath,
-		safeguard:      safeguard.New(),
+		safeguard:      s,
+		health:         health.New(s, organizer.AppName, downloader.AppName, server.AppName),
 		done:           make(chan struct{}),
 		reload:         make(chan subapp.App),
 	}
@@ -69,30 +89,63 @@ func (a *App) init() error {
 		return err
 	}
 	a.logger = config.Logger
+	a.config = config
 
 	log := logrus.NewEntry(a.logger).WithField("function", "app_init")
 	log.Debug("app configuration loaded")
 
+	// The index of the library is built when the sub apps are started
 	library := library.New(config)
+	a.library = library
+	a.health.SetIndexReady(false)
 
-	// Build the library index
-	if err := library.RebuildIndex(log); err != nil {
-		log.WithField("function", "rebuild_index").Error(err)
+	// The status of the modules is shared by the metrics and the http server
+	modulesStatus := configuration.NewStatusChecker(config)
+
+	// Expose the library and the modules status metrics
+	if err := a.registerCollectors(
+		library.Collector(),
+		configuration.NewStatusCollector(modulesStatus),
+	); err != nil {
+		return err
+	}
+
+	// Load the grab history
+	h, err := history.New(config.Downloader.HistoryFile)
+	if err != nil {
+		return err
 	}
 
+	// The disk guard is shared by the organizer, the downloader and the
+	// health probes
+	guard := diskguard.New(config)
+	a.health.SetDiskGuard(guard)
+
 	// Add the organizer
-	a.subApps = []subapp.App{organizer.New(config, library)}
+	a.subApps = []subapp.App{organizer.New(config, library, h, guard)}
 
+	var d *downloader.Downloader
 	if config.Downloader.Enabled {
 		// Add the downloader
-		a.subApps = append(a.subApps, downloader.New(config, library))
+		d = downloader.New(config, library, h, guard)
+		a.subApps = append(a.subApps, d)
 
 		if config.Downloader.Cleaner.Enabled {
 			// Add the cleaner
-			a.subApps = append(a.subApps, cleaner.New(config))
+			a.subApps = append(a.subApps, cleaner.New(config, d))
 		}
 	}
 
+	if config.SubtitleBackfill.Enabled {
+		// Add the subtitle backfill
+		a.subApps = append(a.subApps, backfill.New(config, library))
+	}
+
+	if config.Verify.Enabled {
+		// Add the verifier
+		a.subApps = append(a.subApps, verifier.New(config, library, d))
+	}
+
 	// Only run the HTTP server if specified
 	if config.HTTPServer.Enable {
 		// Read the config of the auth manager
@@ -114,7 +167,7 @@ func (a *App) init() error {
 		}
 
 		// Add the http server
-		a.subApps = append(a.subApps, server.New(config, library, authManager))
+		a.subApps = append(a.subApps, server.New(config, library, authManager, modulesStatus, a.health, a.subApps))
 	}
 
 	log.Debug("app configuration loaded")
@@ -122,6 +175,23 @@ func (a *App) init() error {
 	return nil
 }
 
+// registerCollectors registers the prometheus collectors in place of the
+// ones registered by a previous init
+func (a *App) registerCollectors(collectors ...prometheus.Collector) error {
+	for _, c := range a.collectors {
+		prometheus.Unregister(c)
+	}
+
+	a.collectors = collectors
+	for _, c := range collectors {
+		if err := prometheus.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Run launches the app
 func (a *App) Run() {
 	// Hangle os signals
@@ -192,17 +262,36 @@ func (a *App) Run() {
 	}
 }
 
-// startSubApps launches all the sub app
+// startSubApps launches all the sub app, the http server is started first to
+// answer the health probes while the library index is built
 func (a *App) startSubApps(log *logrus.Entry) {
 	log.Debug("starting the sub apps")
 	for _, subApp := range a.subApps {
-		a.subAppStart(subApp, log)
+		if subApp.Name() == server.AppName {
+			a.subAppStart(subApp, log)
+		}
+	}
+
+	// Build the library index
+	if err := a.library.RebuildIndex(log); err != nil {
+		log.WithField("function", "rebuild_index").Error(err)
+	}
+	a.health.SetIndexReady(true)
+
+	for _, subApp := range a.subApps {
+		if subApp.Name() != server.AppName {
+			a.subAppStart(subApp, log)
+		}
 	}
 }
 
 // stopApps stops all the sub apps
 func (a *App) stopApps(log *logrus.Entry) {
 	log.Debug("stopping the sub apps")
+
+	// Don't make the sub apps wait for the rate limits of the modules
+	a.config.Close()
+
 	for _, subApp := range a.subApps {
 		log.Debugf("stopping sub app %q", subApp.Name())
 		subApp.Stop(log)
@@ -232,9 +321,10 @@ func (a *App) subAppStart(app subapp.App, log *logrus.Entry) {
 			switch e := err.(type) {
 			case *errors.Error:
 				errors.LogErrors(log.WithField("app", app.Name()), e)
+				a.health.SetError(app.Name(), e)
 
 				// Notify the safeguard of the error
-				a.safeguard.Event()
+				a.safeguard.Event(app.Name())
 
 				// Write to the reload channel in a goroutine to prevent deadlocks
 				go func() {
@@ -243,6 +333,7 @@ func (a *App) subAppStart(app subapp.App, log *logrus.Entry) {
 			// Only log the error
 			default:
 				log.Error(err)
+				a.health.SetError(app.Name(), err)
 				go a.Stop(log)
 			}
 		}
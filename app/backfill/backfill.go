@@ -0,0 +1,361 @@
+package backfill
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/odwrtw/errors"
+	"github.com/odwrtw/polochon/app/subapp"
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/library"
+	"github.com/sirupsen/logrus"
+)
+
+// AppName is the application name
+const AppName = "subtitle_backfill"
+
+// maxBackoff is the maximum delay between two tries on the same video
+const maxBackoff = 7 * 24 * time.Hour
+
+// Video types
+const (
+	typeMovie   = "movie"
+	typeEpisode = "episode"
+)
+
+// Missing represents a video with missing subtitles and the state of its
+// retries
+type Missing struct {
+	Type      string              `json:"type"`
+	ImdbID    string              `json:"imdb_id"`
+	Season    int                 `json:"season,omitempty"`
+	Episode   int                 `json:"episode,omitempty"`
+	Languages []polochon.Language `json:"languages"`
+	DateAdded time.Time           `json:"date_added"`
+	Attempts  int                 `json:"attempts"`
+	LastTry   *time.Time          `json:"last_try"`
+	NextTry   time.Time           `json:"next_try"`
+	LastError string              `json:"last_error"`
+	GivenUp   bool                `json:"given_up"`
+}
+
+func (m *Missing) key() string {
+	if m.Type == typeMovie {
+		return m.ImdbID
+	}
+
+	return fmt.Sprintf("%s-S%02dE%02d", m.ImdbID, m.Season, m.Episode)
+}
+
+// Backfill periodically fetches the subtitles missing from the library
+type Backfill struct {
+	*subapp.Base
+
+	config  *configuration.Config
+	library *library.Library
+	event   chan struct{}
+
+	mu      sync.RWMutex
+	missing map[string]*Missing
+}
+
+// New returns a new subtitle backfill
+func New(config *configuration.Config, vs *library.Library) *Backfill {
+	return &Backfill{
+		Base:    subapp.NewBase(AppName),
+		config:  config,
+		library: vs,
+		missing: map[string]*Missing{},
+	}
+}
+
+// Run starts the subtitle backfill
+func (b *Backfill) Run(log *logrus.Entry) error {
+	log = log.WithField("app", AppName)
+
+	// Init the app
+	b.InitStart(log)
+
+	b.event = make(chan struct{}, 1)
+
+	log.Debug("subtitle backfill started")
+
+	log.Debug("initial subtitle backfill launch")
+	b.event <- struct{}{}
+
+	// Start the ticker
+	b.Wg.Add(1)
+	go func() {
+		defer b.Wg.Done()
+		b.ticker(log)
+	}()
+
+	// Start the backfill
+	var err error
+	b.Wg.Add(1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.New("panic recovered").Fatal().AddContext(errors.Context{
+					"sub_app": AppName,
+				})
+				b.Stop(log)
+			}
+
+			b.Wg.Done()
+		}()
+		b.backfill(log)
+	}()
+
+	defer log.Debug("subtitle backfill stopped")
+
+	b.Wg.Wait()
+
+	return err
+}
+
+func (b *Backfill) ticker(log *logrus.Entry) {
+	ticker := time.NewTicker(b.config.SubtitleBackfill.Timer)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			log.Debug("subtitle backfill timer triggered")
+			b.event <- struct{}{}
+		case <-b.Done:
+			log.Debug("subtitle backfill timer stopped")
+			return
+		}
+	}
+}
+
+func (b *Backfill) backfill(log *logrus.Entry) {
+	for {
+		select {
+		case <-b.event:
+			log.Debug("subtitle backfill event")
+			b.fetchMissingSubtitles(log)
+		case <-b.Done:
+			log.Debug("subtitle backfill done handling events")
+			return
+		}
+	}
+}
+
+// Missing returns the videos with missing subtitles, sorted by next try
+func (b *Backfill) Missing() []*Missing {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	list := make([]*Missing, 0, len(b.missing))
+	for _, m := range b.missing {
+		c := *m
+		list = append(list, &c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].NextTry.Before(list[j].NextTry)
+	})
+
+	return list
+}
+
+// missingLanguages returns the wanted languages not present in the given
+// subtitles
+func missingLanguages(wanted, subtitles []polochon.Language) []polochon.Language {
+	have := map[polochon.Language]struct{}{}
+	for _, l := range subtitles {
+		have[l] = struct{}{}
+	}
+
+	missing := []polochon.Language{}
+	for _, l := range wanted {
+		if _, ok := have[l]; !ok {
+			missing = append(missing, l)
+		}
+	}
+
+	return missing
+}
+
+// available returns the languages of the subtitles available for a video,
+// either as a file or embedded in the video file
+func available(m *polochon.VideoMetadata, subtitles []polochon.Language) []polochon.Language {
+	langs := append([]polochon.Language{}, subtitles...)
+	for _, s := range m.EmbeddedSubtitles {
+		if !s.Forced {
+			langs = append(langs, s.Language)
+		}
+	}
+
+	return langs
+}
+
+// scan walks the library index and updates the list of videos with missing
+// subtitles
+func (b *Backfill) scan() {
+	wanted := b.config.SubtitleLanguages
+	found := map[string]*Missing{}
+
+	for id, m := range b.library.MovieIndex() {
+		langs := missingLanguages(wanted, available(&m.VideoMetadata, m.Subtitles))
+		if len(langs) == 0 {
+			continue
+		}
+
+		missing := &Missing{
+			Type:      typeMovie,
+			ImdbID:    id,
+			Languages: langs,
+			DateAdded: m.DateAdded,
+		}
+		found[missing.key()] = missing
+	}
+
+	for id, show := range b.library.ShowIDs() {
+		for sNum, season := range show.Seasons {
+			for eNum, e := range season.Episodes {
+				// The subtitles are shared by the episodes of a file
+				if !e.IsFirst(eNum) {
+					continue
+				}
+
+				langs := missingLanguages(wanted, available(&e.VideoMetadata, e.Subtitles))
+				if len(langs) == 0 {
+					continue
+				}
+
+				missing := &Missing{
+					Type:      typeEpisode,
+					ImdbID:    id,
+					Season:    sNum,
+					Episode:   eNum,
+					Languages: langs,
+					DateAdded: e.DateAdded,
+				}
+				found[missing.key()] = missing
+			}
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Keep the retry state of the videos already known
+	for k, m := range found {
+		old, ok := b.missing[k]
+		if !ok {
+			continue
+		}
+
+		m.Attempts = old.Attempts
+		m.LastTry = old.LastTry
+		m.NextTry = old.NextTry
+		m.LastError = old.LastError
+	}
+
+	b.missing = found
+}
+
+// candidates returns the videos to retry during this run
+func (b *Backfill) candidates(now time.Time) []*Missing {
+	conf := b.config.SubtitleBackfill
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	list := []*Missing{}
+	for _, m := range b.missing {
+		if conf.MaxAge > 0 && !m.DateAdded.IsZero() && now.Sub(m.DateAdded) > conf.MaxAge {
+			m.GivenUp = true
+			continue
+		}
+
+		if now.Before(m.NextTry) {
+			continue
+		}
+
+		list = append(list, m)
+	}
+
+	// Try the videos that have waited the most first
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].NextTry.Before(list[j].NextTry)
+	})
+
+	if conf.MaxPerRun > 0 && len(list) > conf.MaxPerRun {
+		list = list[:conf.MaxPerRun]
+	}
+
+	return list
+}
+
+// nextTry returns the date of the next try using an exponential backoff
+func (b *Backfill) nextTry(now time.Time, attempts int) time.Time {
+	delay := b.config.SubtitleBackfill.Backoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	return now.Add(delay)
+}
+
+func (b *Backfill) video(m *Missing) (polochon.Video, error) {
+	if m.Type == typeMovie {
+		return b.library.GetMovie(m.ImdbID)
+	}
+
+	return b.library.GetEpisode(m.ImdbID, m.Season, m.Episode)
+}
+
+func (b *Backfill) fetchMissingSubtitles(log *logrus.Entry) {
+	b.scan()
+
+	now := time.Now()
+	for _, m := range b.candidates(now) {
+		log := log.WithFields(logrus.Fields{
+			"type":    m.Type,
+			"imdb_id": m.ImdbID,
+		})
+		if m.Type == typeEpisode {
+			log = log.WithFields(logrus.Fields{
+				"season":  m.Season,
+				"episode": m.Episode,
+			})
+		}
+
+		video, err := b.video(m)
+		if err != nil {
+			log.Errorf("failed to get video from the library: %q", err)
+			continue
+		}
+
+		added, err := b.library.AddSubtitles(video, m.Languages, log)
+
+		b.mu.Lock()
+		m.Attempts++
+		m.LastTry = &now
+		m.NextTry = b.nextTry(now, m.Attempts)
+		m.LastError = ""
+		if err != nil {
+			m.LastError = err.Error()
+			errors.LogErrors(log, err)
+		}
+
+		if len(added) == len(m.Languages) {
+			log.Info("all the missing subtitles have been found")
+			delete(b.missing, m.key())
+		} else if len(added) > 0 {
+			log.Infof("found %d subtitle(s) out of %d", len(added), len(m.Languages))
+			m.Languages = missingLanguages(m.Languages, added)
+		}
+		b.mu.Unlock()
+	}
+}
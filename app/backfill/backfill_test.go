@@ -0,0 +1,85 @@
+package backfill
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+)
+
+func newTestBackfill(conf configuration.SubtitleBackfillConfig) *Backfill {
+	return New(&configuration.Config{SubtitleBackfill: conf}, nil)
+}
+
+func TestNextTry(t *testing.T) {
+	b := newTestBackfill(configuration.SubtitleBackfillConfig{Backoff: 6 * time.Hour})
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	for attempts, expected := range map[int]time.Duration{
+		1:  6 * time.Hour,
+		2:  12 * time.Hour,
+		3:  24 * time.Hour,
+		4:  48 * time.Hour,
+		6:  maxBackoff,
+		20: maxBackoff,
+	} {
+		if got := b.nextTry(now, attempts); !got.Equal(now.Add(expected)) {
+			t.Errorf("attempt %d: expected a delay of %s, got %s", attempts, expected, got.Sub(now))
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	b := newTestBackfill(configuration.SubtitleBackfillConfig{
+		MaxPerRun: 2,
+		MaxAge:    30 * 24 * time.Hour,
+	})
+	now := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+
+	for _, m := range []*Missing{
+		// Waiting for its next try
+		{Type: typeMovie, ImdbID: "tt1", DateAdded: now.Add(-time.Hour), NextTry: now.Add(time.Hour)},
+		// Too old to be retried
+		{Type: typeMovie, ImdbID: "tt2", DateAdded: now.Add(-31 * 24 * time.Hour)},
+		// Retried, the one that has waited the most first
+		{Type: typeMovie, ImdbID: "tt3", DateAdded: now.Add(-time.Hour), NextTry: now.Add(-time.Minute)},
+		{Type: typeMovie, ImdbID: "tt4", DateAdded: now.Add(-time.Hour), NextTry: now.Add(-time.Hour)},
+		// Unknown date, never given up
+		{Type: typeEpisode, ImdbID: "tt5", Season: 1, Episode: 2},
+	} {
+		m.Languages = []polochon.Language{polochon.FR}
+		b.missing[m.key()] = m
+	}
+
+	got := []string{}
+	for _, m := range b.candidates(now) {
+		got = append(got, m.key())
+	}
+
+	expected := []string{"tt5-S01E02", "tt4"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected the candidates %v, got %v", expected, got)
+	}
+
+	givenUp := []string{}
+	for _, m := range b.Missing() {
+		if m.GivenUp {
+			givenUp = append(givenUp, m.key())
+		}
+	}
+	sort.Strings(givenUp)
+
+	if !reflect.DeepEqual(givenUp, []string{"tt2"}) {
+		t.Errorf("expected tt2 to be given up, got %v", givenUp)
+	}
+
+	// Without max age every video is retried
+	b.config.SubtitleBackfill = configuration.SubtitleBackfillConfig{}
+	b.missing["tt2"].GivenUp = false
+	if got := b.candidates(now); len(got) != 4 {
+		t.Errorf("expected 4 candidates, got %d", len(got))
+	}
+}
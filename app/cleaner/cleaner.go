@@ -6,12 +6,16 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/odwrtw/errors"
+	"github.com/odwrtw/polochon/app/downloader"
 	"github.com/odwrtw/polochon/app/subapp"
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/archive"
 	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/history"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,15 +26,22 @@ const AppName = "cleaner"
 type Cleaner struct {
 	*subapp.Base
 
-	config *configuration.Config
-	event  chan struct{}
+	config     *configuration.Config
+	downloader *downloader.Downloader
+	history    *history.History
+	event      chan struct{}
+	// samples keeps the progress of the unfinished torrents by ID
+	samples map[string]*sample
 }
 
 // New returns a new cleaner
-func New(config *configuration.Config) *Cleaner {
+func New(config *configuration.Config, d *downloader.Downloader) *Cleaner {
 	return &Cleaner{
-		Base:   subapp.NewBase(AppName),
-		config: config,
+		Base:       subapp.NewBase(AppName),
+		config:     config,
+		downloader: d,
+		history:    d.History(),
+		samples:    map[string]*sample{},
 	}
 }
 
@@ -114,11 +125,24 @@ func (c *Cleaner) cleanDoneVideos(log *logrus.Entry) {
 		return
 	}
 
+	now := time.Now()
+	seen := map[string]struct{}{}
+
 	for _, t := range list {
 		torrentInfos := t.Infos()
+		seen[torrentInfos.ID] = struct{}{}
 
 		log = log.WithField("torrent_name", torrentInfos.Name)
 
+		// Keep track of the download in the history
+		c.track(torrentInfos, log)
+
+		// Replace the torrents that will never finish
+		if reason := c.stalled(torrentInfos, now); reason != "" {
+			c.replace(t, reason, log)
+			continue
+		}
+
 		// Check if the file is ready to be cleaned
 		isReady := c.isReadyToBeCleaned(t, log)
 		if !isReady {
@@ -126,6 +150,8 @@ func (c *Cleaner) cleanDoneVideos(log *logrus.Entry) {
 			continue
 		}
 
+		c.checkImported(torrentInfos, log)
+
 		// We remove the torrent
 		log.Debugf("removing torrent")
 		err := c.config.Downloader.Client.Remove(t)
@@ -133,6 +159,7 @@ func (c *Cleaner) cleanDoneVideos(log *logrus.Entry) {
 			log.Errorf("got error when removing torrent : %q", err)
 			continue
 		}
+		removals.WithLabelValues("done").Inc()
 
 		log.Debug("removing files")
 		if err = c.clean(t, log); err != nil {
@@ -140,6 +167,48 @@ func (c *Cleaner) cleanDoneVideos(log *logrus.Entry) {
 			continue
 		}
 	}
+
+	// Forget the torrents not in the client anymore
+	for id := range c.samples {
+		if _, ok := seen[id]; !ok {
+			delete(c.samples, id)
+		}
+	}
+}
+
+// track marks the grabbed torrents as completed in the history once
+// downloaded
+func (c *Cleaner) track(torrent *polochon.DownloadableInfos, log *logrus.Entry) {
+	if torrent.Metadata == nil || !torrent.IsFinished {
+		return
+	}
+
+	e, err := c.history.Get(torrent.Metadata)
+	if err != nil || e.State != history.StateGrabbed {
+		return
+	}
+
+	if err := c.history.SetState(torrent.Metadata, history.StateCompleted); err != nil {
+		log.Errorf("failed to update the history: %q", err)
+	}
+}
+
+// checkImported marks the torrent as failed in the history if it's about to
+// be removed without having been imported in the library
+func (c *Cleaner) checkImported(torrent *polochon.DownloadableInfos, log *logrus.Entry) {
+	if torrent.Metadata == nil {
+		return
+	}
+
+	e, err := c.history.Get(torrent.Metadata)
+	if err != nil || !e.InFlight() {
+		return
+	}
+
+	log.Warn("torrent removed without being imported")
+	if err := c.history.Failed(torrent.Metadata, "removed without being imported"); err != nil {
+		log.Errorf("failed to update the history: %q", err)
+	}
 }
 
 func (c *Cleaner) isReadyToBeCleaned(d polochon.Downloadable, log *logrus.Entry) bool {
@@ -161,12 +230,55 @@ func (c *Cleaner) isReadyToBeCleaned(d polochon.Downloadable, log *logrus.Entry)
 	return true
 }
 
+// watchDir returns the watched directory holding the files of the torrent,
+// only the directories allowed to be cleaned are considered. The moved videos
+// are gone from the directory, it is found from any of the remaining files or
+// from the directory of the torrent
+func (c *Cleaner) watchDir(torrent *polochon.DownloadableInfos) string {
+	if len(torrent.FilePaths) == 0 {
+		return ""
+	}
+
+	for _, d := range c.config.Watcher.Dirs {
+		if !d.Clean {
+			continue
+		}
+
+		for _, p := range torrentPaths(torrent) {
+			if _, err := os.Lstat(filepath.Join(d.Dir, p)); err == nil {
+				return d.Dir
+			}
+		}
+	}
+
+	return ""
+}
+
+// torrentPaths returns the paths of the files of the torrent followed by the
+// top directory of the torrent if it has one
+func torrentPaths(torrent *polochon.DownloadableInfos) []string {
+	paths := append([]string{}, torrent.FilePaths...)
+
+	top := strings.SplitN(filepath.ToSlash(torrent.FilePaths[0]), "/", 2)
+	if len(top) == 2 && top[0] != "" && top[0] != "." && top[0] != ".." {
+		paths = append(paths, top[0])
+	}
+
+	return paths
+}
+
 func (c *Cleaner) clean(d polochon.Downloadable, log *logrus.Entry) error {
 	torrent := d.Infos()
 
+	watchDir := c.watchDir(torrent)
+	if watchDir == "" {
+		log.Debug("torrent files not found in the watched directories to clean")
+		return nil
+	}
+
 	// Going over all the files and remove only the allowed ones
 	for _, tPath := range torrent.FilePaths {
-		filePath := filepath.Join(c.config.Watcher.Dir, tPath)
+		filePath := filepath.Join(watchDir, tPath)
 		file := polochon.NewFile(filePath)
 
 		// Check extension
@@ -192,8 +304,11 @@ func (c *Cleaner) clean(d polochon.Downloadable, log *logrus.Entry) error {
 		}
 	}
 
+	// Remove what's left from the extraction of the archives
+	c.cleanArchives(watchDir, torrent, log)
+
 	// Need to check if we can delete the directory of the torrent
-	err := c.cleanDirectory(torrent, log)
+	err := c.cleanDirectory(watchDir, torrent, log)
 	if err != nil {
 		log.Warnf("got error while deleting directory : %q", err)
 		return err
@@ -202,12 +317,64 @@ func (c *Cleaner) clean(d polochon.Downloadable, log *logrus.Entry) error {
 	return nil
 }
 
+// cleanArchives removes the ignore files of the extracted archives and the
+// extraction directories of the archives left by an interrupted organizer
+func (c *Cleaner) cleanArchives(watchDir string, torrent *polochon.DownloadableInfos, log *logrus.Entry) {
+	for _, tPath := range torrent.FilePaths {
+		filePath := filepath.Join(watchDir, tPath)
+		if !archive.IsArchive(filePath) {
+			continue
+		}
+
+		file := polochon.NewFile(filePath)
+		if file.IsIgnored() {
+			if err := c.remove(file.IgnorePath(), log); err != nil {
+				log.Warnf("got error while removing file %q", err)
+			}
+		}
+
+		// Only the first volume of the archives is extracted
+		if _, first := archive.Volume(filePath); !first {
+			continue
+		}
+
+		tempDirs, err := archive.TempDirs(filePath)
+		if err != nil {
+			log.Warnf("got error while listing the extraction directories: %q", err)
+			continue
+		}
+
+		for _, tempDir := range tempDirs {
+			log.WithField("path", tempDir).Debug("deleting extraction directory")
+			if err := os.RemoveAll(tempDir); err != nil {
+				log.Warnf("got error while removing directory %q", err)
+			}
+		}
+	}
+}
+
 func (c *Cleaner) remove(filePath string, log *logrus.Entry) error {
 	log.WithField("path", filePath).Debug("deleting item")
-	return os.Remove(filePath)
+
+	// Only the regular files free some space, the symlinks point to the
+	// videos organized in the library
+	info, err := os.Lstat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(filePath); err != nil {
+		return err
+	}
+
+	if info.Mode().IsRegular() {
+		bytesFreed.Add(float64(info.Size()))
+	}
+
+	return nil
 }
 
-func (c *Cleaner) cleanDirectory(torrent *polochon.DownloadableInfos, log *logrus.Entry) error {
+func (c *Cleaner) cleanDirectory(watchDir string, torrent *polochon.DownloadableInfos, log *logrus.Entry) error {
 	if len(torrent.FilePaths) == 0 {
 		return fmt.Errorf("no torrent files to clean")
 	}
@@ -217,19 +384,19 @@ func (c *Cleaner) cleanDirectory(torrent *polochon.DownloadableInfos, log *logru
 	torrentFilePath := torrent.FilePaths[0]
 
 	// Get the full path of the file
-	filePath := filepath.Join(c.config.Watcher.Dir, torrentFilePath)
+	filePath := filepath.Join(watchDir, torrentFilePath)
 	// Get the directory of the file
 	directoryPath := filepath.Dir(filePath)
 	// Ensure the path is clean
 	directoryPath = filepath.Clean(directoryPath)
 	// We don't want to clean the DownloadDir
-	if directoryPath == c.config.Watcher.Dir {
+	if directoryPath == watchDir {
 		log.Debug("in the watching folder, no need to clean")
 		return nil
 	}
 
 	// Get relative path of the directory to clean
-	relDir, err := filepath.Rel(c.config.Watcher.Dir, directoryPath)
+	relDir, err := filepath.Rel(watchDir, directoryPath)
 	if err != nil {
 		return err
 	}
@@ -241,7 +408,7 @@ func (c *Cleaner) cleanDirectory(torrent *polochon.DownloadableInfos, log *logru
 	}
 
 	// Get the full path
-	directoryToClean := filepath.Join(c.config.Watcher.Dir, relDir)
+	directoryToClean := filepath.Join(watchDir, relDir)
 	log.Debug("try to clean and delete")
 
 	ok, err := IsEmpty(directoryToClean)
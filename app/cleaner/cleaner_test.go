@@ -0,0 +1,59 @@
+package cleaner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+)
+
+func TestWatchDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-cleaner")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The video has been moved into the library, only the sample is left
+	torrentDir := filepath.Join(dir, "Movie.2019")
+	if err := os.MkdirAll(torrentDir, os.ModePerm); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(torrentDir, "sample.mp4"), nil, 0644); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	c := &Cleaner{
+		config: &configuration.Config{
+			Watcher: configuration.WatcherConfig{
+				Dirs: []*configuration.WatchDir{{Dir: dir, Clean: true}},
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		name      string
+		filePaths []string
+		expected  string
+	}{
+		{name: "remaining file", filePaths: []string{"Movie.2019/movie.mp4", "Movie.2019/sample.mp4"}, expected: dir},
+		{name: "torrent directory", filePaths: []string{"Movie.2019/movie.mp4"}, expected: dir},
+		{name: "moved single file", filePaths: []string{"movie.mp4"}, expected: ""},
+		{name: "no files", expected: ""},
+	} {
+		torrent := &polochon.DownloadableInfos{FilePaths: tc.filePaths}
+		if got := c.watchDir(torrent); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+
+	// The directories not allowed to be cleaned are not considered
+	c.config.Watcher.Dirs[0].Clean = false
+	torrent := &polochon.DownloadableInfos{FilePaths: []string{"Movie.2019/sample.mp4"}}
+	if got := c.watchDir(torrent); got != "" {
+		t.Errorf("expected no watched directory, got %q", got)
+	}
+}
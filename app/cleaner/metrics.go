@@ -0,0 +1,22 @@
+package cleaner
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	removals = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polochon",
+		Subsystem: "cleaner",
+		Name:      "removals_total",
+		Help:      "Number of torrents removed from the downloader client by reason.",
+	}, []string{"reason"})
+
+	bytesFreed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "polochon",
+		Subsystem: "cleaner",
+		Name:      "freed_bytes_total",
+		Help:      "Size of the files deleted by the cleaner.",
+	})
+)
@@ -0,0 +1,138 @@
+package cleaner
+
+import (
+	"fmt"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/history"
+	"github.com/sirupsen/logrus"
+)
+
+// sample represents the progress of a torrent across the cleaner runs
+type sample struct {
+	firstSeen    time.Time
+	lastProgress time.Time
+	percentDone  float32
+}
+
+// stalled returns the reason why the torrent is considered stalled, or an
+// empty string if the torrent is still making progress
+func (c *Cleaner) stalled(torrent *polochon.DownloadableInfos, now time.Time) string {
+	conf := c.config.Downloader.Cleaner
+	if torrent.IsFinished || (conf.StallTimeout == 0 && conf.MaxDownloadTime == 0) {
+		delete(c.samples, torrent.ID)
+		return ""
+	}
+
+	s, ok := c.samples[torrent.ID]
+	if !ok {
+		s = &sample{
+			firstSeen:    now,
+			lastProgress: now,
+			percentDone:  torrent.PercentDone,
+		}
+
+		// The history knows when the torrent was added, even if polochon
+		// has been restarted since then
+		if torrent.Metadata != nil {
+			if e, err := c.history.Get(torrent.Metadata); err == nil && e.DateGrabbed.Before(now) {
+				s.firstSeen = e.DateGrabbed
+			}
+		}
+
+		c.samples[torrent.ID] = s
+	}
+
+	if torrent.PercentDone > s.percentDone || torrent.DownloadRate > 0 {
+		s.percentDone = torrent.PercentDone
+		s.lastProgress = now
+	}
+
+	if conf.MaxDownloadTime > 0 && now.Sub(s.firstSeen) > conf.MaxDownloadTime {
+		return fmt.Sprintf("not finished after %s", conf.MaxDownloadTime)
+	}
+
+	if conf.StallTimeout > 0 && now.Sub(s.lastProgress) > conf.StallTimeout {
+		return fmt.Sprintf("no progress for %s at %.0f%%", conf.StallTimeout, s.percentDone)
+	}
+
+	return ""
+}
+
+// replace removes a stalled torrent, blacklists it and grabs the next best
+// torrent for the video in the background not to hold the cleaner
+func (c *Cleaner) replace(d polochon.Downloadable, reason string, log *logrus.Entry) {
+	torrent := d.Infos()
+	log = log.WithField("reason", reason)
+	log.Warn("torrent stalled")
+
+	c.notify(&polochon.DownloadEvent{
+		Event:    polochon.DownloadStalled,
+		Torrent:  torrent.Name,
+		Reason:   reason,
+		Metadata: torrent.Metadata,
+	}, log)
+
+	if err := c.config.Downloader.Client.Remove(d); err != nil {
+		log.Errorf("got error when removing torrent : %q", err)
+		return
+	}
+	delete(c.samples, torrent.ID)
+	removals.WithLabelValues("stalled").Inc()
+
+	if err := c.clean(d, log); err != nil {
+		log.Errorf("failed to clean torrent files: %q", err)
+	}
+
+	c.notify(&polochon.DownloadEvent{
+		Event:    polochon.DownloadRemoved,
+		Torrent:  torrent.Name,
+		Reason:   reason,
+		Metadata: torrent.Metadata,
+	}, log)
+
+	// The video of the torrent is needed to find another one
+	if torrent.Metadata == nil {
+		return
+	}
+
+	err := c.history.Failed(torrent.Metadata, reason)
+	if err != nil && err != history.ErrNotFound {
+		log.Errorf("failed to update the history: %q", err)
+	}
+
+	c.Wg.Add(1)
+	go func() {
+		defer c.Wg.Done()
+		c.grabReplacement(torrent.Metadata, reason, log)
+	}()
+}
+
+// grabReplacement grabs the next best torrent of a video whose torrent has
+// been removed
+func (c *Cleaner) grabReplacement(m *polochon.DownloadableMetadata, reason string, log *logrus.Entry) {
+	g, err := c.downloader.Replace(m, log)
+	if err != nil {
+		log.Warnf("failed to replace the torrent: %q", err)
+		return
+	}
+
+	log.WithField("replacement", g.Torrent.Name).Info("torrent replaced")
+	c.notify(&polochon.DownloadEvent{
+		Event:    polochon.DownloadReplaced,
+		Torrent:  g.Torrent.Name,
+		Reason:   reason,
+		Metadata: g.Metadata,
+	}, log)
+}
+
+// notify sends a download event to the notifiers
+func (c *Cleaner) notify(event *polochon.DownloadEvent, log *logrus.Entry) {
+	log = log.WithField("function", "notify")
+	for _, n := range c.config.Notifiers {
+		if err := n.Notify(event, log); err != nil {
+			log.Warnf("failed to send a notification from notifier: %q: %q", n.Name(), err)
+		}
+	}
+}
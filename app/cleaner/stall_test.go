@@ -0,0 +1,67 @@
+package cleaner
+
+import (
+	"testing"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/history"
+)
+
+func TestStalled(t *testing.T) {
+	h, err := history.New("")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	c := &Cleaner{
+		config: &configuration.Config{
+			Downloader: configuration.DownloaderConfig{
+				Cleaner: configuration.CleanerConfig{
+					StallTimeout:    time.Hour,
+					MaxDownloadTime: 10 * time.Hour,
+				},
+			},
+		},
+		history: h,
+		samples: map[string]*sample{},
+	}
+
+	start := time.Now()
+	torrent := &polochon.DownloadableInfos{ID: "1", PercentDone: 3}
+
+	for _, step := range []struct {
+		name        string
+		after       time.Duration
+		percentDone float32
+		rate        int
+		stalled     bool
+	}{
+		{name: "first sample", after: 0, percentDone: 3},
+		{name: "no progress yet", after: 30 * time.Minute, percentDone: 3},
+		{name: "progress", after: 50 * time.Minute, percentDone: 4},
+		{name: "download rate", after: 2 * time.Hour, percentDone: 4, rate: 10},
+		{name: "stalled", after: 3*time.Hour + time.Minute, percentDone: 4, stalled: true},
+		{name: "progress again", after: 4 * time.Hour, percentDone: 50},
+		{name: "dead", after: 10*time.Hour + time.Minute, percentDone: 90, stalled: true},
+	} {
+		torrent.PercentDone = step.percentDone
+		torrent.DownloadRate = step.rate
+
+		reason := c.stalled(torrent, start.Add(step.after))
+		if (reason != "") != step.stalled {
+			t.Errorf("%s: expected stalled to be %t, got reason %q", step.name, step.stalled, reason)
+		}
+	}
+
+	// Finished torrents are never stalled and forgotten
+	torrent.IsFinished = true
+	if reason := c.stalled(torrent, start.Add(20*time.Hour)); reason != "" {
+		t.Errorf("expected a finished torrent not to be stalled, got %q", reason)
+	}
+
+	if _, ok := c.samples[torrent.ID]; ok {
+		t.Errorf("the sample of a finished torrent should be removed")
+	}
+}
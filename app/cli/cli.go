@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/odwrtw/polochon/app/downloader"
+	"github.com/odwrtw/polochon/app/organizer"
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/history"
+	"github.com/odwrtw/polochon/lib/library"
+	"github.com/sirupsen/logrus"
+)
+
+// Custom errors
+var (
+	ErrUnknownCommand  = errors.New("cli: unknown command")
+	ErrMissingArgument = errors.New("cli: missing argument")
+	ErrNoClient        = errors.New("cli: no downloader client configured")
+)
+
+// command represents a one-shot operation
+type command struct {
+	// name of the command, e.g. "index rebuild"
+	name string
+	// usage describes the arguments of the command
+	usage string
+	// run runs the command and returns the result to print
+	run func(env *env, args []string) (interface{}, error)
+}
+
+var commands = []*command{
+	{name: "organize", usage: "<path>", run: organize},
+	{name: "download", usage: "[-dry-run]", run: download},
+	{name: "index rebuild", run: indexRebuild},
+	{name: "index stats", run: indexStats},
+	{name: "wishlist show", run: wishlistShow},
+	{name: "subtitles fetch", usage: "[-season <n> -episode <n>] <imdb_id>", run: subtitlesFetch},
+	{name: "modules status", run: modulesStatus},
+	{name: "config validate", run: configValidate},
+	{name: "doctor", usage: "[-repair]", run: doctor},
+	{name: "rename", usage: "[-dry-run]", run: rename},
+}
+
+// env holds what the commands need to run
+type env struct {
+	configPath string
+	config     *configuration.Config
+	log        *logrus.Entry
+}
+
+// loadConfig loads the configuration file
+func (e *env) loadConfig() error {
+	config, err := configuration.LoadConfigFile(e.configPath)
+	if err != nil {
+		return err
+	}
+
+	e.config = config
+	e.log = logrus.NewEntry(config.Logger).WithField("app", "cli")
+	return nil
+}
+
+// history returns the grab history
+func (e *env) history() (*history.History, error) {
+	return history.New(e.config.Downloader.HistoryFile)
+}
+
+// library returns the library with its index built
+func (e *env) library() (*library.Library, error) {
+	l := library.New(e.config)
+	if err := l.RebuildIndex(e.log); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Run runs the command found in the args and prints its result as JSON on
+// the standard output
+func Run(configPath string, args []string) error {
+	cmd, args := findCommand(args)
+	if cmd == nil {
+		return ErrUnknownCommand
+	}
+
+	result, err := cmd.run(&env{configPath: configPath}, args)
+	if err != nil {
+		return err
+	}
+
+	return render(os.Stdout, result)
+}
+
+// Fatal prints the error as JSON on the standard output and exits
+func Fatal(err error) {
+	render(os.Stdout, map[string]string{"error": err.Error()})
+	os.Exit(1)
+}
+
+// Usage prints the available commands
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// findCommand returns the command matching the args and the remaining args
+func findCommand(args []string) (*command, []string) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):]
+		}
+	}
+
+	return nil, nil
+}
+
+func render(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func organize(e *env, args []string) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, ErrMissingArgument
+	}
+
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	h, err := e.history()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := e.library()
+	if err != nil {
+		return nil, err
+	}
+
+	o := organizer.New(e.config, l, h, nil)
+	if err := o.Organize(args[0], e.log); err != nil {
+		return nil, err
+	}
+
+	return map[string]string{"organized": args[0]}, nil
+}
+
+// newFlagSet returns a flag set returning the parsing errors instead of
+// printing the usage and exiting
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return fs
+}
+
+func download(e *env, args []string) (interface{}, error) {
+	fs := newFlagSet("download")
+	dryRun := fs.Bool("dry-run", false, "only show the torrents that would be downloaded")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	h, err := e.history()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := e.library()
+	if err != nil {
+		return nil, err
+	}
+
+	d := downloader.New(e.config, l, h, nil)
+	grabs, err := d.MissingVideos(e.log)
+	if err != nil {
+		return nil, err
+	}
+
+	if *dryRun {
+		return grabs, nil
+	}
+
+	if e.config.Downloader.Client == nil {
+		return nil, ErrNoClient
+	}
+
+	downloaded := []*downloader.Grab{}
+	for _, g := range grabs {
+		if err := d.Download(g, e.log); err != nil {
+			e.log.WithField("imdb_id", g.Metadata.ImdbID).Error(err)
+			continue
+		}
+
+		downloaded = append(downloaded, g)
+	}
+
+	return downloaded, nil
+}
+
+func indexRebuild(e *env, args []string) (interface{}, error) {
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	l := library.New(e.config)
+	if err := l.RebuildIndex(e.log); err != nil {
+		return nil, err
+	}
+
+	return l.IndexStats(), nil
+}
+
+func indexStats(e *env, args []string) (interface{}, error) {
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	l, err := e.library()
+	if err != nil {
+		return nil, err
+	}
+
+	return l.IndexStats(), nil
+}
+
+func wishlistShow(e *env, args []string) (interface{}, error) {
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	wl := polochon.NewWishlist(e.config.Wishlist, e.log)
+	if err := wl.Fetch(); err != nil {
+		return nil, err
+	}
+
+	return wl, nil
+}
+
+// subtitlesResult represents the subtitles fetched for a video
+type subtitlesResult struct {
+	ImdbID    string              `json:"imdb_id"`
+	Season    int                 `json:"season,omitempty"`
+	Episode   int                 `json:"episode,omitempty"`
+	Subtitles []polochon.Language `json:"subtitles"`
+}
+
+func subtitlesFetch(e *env, args []string) (interface{}, error) {
+	fs := newFlagSet("subtitles fetch")
+	season := fs.Int("season", 0, "season of the episode")
+	episode := fs.Int("episode", 0, "episode number")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() != 1 {
+		return nil, ErrMissingArgument
+	}
+	id := fs.Arg(0)
+
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	l, err := e.library()
+	if err != nil {
+		return nil, err
+	}
+
+	var video polochon.Subtitlable
+	if *season != 0 || *episode != 0 {
+		video, err = l.GetEpisode(id, *season, *episode)
+	} else {
+		video, err = l.GetMovie(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	subs, err := l.AddSubtitles(video, e.config.SubtitleLanguages, e.log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subtitlesResult{
+		ImdbID:    id,
+		Season:    *season,
+		Episode:   *episode,
+		Subtitles: subs,
+	}, nil
+}
+
+func modulesStatus(e *env, args []string) (interface{}, error) {
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	return e.config.ModulesStatus(), nil
+}
+
+func configValidate(e *env, args []string) (interface{}, error) {
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	return map[string]bool{"valid": true}, nil
+}
+
+func doctor(e *env, args []string) (interface{}, error) {
+	fs := newFlagSet("doctor")
+	repair := fs.Bool("repair", false, "repair the inconsistencies, only report them otherwise")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	return library.New(e.config).Doctor(*repair, e.log)
+}
+
+func rename(e *env, args []string) (interface{}, error) {
+	fs := newFlagSet("rename")
+	dryRun := fs.Bool("dry-run", false, "only report the files to rename")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := e.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	l, err := e.library()
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Rename(*dryRun, e.log)
+}
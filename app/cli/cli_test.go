@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/odwrtw/polochon/lib/library"
+)
+
+func TestFindCommand(t *testing.T) {
+	for _, tc := range []struct {
+		args         []string
+		expectedName string
+		expectedArgs []string
+	}{
+		{args: []string{"organize", "/tmp/video.mp4"}, expectedName: "organize", expectedArgs: []string{"/tmp/video.mp4"}},
+		{args: []string{"index", "stats"}, expectedName: "index stats", expectedArgs: []string{}},
+		{args: []string{"subtitles", "fetch", "-season", "1", "tt12345"}, expectedName: "subtitles fetch", expectedArgs: []string{"-season", "1", "tt12345"}},
+		{args: []string{"index"}},
+		{args: []string{"unknown"}},
+	} {
+		cmd, args := findCommand(tc.args)
+		if tc.expectedName == "" {
+			if cmd != nil {
+				t.Errorf("expected no command for %v, got %q", tc.args, cmd.name)
+			}
+			continue
+		}
+
+		if cmd == nil {
+			t.Errorf("expected command %q for %v, got nil", tc.expectedName, tc.args)
+			continue
+		}
+
+		if cmd.name != tc.expectedName {
+			t.Errorf("expected command %q, got %q", tc.expectedName, cmd.name)
+		}
+
+		if !reflect.DeepEqual(args, tc.expectedArgs) {
+			t.Errorf("expected args %v, got %v", tc.expectedArgs, args)
+		}
+	}
+}
+
+// newTestEnv returns an env using a configuration with an empty library in a
+// temporary directory
+func newTestEnv(t *testing.T) (*env, string) {
+	dir, err := ioutil.TempDir("", "polochon-cli")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, d := range []string{"movies", "shows"} {
+		if err := os.Mkdir(filepath.Join(dir, d), os.ModePerm); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	}
+
+	config := fmt.Sprintf(`
+modules_params: []
+logs:
+  level: panic
+video:
+  allowed_file_extensions:
+    - .mp4
+movie:
+  dir: %s
+show:
+  dir: %s
+`, filepath.Join(dir, "movies"), filepath.Join(dir, "shows"))
+
+	configPath := filepath.Join(dir, "config.yml")
+	if err := ioutil.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	return &env{configPath: configPath}, dir
+}
+
+// runCommand runs the command found in the args
+func runCommand(e *env, args ...string) (interface{}, error) {
+	cmd, args := findCommand(args)
+	if cmd == nil {
+		return nil, ErrUnknownCommand
+	}
+
+	return cmd.run(e, args)
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	if err := Run("config.yml", []string{"index"}); err != ErrUnknownCommand {
+		t.Errorf("expected error %q, got %q", ErrUnknownCommand, err)
+	}
+}
+
+func TestMissingArgument(t *testing.T) {
+	e, dir := newTestEnv(t)
+	defer os.RemoveAll(dir)
+
+	for _, args := range [][]string{
+		{"organize"},
+		{"organize", "a", "b"},
+		{"subtitles", "fetch"},
+		{"subtitles", "fetch", "-season", "1", "-episode", "2"},
+	} {
+		if _, err := runCommand(e, args...); err != ErrMissingArgument {
+			t.Errorf("expected error %q for %v, got %q", ErrMissingArgument, args, err)
+		}
+	}
+}
+
+func TestInvalidFlag(t *testing.T) {
+	e, dir := newTestEnv(t)
+	defer os.RemoveAll(dir)
+
+	for _, args := range [][]string{
+		{"download", "-unknown"},
+		{"subtitles", "fetch", "-season", "one", "tt12345"},
+		{"doctor", "-unknown"},
+		{"rename", "-unknown"},
+	} {
+		if _, err := runCommand(e, args...); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	e, dir := newTestEnv(t)
+	defer os.RemoveAll(dir)
+
+	got, err := runCommand(e, "config", "validate")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := map[string]bool{"valid": true}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	// An invalid configuration is reported as an error
+	if err := ioutil.WriteFile(e.configPath, []byte("modules_params: []\nmovie:\n  placement: random\n"), 0644); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if _, err := runCommand(e, "config", "validate"); err == nil {
+		t.Error("expected an error with an invalid configuration")
+	}
+
+	// So is a missing configuration
+	e.configPath = filepath.Join(dir, "missing.yml")
+	if _, err := runCommand(e, "config", "validate"); !os.IsNotExist(err) {
+		t.Errorf("expected a not exist error, got %q", err)
+	}
+}
+
+func TestIndexStats(t *testing.T) {
+	e, dir := newTestEnv(t)
+	defer os.RemoveAll(dir)
+
+	// Add a movie to the library
+	movieDir := filepath.Join(dir, "movies", "Movie (2000)")
+	if err := os.Mkdir(movieDir, os.ModePerm); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for name, content := range map[string]string{
+		"movie.mp4": "",
+		"movie.nfo": "<movie><id>tt12345</id><title>Movie</title><year>2000</year></movie>",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(movieDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"index", "stats"},
+		{"index", "rebuild"},
+	} {
+		got, err := runCommand(e, args...)
+		if err != nil {
+			t.Fatalf("expected no error for %v, got %q", args, err)
+		}
+
+		expected := &library.IndexStats{Movies: 1}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected %+v for %v, got %+v", expected, args, got)
+		}
+	}
+}
+
+func TestLibraryReports(t *testing.T) {
+	e, dir := newTestEnv(t)
+	defer os.RemoveAll(dir)
+
+	got, err := runCommand(e, "doctor")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	doctorReport, ok := got.(*library.DoctorReport)
+	if !ok {
+		t.Fatalf("expected a doctor report, got %T", got)
+	}
+
+	if doctorReport.Repair || len(doctorReport.Issues) != 0 {
+		t.Errorf("expected an empty dry-run report, got %+v", doctorReport)
+	}
+
+	got, err = runCommand(e, "rename", "-dry-run")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	renameReport, ok := got.(*library.RenameReport)
+	if !ok {
+		t.Fatalf("expected a rename report, got %T", got)
+	}
+
+	if !renameReport.DryRun || len(renameReport.Operations) != 0 {
+		t.Errorf("expected an empty dry-run report, got %+v", renameReport)
+	}
+}
@@ -0,0 +1,172 @@
+package diskguard
+
+import (
+	"sync"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/disk"
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of the checked directories
+const (
+	KindDownload = "download"
+	KindMovie    = "movie"
+	KindShow     = "show"
+)
+
+// DirStatus represents the free space of a checked directory
+type DirStatus struct {
+	disk.Usage
+	Kind  string `json:"kind"`
+	Low   bool   `json:"low"`
+	Error string `json:"error,omitempty"`
+}
+
+// Status represents the free space of the checked directories
+type Status struct {
+	MinFree uint64       `json:"min_free"`
+	Low     bool         `json:"low"`
+	Dirs    []*DirStatus `json:"dirs"`
+}
+
+// Fits returns true if one of the directories of a kind has enough free space
+// to store a file of the given size and stay above the minimum free space.
+// The directories which could not be read are not taken into account
+func (s *Status) Fits(kind string, size uint64) bool {
+	checked := false
+	for _, d := range s.Dirs {
+		if d.Kind != kind || d.Error != "" {
+			continue
+		}
+
+		if d.Free >= s.MinFree+size {
+			return true
+		}
+		checked = true
+	}
+
+	return !checked
+}
+
+// dir represents a checked directory
+type dir struct {
+	path string
+	kind string
+}
+
+// Guard checks the free space of the watched and library directories, the
+// notifiers are told when a directory goes below the minimum free space and
+// when it's back above it
+type Guard struct {
+	sync.Mutex
+	minFree   uint64
+	dirs      []dir
+	notifiers []polochon.Notifier
+	usage     func(string) (*disk.Usage, error)
+	// low holds the directories below the minimum free space at the last
+	// check
+	low map[string]bool
+}
+
+// New returns a new guard, it returns nil if the guard is disabled
+func New(config *configuration.Config) *Guard {
+	if !config.DiskGuard.Enabled {
+		return nil
+	}
+
+	g := &Guard{
+		minFree:   uint64(config.DiskGuard.MinFree),
+		notifiers: config.Notifiers,
+		usage:     disk.GetUsage,
+		low:       map[string]bool{},
+	}
+
+	for _, d := range config.Watcher.Dirs {
+		g.dirs = append(g.dirs, dir{path: d.Dir, kind: KindDownload})
+	}
+	for _, path := range config.Library.MovieRoots() {
+		g.dirs = append(g.dirs, dir{path: path, kind: KindMovie})
+	}
+	for _, path := range config.Library.ShowRoots() {
+		g.dirs = append(g.dirs, dir{path: path, kind: KindShow})
+	}
+
+	return g
+}
+
+// Status returns the free space of the directories
+func (g *Guard) Status() *Status {
+	s := &Status{
+		MinFree: g.minFree,
+		Dirs:    make([]*DirStatus, 0, len(g.dirs)),
+	}
+
+	for _, d := range g.dirs {
+		ds := &DirStatus{Kind: d.kind}
+		ds.Path = d.path
+
+		u, err := g.usage(d.path)
+		if err != nil {
+			ds.Error = err.Error()
+		} else {
+			ds.Usage = *u
+			ds.Low = u.Free < g.minFree
+		}
+
+		s.Low = s.Low || ds.Low
+		s.Dirs = append(s.Dirs, ds)
+	}
+
+	return s
+}
+
+// Check returns the free space of the directories and notifies the changes
+// since the last check
+func (g *Guard) Check(log *logrus.Entry) *Status {
+	s := g.Status()
+
+	g.Lock()
+	events := []*polochon.DiskSpaceEvent{}
+	for _, d := range s.Dirs {
+		if d.Error != "" || d.Low == g.low[d.Path] {
+			continue
+		}
+		g.low[d.Path] = d.Low
+
+		event := &polochon.DiskSpaceEvent{
+			Event:   polochon.DiskSpaceRecovered,
+			Path:    d.Path,
+			Free:    d.Free,
+			MinFree: g.minFree,
+		}
+		if d.Low {
+			event.Event = polochon.DiskSpaceLow
+		}
+		events = append(events, event)
+	}
+	g.Unlock()
+
+	for _, e := range events {
+		g.notify(e, log.WithField("path", e.Path))
+	}
+
+	return s
+}
+
+// notify sends a disk space event to the notifiers
+func (g *Guard) notify(event *polochon.DiskSpaceEvent, log *logrus.Entry) {
+	if event.Event == polochon.DiskSpaceLow {
+		log.Warnf("free space below %s", disk.Size(event.MinFree))
+	} else {
+		log.Infof("free space back above %s", disk.Size(event.MinFree))
+	}
+
+	log = log.WithField("function", "notify")
+	for _, n := range g.notifiers {
+		if err := n.Notify(event, log); err != nil {
+			log.Warnf("failed to send a notification from notifier: %q: %q", n.Name(), err)
+		}
+	}
+}
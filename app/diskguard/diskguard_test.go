@@ -0,0 +1,151 @@
+package diskguard
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/disk"
+	"github.com/sirupsen/logrus"
+)
+
+var mockLogEntry = logrus.NewEntry(&logrus.Logger{Out: ioutil.Discard})
+
+// fakeNotifier records the events it's notified of
+type fakeNotifier struct {
+	events []*polochon.DiskSpaceEvent
+}
+
+func (f *fakeNotifier) Init([]byte) error                      { return nil }
+func (f *fakeNotifier) Name() string                           { return "fake" }
+func (f *fakeNotifier) Status() (polochon.ModuleStatus, error) { return polochon.StatusOK, nil }
+func (f *fakeNotifier) Notify(i interface{}, log *logrus.Entry) error {
+	f.events = append(f.events, i.(*polochon.DiskSpaceEvent))
+	return nil
+}
+
+func newGuard(free map[string]uint64, n polochon.Notifier) *Guard {
+	g := New(&configuration.Config{
+		Watcher: configuration.WatcherConfig{
+			Dirs: []*configuration.WatchDir{{Dir: "/downloads"}},
+		},
+		Library: configuration.LibraryConfig{
+			MovieDir:  "/movies",
+			MovieDirs: []string{"/mnt/movies"},
+			ShowDir:   "/shows",
+		},
+		Notifiers: []polochon.Notifier{n},
+		DiskGuard: configuration.DiskGuardConfig{
+			Enabled: true,
+			MinFree: 100,
+		},
+	})
+
+	g.usage = func(path string) (*disk.Usage, error) {
+		f, ok := free[path]
+		if !ok {
+			return nil, fmt.Errorf("no such directory %q", path)
+		}
+
+		return &disk.Usage{Path: path, Total: 1000, Free: f, Used: 1000 - f}, nil
+	}
+
+	return g
+}
+
+func TestDisabled(t *testing.T) {
+	if g := New(&configuration.Config{}); g != nil {
+		t.Errorf("expected no guard, got %+v", g)
+	}
+}
+
+func TestStatusFits(t *testing.T) {
+	g := newGuard(map[string]uint64{
+		"/downloads":  150,
+		"/movies":     50,
+		"/mnt/movies": 400,
+	}, &fakeNotifier{})
+
+	s := g.Status()
+	if !s.Low {
+		t.Error("the status should be low")
+	}
+
+	for _, test := range []struct {
+		kind     string
+		size     uint64
+		expected bool
+	}{
+		{kind: KindDownload, size: 0, expected: true},
+		{kind: KindDownload, size: 50, expected: true},
+		{kind: KindDownload, size: 51, expected: false},
+		// The other movie root has enough space
+		{kind: KindMovie, size: 300, expected: true},
+		{kind: KindMovie, size: 301, expected: false},
+		// The show root can't be read
+		{kind: KindShow, size: 1000, expected: true},
+	} {
+		if got := s.Fits(test.kind, test.size); got != test.expected {
+			t.Errorf("%s of size %d: expected %t, got %t", test.kind, test.size, test.expected, got)
+		}
+	}
+}
+
+func TestCheckNotify(t *testing.T) {
+	free := map[string]uint64{
+		"/downloads":  500,
+		"/movies":     500,
+		"/mnt/movies": 500,
+		"/shows":      500,
+	}
+	n := &fakeNotifier{}
+	g := newGuard(free, n)
+
+	for _, step := range []struct {
+		name     string
+		update   func()
+		expected []*polochon.DiskSpaceEvent
+	}{
+		{
+			name:   "enough space",
+			update: func() {},
+		},
+		{
+			name:   "low download dir",
+			update: func() { free["/downloads"] = 10 },
+			expected: []*polochon.DiskSpaceEvent{
+				{Event: polochon.DiskSpaceLow, Path: "/downloads", Free: 10, MinFree: 100},
+			},
+		},
+		{
+			name:   "still low",
+			update: func() { free["/downloads"] = 20 },
+		},
+		{
+			name: "recovered and low show dir",
+			update: func() {
+				free["/downloads"] = 300
+				free["/shows"] = 0
+			},
+			expected: []*polochon.DiskSpaceEvent{
+				{Event: polochon.DiskSpaceRecovered, Path: "/downloads", Free: 300, MinFree: 100},
+				{Event: polochon.DiskSpaceLow, Path: "/shows", Free: 0, MinFree: 100},
+			},
+		},
+		{
+			name:   "unreadable dir",
+			update: func() { delete(free, "/shows") },
+		},
+	} {
+		n.events = nil
+		step.update()
+		g.Check(mockLogEntry)
+
+		if !reflect.DeepEqual(n.events, step.expected) {
+			t.Errorf("%s: expected %+v, got %+v", step.name, step.expected, n.events)
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package downloader
 
 import (
+	"sync"
+	"time"
+
 	"github.com/odwrtw/errors"
+	"github.com/odwrtw/polochon/app/diskguard"
 	"github.com/odwrtw/polochon/app/subapp"
 	polochon "github.com/odwrtw/polochon/lib"
 	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/disk"
+	"github.com/odwrtw/polochon/lib/history"
 	"github.com/odwrtw/polochon/lib/library"
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
@@ -13,24 +19,59 @@ import (
 // AppName is the application name
 const AppName = "downloader"
 
+// Custom errors
+var (
+	ErrNoReplacement  = errors.New("downloader: no replacement torrent found")
+	ErrNotEnoughSpace = errors.New("downloader: not enough free space for the torrent")
+)
+
 // Downloader represents the downloader
 type Downloader struct {
 	*subapp.Base
 
 	config  *configuration.Config
 	library *library.Library
+	history *history.History
+	guard   *diskguard.Guard
 	event   chan struct{}
+
+	// mu protects the searches, the status and the wishlist
+	mu sync.RWMutex
+	// searches keeps the last search time of the missing episodes
+	searches map[string]time.Time
+	status   *WishlistStatus
+	// wishlist is the last wishlist fetched, it's used to replace the
+	// torrents between two runs
+	wishlist *polochon.Wishlist
 }
 
-// New returns a new downloader
-func New(config *configuration.Config, vs *library.Library) *Downloader {
+// New returns a new downloader, the disk guard is optional and used to stop
+// grabbing when the disks are full
+func New(config *configuration.Config, vs *library.Library, h *history.History, g *diskguard.Guard) *Downloader {
 	return &Downloader{
-		Base:    subapp.NewBase(AppName),
-		config:  config,
-		library: vs,
+		Base:     subapp.NewBase(AppName),
+		config:   config,
+		library:  vs,
+		history:  h,
+		guard:    g,
+		searches: map[string]time.Time{},
+		status:   &WishlistStatus{Episodes: []*EpisodeStatus{}},
 	}
 }
 
+// History returns the grab history
+func (d *Downloader) History() *history.History {
+	return d.history
+}
+
+// WishlistStatus returns the search status of the missing episodes from the
+// last run
+func (d *Downloader) WishlistStatus() *WishlistStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.status
+}
+
 // Name returns the name of the app
 func (d *Downloader) Name() string {
 	return AppName
@@ -82,6 +123,15 @@ func (d *Downloader) Run(log *logrus.Entry) error {
 	return err
 }
 
+// Trigger launches the downloader without waiting for its schedule, it does
+// nothing if a launch is already pending
+func (d *Downloader) Trigger() {
+	select {
+	case d.event <- struct{}{}:
+	default:
+	}
+}
+
 func (d *Downloader) scheduler(log *logrus.Entry) {
 	c := cron.New()
 	c.Schedule(d.config.Downloader.Schedule, cron.FuncJob(func() {
@@ -108,177 +158,524 @@ func (d *Downloader) downloader(log *logrus.Entry) {
 	}
 }
 
+// Grab represents a torrent to be downloaded
+type Grab struct {
+	Torrent  *polochon.Torrent              `json:"torrent"`
+	Metadata *polochon.DownloadableMetadata `json:"metadata"`
+}
+
 func (d *Downloader) downloadMissingVideos(log *logrus.Entry) {
-	// Fetch wishlist
-	wl := polochon.NewWishlist(d.config.Wishlist, log)
-	if err := wl.Fetch(); err != nil {
+	start := time.Now()
+	defer func() {
+		runDuration.Observe(time.Since(start).Seconds())
+	}()
+
+	var space *diskguard.Status
+	if d.guard != nil {
+		space = d.guard.Check(log)
+		if !space.Fits(diskguard.KindDownload, 0) {
+			log.Warn("not enough free space to download, grabbing paused")
+			return
+		}
+	}
+
+	grabs, err := d.MissingVideos(log)
+	if err != nil {
 		log.Errorf("got an error while fetching wishlist: %q", err)
 		return
 	}
 
-	d.downloadMissingMovies(wl, log)
-	d.downloadMissingShows(wl, log)
-}
-
-func (d *Downloader) downloadMissingMovies(wl *polochon.Wishlist, log *logrus.Entry) {
-	logger := log.WithField("function", "download_movies")
+	// reserved is the size of the torrents grabbed during this run
+	var reserved uint64
+	for _, g := range grabs {
+		if d.stopped() {
+			log.Debug("downloader stopped, skipping the remaining downloads")
+			return
+		}
 
-	for _, wantedMovie := range wl.Movies {
-		log := logger.WithField("imdb_id", wantedMovie.ImdbID)
+		log := log.WithFields(logrus.Fields{
+			"type":    g.Metadata.Type,
+			"imdb_id": g.Metadata.ImdbID,
+		})
 
-		ok, err := d.library.HasMovie(wantedMovie.ImdbID)
-		if err != nil {
-			log.Error(err)
+		size := torrentSize(g.Torrent)
+		if space != nil && !grabFits(space, g, reserved+size) {
+			log.WithField("size", disk.Size(size)).Warn("not enough free space for the torrent, grab skipped")
 			continue
 		}
 
-		if ok {
-			log.Debugf("movie %q already in the video store", wantedMovie.ImdbID)
+		if err := d.Download(g, log); err != nil {
+			log.Error(err)
 			continue
 		}
+		reserved += size
+	}
+}
+
+// torrentSize returns the size of a torrent in bytes, 0 if it's unknown
+func torrentSize(t *polochon.Torrent) uint64 {
+	if t.Size < 0 {
+		return 0
+	}
+
+	return uint64(t.Size)
+}
 
-		m := polochon.NewMovie(d.config.Movie)
-		m.ImdbID = wantedMovie.ImdbID
+// grabFits returns true if the download directories can hold the torrents
+// of the run and the library can store the video of the grab
+func grabFits(space *diskguard.Status, g *Grab, reserved uint64) bool {
+	kind := diskguard.KindMovie
+	if g.Metadata.Type == "episode" {
+		kind = diskguard.KindShow
+	}
+
+	return space.Fits(diskguard.KindDownload, reserved) && space.Fits(kind, torrentSize(g.Torrent))
+}
+
+// Download sends the torrent to the downloader client and records it in the
+// grab history
+func (d *Downloader) Download(g *Grab, log *logrus.Entry) error {
+	err := d.config.Downloader.Client.Download(g.Torrent.URL, g.Metadata, log)
+	switch err {
+	case nil:
+	case polochon.ErrDuplicateTorrent:
+		// The torrent is already in the client, only record it
+		log.Debug("torrent already added")
+	default:
+		return err
+	}
+
+	torrentsGrabbed.WithLabelValues(g.Metadata.Type, g.Torrent.Source).Inc()
+	return d.history.Grabbed(g.Metadata, g.Torrent)
+}
+
+// inFlight returns true if a torrent has already been grabbed for the video
+// and is still in the downloader client
+func (d *Downloader) inFlight(m *polochon.DownloadableMetadata) bool {
+	e, err := d.history.Get(m)
+	if err != nil {
+		return false
+	}
 
-		if err := polochon.GetDetails(m, log); err != nil {
-			errors.LogErrors(log, err)
-			if errors.IsFatal(err) {
+	return e.InFlight()
+}
+
+// bestTorrent returns the first torrent matching the wanted qualities which
+// did not fail previously
+func (d *Downloader) bestTorrent(m *polochon.DownloadableMetadata, qualities []polochon.Quality, torrents []polochon.Torrent) *polochon.Torrent {
+	for _, q := range qualities {
+		for i := range torrents {
+			t := &torrents[i]
+			if t.Quality != q || d.history.IsBlacklisted(m, t.URL) {
 				continue
 			}
+
+			return t
 		}
+	}
 
-		log = log.WithField("title", m.Title)
+	return nil
+}
 
-		if err := polochon.GetTorrents(m, log); err != nil && err != polochon.ErrMovieTorrentNotFound {
-			errors.LogErrors(log, err)
-			if errors.IsFatal(err) {
-				continue
+// MissingVideos returns the torrents to download to get the wished videos
+// missing from the library
+func (d *Downloader) MissingVideos(log *logrus.Entry) ([]*Grab, error) {
+	// Fetch wishlist
+	wl := polochon.NewWishlist(d.config.Wishlist, log)
+	if err := wl.Fetch(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.wishlist = wl
+	d.mu.Unlock()
+
+	grabs := d.missingMovies(wl, log)
+	grabs = append(grabs, d.missingShows(wl, log)...)
+
+	return grabs, nil
+}
+
+// lastWishlist returns the wishlist of the last run, it's fetched if the
+// downloader did not run yet
+func (d *Downloader) lastWishlist(log *logrus.Entry) (*polochon.Wishlist, error) {
+	d.mu.RLock()
+	wl := d.wishlist
+	d.mu.RUnlock()
+
+	if wl != nil {
+		return wl, nil
+	}
+
+	wl = polochon.NewWishlist(d.config.Wishlist, log)
+	if err := wl.Fetch(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.wishlist = wl
+	d.mu.Unlock()
+
+	return wl, nil
+}
+
+// Replace grabs the next best torrent of a wished video whose previous
+// torrent failed, the wished video is looked up in the wishlist of the last
+// run
+func (d *Downloader) Replace(m *polochon.DownloadableMetadata, log *logrus.Entry) (*Grab, error) {
+	wl, err := d.lastWishlist(log)
+	if err != nil {
+		return nil, err
+	}
+
+	var g *Grab
+	switch m.Type {
+	case "movie":
+		for _, wantedMovie := range wl.Movies {
+			if wantedMovie.ImdbID == m.ImdbID {
+				g = d.movieGrab(wantedMovie, log.WithField("imdb_id", m.ImdbID))
+				break
 			}
 		}
+	case "episode":
+		for _, wishedShow := range wl.Shows {
+			if wishedShow.ImdbID != m.ImdbID {
+				continue
+			}
 
-		var torrent *polochon.Torrent
-	quality_loop:
-		for _, q := range wantedMovie.Qualities {
-			for _, t := range m.Torrents {
-				if t.Quality == q {
-					torrent = &t
-					break quality_loop
+			log := log.WithField("imdb_id", m.ImdbID)
+			s := polochon.NewShow(d.config.Show)
+			s.ImdbID = wishedShow.ImdbID
+			if err := polochon.GetDetails(s, log); err != nil {
+				errors.LogErrors(log, err)
+				if errors.IsFatal(err) {
+					return nil, err
 				}
 			}
+
+			if metadata := d.episodeWanted(m.ImdbID, m.Season, m.Episode, log); metadata != nil {
+				g = d.episodeGrab(s, wishedShow, metadata, log)
+			}
+			break
 		}
+	}
 
-		if torrent == nil {
-			log.Debug("no torrent found")
-			continue
+	if g == nil {
+		return nil, ErrNoReplacement
+	}
+
+	if d.guard != nil && !grabFits(d.guard.Check(log), g, torrentSize(g.Torrent)) {
+		return nil, ErrNotEnoughSpace
+	}
+
+	if err := d.Download(g, log); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (d *Downloader) missingMovies(wl *polochon.Wishlist, log *logrus.Entry) []*Grab {
+	logger := log.WithField("function", "download_movies")
+
+	// Each worker writes the result of its movie in its own slot
+	results := make([]*Grab, len(wl.Movies))
+	d.forEach(len(wl.Movies), logger, func(i int) {
+		wantedMovie := wl.Movies[i]
+		log := logger.WithField("imdb_id", wantedMovie.ImdbID)
+
+		results[i] = d.movieGrab(wantedMovie, log)
+		itemsProcessed.WithLabelValues("movie").Inc()
+	})
+
+	grabs := []*Grab{}
+	for _, g := range results {
+		if g != nil {
+			grabs = append(grabs, g)
 		}
+	}
 
-		metadata := &polochon.DownloadableMetadata{
-			Type:    "movie",
-			ImdbID:  m.ImdbID,
-			Quality: torrent.Quality,
+	return grabs
+}
+
+// movieGrab returns the torrent to download for a wished movie, or nil if
+// there is nothing to download
+func (d *Downloader) movieGrab(wantedMovie *polochon.WishedMovie, log *logrus.Entry) *Grab {
+	ok, err := d.library.HasMovie(wantedMovie.ImdbID)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+
+	if ok {
+		log.Debugf("movie %q already in the video store", wantedMovie.ImdbID)
+		return nil
+	}
+
+	metadata := &polochon.DownloadableMetadata{
+		Type:   "movie",
+		ImdbID: wantedMovie.ImdbID,
+	}
+
+	if d.inFlight(metadata) {
+		log.Debug("movie already grabbed")
+		return nil
+	}
+
+	m := polochon.NewMovie(d.config.Movie)
+	m.ImdbID = wantedMovie.ImdbID
+
+	if err := polochon.GetDetails(m, log); err != nil {
+		errors.LogErrors(log, err)
+		if errors.IsFatal(err) {
+			return nil
 		}
+	}
 
-		if err := d.config.Downloader.Client.Download(torrent.URL, metadata, log); err != nil {
-			log.Error(err)
-			continue
+	log = log.WithField("title", m.Title)
+
+	if err := polochon.GetTorrents(m, log); err != nil && err != polochon.ErrMovieTorrentNotFound {
+		errors.LogErrors(log, err)
+		if errors.IsFatal(err) {
+			return nil
 		}
 	}
+
+	torrent := d.bestTorrent(metadata, wantedMovie.Qualities, m.Torrents)
+	if torrent == nil {
+		log.Debug("no torrent found")
+		return nil
+	}
+
+	metadata.Quality = torrent.Quality
+	return &Grab{
+		Torrent:  torrent,
+		Metadata: metadata,
+	}
 }
 
-func (d *Downloader) downloadMissingShows(wl *polochon.Wishlist, log *logrus.Entry) {
+func (d *Downloader) missingShows(wl *polochon.Wishlist, log *logrus.Entry) []*Grab {
 	logger := log.WithField("function", "download_shows")
+	now := time.Now()
 
-	for _, wishedShow := range wl.Shows {
+	// Each worker writes the result of its show in its own slot
+	type showResult struct {
+		grabs    []*Grab
+		episodes []*EpisodeStatus
+	}
+	results := make([]showResult, len(wl.Shows))
+	d.forEach(len(wl.Shows), logger, func(i int) {
+		wishedShow := wl.Shows[i]
 		log := logger.WithField("imdb_id", wishedShow.ImdbID)
 
-		s := polochon.NewShow(d.config.Show)
-		s.ImdbID = wishedShow.ImdbID
+		results[i].grabs, results[i].episodes = d.showGrabs(wishedShow, now, log)
+		itemsProcessed.WithLabelValues("show").Inc()
+	})
 
-		if err := polochon.GetDetails(s, log); err != nil {
-			errors.LogErrors(log, err)
-			if errors.IsFatal(err) {
-				continue
-			}
+	grabs := []*Grab{}
+	status := &WishlistStatus{
+		LastRun:  &now,
+		Episodes: []*EpisodeStatus{},
+	}
+	// Only the searches of the episodes still missing are kept
+	searched := map[string]struct{}{}
+	for _, r := range results {
+		grabs = append(grabs, r.grabs...)
+		status.Episodes = append(status.Episodes, r.episodes...)
+		for _, es := range r.episodes {
+			searched[episodeID(es)] = struct{}{}
 		}
+	}
 
-		calendar, err := s.GetCalendar(log)
-		if err != nil {
-			errors.LogErrors(log, err)
-			if errors.IsFatal(err) {
-				continue
-			}
+	d.mu.Lock()
+	d.status = status
+	for id := range d.searches {
+		if _, ok := searched[id]; !ok {
+			delete(d.searches, id)
 		}
+	}
+	d.mu.Unlock()
 
-		for _, calEpisode := range calendar.Episodes {
-			if calEpisode.Season == 0 {
-				// Skip the show "Specials" episodes
-				continue
-			}
+	return grabs
+}
 
-			// Check if the episode should be downloaded
-			if calEpisode.IsOlder(wishedShow) {
-				continue
-			}
+// showGrabs returns the torrents to download for the missing episodes of a
+// wished show along with the search status of the episodes
+func (d *Downloader) showGrabs(wishedShow *polochon.WishedShow, now time.Time, log *logrus.Entry) ([]*Grab, []*EpisodeStatus) {
+	grabs := []*Grab{}
+	episodes := []*EpisodeStatus{}
 
-			// Check if the episode has already been downloaded
-			ok, err := d.library.HasShowEpisode(wishedShow.ImdbID, calEpisode.Season, calEpisode.Episode)
-			if err != nil {
-				log.Error(err)
-				continue
-			}
+	s := polochon.NewShow(d.config.Show)
+	s.ImdbID = wishedShow.ImdbID
 
-			if ok {
-				continue
-			}
+	if err := polochon.GetDetails(s, log); err != nil {
+		errors.LogErrors(log, err)
+		if errors.IsFatal(err) {
+			return grabs, episodes
+		}
+	}
 
-			// Setup the episode
-			e := polochon.NewShowEpisode(d.config.Show)
-			e.ShowImdbID = wishedShow.ImdbID
-			e.ShowTitle = s.Title
-			e.Season = calEpisode.Season
-			e.Episode = calEpisode.Episode
-			log = log.WithFields(logrus.Fields{
-				"show_imdb_id": e.ShowImdbID,
-				"show_title":   e.ShowTitle,
-				"season":       e.Season,
-				"episode":      e.Episode,
-			})
-
-			if err := polochon.GetTorrents(e, log); err != nil && err != polochon.ErrShowEpisodeTorrentNotFound {
-				errors.LogErrors(log, err)
-				if errors.IsFatal(err) {
-					continue
-				}
-			}
+	calendar, err := s.GetCalendar(log)
+	if err != nil {
+		errors.LogErrors(log, err)
+		if errors.IsFatal(err) {
+			return grabs, episodes
+		}
+	}
 
-			var torrent *polochon.Torrent
-		quality_loop:
-			for _, q := range wishedShow.Qualities {
-				for _, t := range e.Torrents {
-					if t.Quality == q {
-						torrent = &t
-						break quality_loop
-					}
-				}
-			}
+	for _, calEpisode := range calendar.Episodes {
+		if calEpisode.Season == 0 {
+			// Skip the show "Specials" episodes
+			continue
+		}
 
-			if torrent == nil {
-				log.Debug("no torrent found")
-				continue
-			}
+		// Check if the episode should be downloaded
+		if calEpisode.IsOlder(wishedShow) {
+			continue
+		}
 
-			metadata := &polochon.DownloadableMetadata{
-				Type:    "episode",
-				ImdbID:  e.ShowImdbID,
-				Quality: torrent.Quality,
-				Season:  e.Season,
-				Episode: e.Episode,
-			}
+		metadata := d.episodeWanted(wishedShow.ImdbID, calEpisode.Season, calEpisode.Episode, log)
+		if metadata == nil {
+			continue
+		}
 
-			if err := d.config.Downloader.Client.Download(torrent.URL, metadata, log); err != nil {
-				log.Error(err)
-				continue
-			}
+		es := d.searchEpisode(metadata, calEpisode, now)
+		episodes = append(episodes, es)
+		if es.Status != statusSearch {
+			continue
+		}
+
+		g := d.episodeGrab(s, wishedShow, metadata, log)
+		if g == nil {
+			es.Status = StatusNotFound
+			continue
 		}
+
+		es.Status = StatusGrabbed
+		grabs = append(grabs, g)
+	}
+
+	return grabs, episodes
+}
+
+// episodeID returns the history ID of the episode of a search status
+func episodeID(es *EpisodeStatus) string {
+	return history.ID(&polochon.DownloadableMetadata{
+		Type:    "episode",
+		ImdbID:  es.ImdbID,
+		Season:  es.Season,
+		Episode: es.Episode,
+	})
+}
+
+// searchEpisode returns the search status of a missing episode, the search
+// is recorded if the episode has to be searched
+func (d *Downloader) searchEpisode(m *polochon.DownloadableMetadata, calEpisode *polochon.ShowCalendarEpisode, now time.Time) *EpisodeStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := history.ID(m)
+	es := &EpisodeStatus{
+		ImdbID:    m.ImdbID,
+		Season:    m.Season,
+		Episode:   m.Episode,
+		AiredDate: calEpisode.AiredDate,
+	}
+
+	lastSearch := d.searches[id]
+	if !lastSearch.IsZero() {
+		es.LastSearch = &lastSearch
+	}
+
+	status, next := searchState(d.config.Downloader.EpisodeSearch, m.ImdbID, calEpisode.AiredDate, lastSearch, now)
+	es.Status = status
+	if !next.IsZero() && status != statusSearch {
+		es.NextSearch = &next
+	}
+
+	if status == statusSearch {
+		d.searches[id] = now
+		es.LastSearch = &now
+	}
+
+	return es
+}
+
+// episodeWanted returns the metadata of an episode if it's neither in the
+// library nor already grabbed, nil otherwise
+func (d *Downloader) episodeWanted(imdbID string, season, episode int, log *logrus.Entry) *polochon.DownloadableMetadata {
+	// Check if the episode has already been downloaded
+	ok, err := d.library.HasShowEpisode(imdbID, season, episode)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+
+	if ok {
+		return nil
+	}
+
+	metadata := &polochon.DownloadableMetadata{
+		Type:    "episode",
+		ImdbID:  imdbID,
+		Season:  season,
+		Episode: episode,
+	}
+
+	if d.inFlight(metadata) {
+		return nil
+	}
+
+	return metadata
+}
+
+// episodeGrab returns the torrent to download for an episode of a wished
+// show, or nil if no torrent is found
+func (d *Downloader) episodeGrab(s *polochon.Show, wishedShow *polochon.WishedShow, metadata *polochon.DownloadableMetadata, log *logrus.Entry) *Grab {
+	// Setup the episode
+	e := polochon.NewShowEpisode(d.config.Show)
+	e.ShowImdbID = wishedShow.ImdbID
+	e.ShowTitle = s.Title
+	e.Season = metadata.Season
+	e.Episode = metadata.Episode
+
+	// The anime releases are numbered from the first episode of the show
+	if d.config.Show.IsAnime(wishedShow.ImdbID) {
+		e.AbsoluteNumber = s.AbsoluteNumber(e.Season, e.Episode)
+	}
+
+	// The daily shows releases are named after the air date
+	if d.config.Show.IsDaily(wishedShow.ImdbID) {
+		if se := s.GetEpisode(e.Season, e.Episode); se != nil {
+			e.Aired = se.Aired
+		}
+	}
+
+	log = log.WithFields(logrus.Fields{
+		"show_imdb_id": e.ShowImdbID,
+		"show_title":   e.ShowTitle,
+		"season":       e.Season,
+		"episode":      e.Episode,
+		"absolute":     e.AbsoluteNumber,
+		"aired":        e.Aired,
+	})
+
+	if err := polochon.GetTorrents(e, log); err != nil && err != polochon.ErrShowEpisodeTorrentNotFound {
+		errors.LogErrors(log, err)
+		if errors.IsFatal(err) {
+			return nil
+		}
+	}
+
+	torrent := d.bestTorrent(metadata, wishedShow.Qualities, e.Torrents)
+	if torrent == nil {
+		log.Debug("no torrent found")
+		return nil
+	}
+
+	metadata.Quality = torrent.Quality
+	return &Grab{
+		Torrent:  torrent,
+		Metadata: metadata,
 	}
 }
@@ -0,0 +1,44 @@
+package downloader
+
+import (
+	"testing"
+
+	"github.com/odwrtw/polochon/app/diskguard"
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/disk"
+)
+
+func TestGrabFits(t *testing.T) {
+	space := &diskguard.Status{
+		MinFree: 100,
+		Dirs: []*diskguard.DirStatus{
+			{Usage: disk.Usage{Path: "/downloads", Free: 1000}, Kind: diskguard.KindDownload},
+			{Usage: disk.Usage{Path: "/movies", Free: 500}, Kind: diskguard.KindMovie},
+			{Usage: disk.Usage{Path: "/shows", Free: 2000}, Kind: diskguard.KindShow},
+		},
+	}
+
+	grab := func(videoType string, size int) *Grab {
+		return &Grab{
+			Torrent:  &polochon.Torrent{Size: size},
+			Metadata: &polochon.DownloadableMetadata{Type: videoType},
+		}
+	}
+
+	for _, test := range []struct {
+		name     string
+		grab     *Grab
+		reserved uint64
+		expected bool
+	}{
+		{name: "unknown size", grab: grab("movie", 0), expected: true},
+		{name: "movie fits", grab: grab("movie", 400), reserved: 400, expected: true},
+		{name: "movie too big for the library", grab: grab("movie", 401), reserved: 401, expected: false},
+		{name: "episode fits", grab: grab("episode", 900), reserved: 900, expected: true},
+		{name: "previous grabs fill the downloads", grab: grab("episode", 500), reserved: 901, expected: false},
+	} {
+		if got := grabFits(space, test.grab, test.reserved); got != test.expected {
+			t.Errorf("%s: expected %t, got %t", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package downloader
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	runDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "polochon",
+		Subsystem: "downloader",
+		Name:      "run_duration_seconds",
+		Help:      "Duration of the downloader runs.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14),
+	})
+
+	itemsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polochon",
+		Subsystem: "downloader",
+		Name:      "items_processed_total",
+		Help:      "Number of wished items processed by type.",
+	}, []string{"type"})
+
+	torrentsGrabbed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polochon",
+		Subsystem: "downloader",
+		Name:      "torrents_grabbed_total",
+		Help:      "Number of torrents sent to the downloader client by type and torrenter.",
+	}, []string{"type", "torrenter"})
+)
@@ -0,0 +1,66 @@
+package downloader
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// forEach calls f with each index from 0 to n using a bounded number of
+// workers, it stops dispatching the items once the downloader is done
+func (d *Downloader) forEach(n int, log *logrus.Entry, f func(i int)) {
+	workers := d.config.Downloader.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+
+	items := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				work(i, log, f)
+			}
+		}()
+	}
+
+	for i := 0; i < n && !d.stopped(); i++ {
+		select {
+		case items <- i:
+		case <-d.Done:
+		}
+	}
+
+	close(items)
+	wg.Wait()
+
+	if d.stopped() {
+		log.Debug("downloader stopped, the remaining items were skipped")
+	}
+}
+
+// work calls f with the index, a panic only stops the current item
+func work(i int, log *logrus.Entry, f func(i int)) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("panic recovered while processing an item: %v", r)
+		}
+	}()
+
+	f(i)
+}
+
+// stopped returns true once the downloader is done
+func (d *Downloader) stopped() bool {
+	select {
+	case <-d.Done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,82 @@
+package downloader
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/odwrtw/polochon/app/subapp"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/sirupsen/logrus"
+)
+
+func newTestDownloader(workers int) *Downloader {
+	return &Downloader{
+		Base: subapp.NewBase(AppName),
+		config: &configuration.Config{
+			Downloader: configuration.DownloaderConfig{Workers: workers},
+		},
+	}
+}
+
+func TestForEach(t *testing.T) {
+	d := newTestDownloader(3)
+	log := logrus.NewEntry(logrus.New())
+
+	var mu sync.Mutex
+	var running, max int
+	done := make([]bool, 20)
+	d.forEach(len(done), log, func(i int) {
+		mu.Lock()
+		running++
+		if running > max {
+			max = running
+		}
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+
+		time.Sleep(time.Millisecond)
+		if i == 5 {
+			panic("item failure")
+		}
+
+		mu.Lock()
+		done[i] = true
+		mu.Unlock()
+	})
+
+	if max > 3 {
+		t.Errorf("expected at most 3 workers, got %d", max)
+	}
+
+	for i, ok := range done {
+		if !ok && i != 5 {
+			t.Errorf("item %d not processed", i)
+		}
+	}
+}
+
+func TestForEachDone(t *testing.T) {
+	d := newTestDownloader(1)
+	d.Done = make(chan struct{})
+	log := logrus.NewEntry(logrus.New())
+
+	count := 0
+	d.forEach(10, log, func(i int) {
+		count++
+		if i == 2 {
+			close(d.Done)
+		}
+	})
+
+	// The item being dispatched when the downloader stops might still be
+	// processed
+	if count != 3 && count != 4 {
+		t.Errorf("expected the dispatch to stop, got %d items processed", count)
+	}
+}
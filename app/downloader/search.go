@@ -0,0 +1,80 @@
+package downloader
+
+import (
+	"time"
+
+	"github.com/odwrtw/polochon/lib/configuration"
+)
+
+// Search status of the missing episodes
+const (
+	StatusNotAired  = "not_aired"
+	StatusScheduled = "scheduled"
+	StatusNotFound  = "not_found"
+	StatusGrabbed   = "grabbed"
+	StatusGivenUp   = "given_up"
+	statusSearch    = "search"
+)
+
+// searchIntervalRatio is the ratio between the time since an episode aired
+// and the time between two searches: an episode aired 10 hours ago is
+// searched every hour, an episode aired 10 days ago once a day
+const searchIntervalRatio = 10
+
+// EpisodeStatus represents the search status of a missing episode
+type EpisodeStatus struct {
+	ImdbID     string     `json:"imdb_id"`
+	Season     int        `json:"season"`
+	Episode    int        `json:"episode"`
+	AiredDate  *time.Time `json:"aired_date"`
+	Status     string     `json:"status"`
+	LastSearch *time.Time `json:"last_search,omitempty"`
+	NextSearch *time.Time `json:"next_search,omitempty"`
+}
+
+// WishlistStatus represents the search status of the wished episodes
+// missing from the library
+type WishlistStatus struct {
+	LastRun  *time.Time       `json:"last_run"`
+	Episodes []*EpisodeStatus `json:"episodes"`
+}
+
+// searchState returns the search status of a missing episode and the time
+// of its next search
+func searchState(conf configuration.EpisodeSearchConfig, imdbID string, aired *time.Time, lastSearch, now time.Time) (string, time.Time) {
+	// Without air date, the episode might be available
+	if aired == nil {
+		return statusSearch, now
+	}
+
+	// A zero air date means that the episode is announced without a date
+	if aired.IsZero() {
+		return StatusNotAired, time.Time{}
+	}
+
+	availableAt := aired.Add(conf.ShowAirDelay(imdbID))
+	if now.Before(availableAt) {
+		return StatusNotAired, availableAt
+	}
+
+	age := now.Sub(availableAt)
+	if conf.GiveUpAfter > 0 && age > conf.GiveUpAfter {
+		return StatusGivenUp, time.Time{}
+	}
+
+	if lastSearch.IsZero() {
+		return statusSearch, now
+	}
+
+	interval := age / searchIntervalRatio
+	if conf.MaxSearchInterval > 0 && interval > conf.MaxSearchInterval {
+		interval = conf.MaxSearchInterval
+	}
+
+	next := lastSearch.Add(interval)
+	if now.Before(next) {
+		return StatusScheduled, next
+	}
+
+	return statusSearch, now
+}
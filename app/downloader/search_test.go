@@ -0,0 +1,114 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/odwrtw/polochon/lib/configuration"
+)
+
+func TestSearchState(t *testing.T) {
+	conf := configuration.EpisodeSearchConfig{
+		AirDelay:          2 * time.Hour,
+		GiveUpAfter:       30 * 24 * time.Hour,
+		MaxSearchInterval: 24 * time.Hour,
+		Shows: map[string]configuration.ShowSearchConfig{
+			"tt0944947": {AirDelay: 12 * time.Hour},
+		},
+	}
+
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	for _, tc := range []struct {
+		name           string
+		imdbID         string
+		aired          *time.Time
+		lastSearch     time.Time
+		expectedStatus string
+		expectedNext   time.Time
+	}{
+		{
+			name:           "no air date",
+			imdbID:         "tt2085059",
+			expectedStatus: statusSearch,
+			expectedNext:   now,
+		},
+		{
+			name:           "zero air date",
+			imdbID:         "tt2085059",
+			aired:          &time.Time{},
+			expectedStatus: StatusNotAired,
+		},
+		{
+			name:           "future episode",
+			imdbID:         "tt2085059",
+			aired:          at(24 * time.Hour),
+			expectedStatus: StatusNotAired,
+			expectedNext:   now.Add(26 * time.Hour),
+		},
+		{
+			name:           "within the air delay",
+			imdbID:         "tt2085059",
+			aired:          at(-time.Hour),
+			expectedStatus: StatusNotAired,
+			expectedNext:   now.Add(time.Hour),
+		},
+		{
+			name:           "within the show air delay",
+			imdbID:         "tt0944947",
+			aired:          at(-6 * time.Hour),
+			expectedStatus: StatusNotAired,
+			expectedNext:   now.Add(6 * time.Hour),
+		},
+		{
+			name:           "never searched",
+			imdbID:         "tt2085059",
+			aired:          at(-3 * time.Hour),
+			expectedStatus: statusSearch,
+			expectedNext:   now,
+		},
+		{
+			name:           "recently searched",
+			imdbID:         "tt2085059",
+			aired:          at(-22 * time.Hour),
+			lastSearch:     now.Add(-time.Hour),
+			expectedStatus: StatusScheduled,
+			expectedNext:   now.Add(time.Hour),
+		},
+		{
+			name:           "search again",
+			imdbID:         "tt2085059",
+			aired:          at(-22 * time.Hour),
+			lastSearch:     now.Add(-3 * time.Hour),
+			expectedStatus: statusSearch,
+			expectedNext:   now,
+		},
+		{
+			name:           "capped interval",
+			imdbID:         "tt2085059",
+			aired:          at(-20 * 24 * time.Hour),
+			lastSearch:     now.Add(-23 * time.Hour),
+			expectedStatus: StatusScheduled,
+			expectedNext:   now.Add(time.Hour),
+		},
+		{
+			name:           "given up",
+			imdbID:         "tt2085059",
+			aired:          at(-31 * 24 * time.Hour),
+			expectedStatus: StatusGivenUp,
+		},
+	} {
+		status, next := searchState(conf, tc.imdbID, tc.aired, tc.lastSearch, now)
+		if status != tc.expectedStatus {
+			t.Errorf("%s: expected status %q, got %q", tc.name, tc.expectedStatus, status)
+		}
+
+		if !next.Equal(tc.expectedNext) {
+			t.Errorf("%s: expected next search at %s, got %s", tc.name, tc.expectedNext, next)
+		}
+	}
+}
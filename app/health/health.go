@@ -0,0 +1,119 @@
+package health
+
+import (
+	"sync"
+
+	"github.com/odwrtw/polochon/app/diskguard"
+	"github.com/odwrtw/polochon/app/safeguard"
+	"github.com/odwrtw/polochon/app/subapp"
+)
+
+// Health holds the state of the app reported by the liveness and readiness
+// probes
+type Health struct {
+	sync.Mutex
+	safeguard  *safeguard.Safeguard
+	critical   map[string]bool
+	indexReady bool
+	errors     map[string]string
+	diskGuard  *diskguard.Guard
+}
+
+// AppReport represents the state of a sub app
+type AppReport struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LastError string `json:"last_error,omitempty"`
+	Restarts  int    `json:"restarts"`
+	PanicLoop bool   `json:"panic_loop"`
+}
+
+// Report represents the state of the app
+type Report struct {
+	Ready      bool              `json:"ready"`
+	IndexReady bool              `json:"index_ready"`
+	Apps       []*AppReport      `json:"apps"`
+	DiskSpace  *diskguard.Status `json:"disk_space,omitempty"`
+}
+
+// New returns a new health, the app is not ready while one of the critical
+// sub apps is not started or in a panic loop
+func New(s *safeguard.Safeguard, critical ...string) *Health {
+	h := &Health{
+		safeguard: s,
+		critical:  map[string]bool{},
+		errors:    map[string]string{},
+	}
+
+	for _, name := range critical {
+		h.critical[name] = true
+	}
+
+	return h
+}
+
+// SetIndexReady sets whether the library index is built
+func (h *Health) SetIndexReady(ready bool) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.indexReady = ready
+}
+
+// SetDiskGuard sets the disk guard reporting the free space of the
+// directories, the low disk space does not make the app unready
+func (h *Health) SetDiskGuard(g *diskguard.Guard) {
+	h.Lock()
+	defer h.Unlock()
+
+	h.diskGuard = g
+}
+
+// SetError keeps the last error returned by a sub app
+func (h *Health) SetError(app string, err error) {
+	if err == nil {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	h.errors[app] = err.Error()
+}
+
+// Report returns the state of the app and of the given sub apps
+func (h *Health) Report(apps []subapp.App) *Report {
+	h.Lock()
+	defer h.Unlock()
+
+	r := &Report{
+		Ready:      h.indexReady,
+		IndexReady: h.indexReady,
+		Apps:       make([]*AppReport, 0, len(apps)),
+	}
+
+	for _, app := range apps {
+		name := app.Name()
+		ar := &AppReport{
+			Name:      name,
+			Status:    app.Status().String(),
+			Critical:  h.critical[name],
+			LastError: h.errors[name],
+			Restarts:  h.safeguard.Restarts(name),
+			PanicLoop: h.safeguard.PanicLoop(name),
+		}
+
+		if ar.Critical && (app.Status() != subapp.Started || ar.PanicLoop) {
+			r.Ready = false
+		}
+
+		r.Apps = append(r.Apps, ar)
+	}
+
+	if h.diskGuard != nil {
+		r.DiskSpace = h.diskGuard.Status()
+	}
+
+	return r
+}
@@ -0,0 +1,152 @@
+package health
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/odwrtw/polochon/app/diskguard"
+	"github.com/odwrtw/polochon/app/safeguard"
+	"github.com/odwrtw/polochon/app/subapp"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeApp is a sub app doing nothing
+type fakeApp struct {
+	*subapp.Base
+}
+
+func (f *fakeApp) Run(log *logrus.Entry) error {
+	return nil
+}
+
+func newFakeApp(name string, status subapp.Status) *fakeApp {
+	app := &fakeApp{Base: subapp.NewBase(name)}
+	app.AppStatus = status
+	return app
+}
+
+func TestReport(t *testing.T) {
+	organizer := newFakeApp("organizer", subapp.Started)
+	cleaner := newFakeApp("cleaner", subapp.Stopped)
+	apps := []subapp.App{organizer, cleaner}
+
+	s := safeguard.New()
+	h := New(s, "organizer")
+
+	for _, step := range []struct {
+		name     string
+		update   func()
+		expected *Report
+	}{
+		{
+			name:   "index loading",
+			update: func() {},
+			expected: &Report{
+				Apps: []*AppReport{
+					{Name: "organizer", Status: "started", Critical: true},
+					{Name: "cleaner", Status: "stopped"},
+				},
+			},
+		},
+		{
+			name:   "index ready",
+			update: func() { h.SetIndexReady(true) },
+			expected: &Report{
+				Ready:      true,
+				IndexReady: true,
+				Apps: []*AppReport{
+					{Name: "organizer", Status: "started", Critical: true},
+					{Name: "cleaner", Status: "stopped"},
+				},
+			},
+		},
+		{
+			name: "non critical app failing",
+			update: func() {
+				h.SetError("cleaner", errors.New("transmission is down"))
+				s.Event("cleaner")
+				s.Event("cleaner")
+			},
+			expected: &Report{
+				Ready:      true,
+				IndexReady: true,
+				Apps: []*AppReport{
+					{Name: "organizer", Status: "started", Critical: true},
+					{Name: "cleaner", Status: "stopped", LastError: "transmission is down", Restarts: 2, PanicLoop: true},
+				},
+			},
+		},
+		{
+			name: "critical app restarted once",
+			update: func() {
+				h.SetError("organizer", errors.New("panic"))
+				s.Event("organizer")
+			},
+			expected: &Report{
+				Ready:      true,
+				IndexReady: true,
+				Apps: []*AppReport{
+					{Name: "organizer", Status: "started", Critical: true, LastError: "panic", Restarts: 1},
+					{Name: "cleaner", Status: "stopped", LastError: "transmission is down", Restarts: 2, PanicLoop: true},
+				},
+			},
+		},
+		{
+			name:   "critical app in a panic loop",
+			update: func() { s.Event("organizer") },
+			expected: &Report{
+				IndexReady: true,
+				Apps: []*AppReport{
+					{Name: "organizer", Status: "started", Critical: true, LastError: "panic", Restarts: 2, PanicLoop: true},
+					{Name: "cleaner", Status: "stopped", LastError: "transmission is down", Restarts: 2, PanicLoop: true},
+				},
+			},
+		},
+	} {
+		step.update()
+
+		got := h.Report(apps)
+		if !reflect.DeepEqual(got, step.expected) {
+			t.Errorf("%s: expected %+v, got %+v", step.name, step.expected, got)
+		}
+	}
+}
+
+func TestReportDiskSpace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-health")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	defer os.RemoveAll(dir)
+
+	h := New(safeguard.New())
+	h.SetDiskGuard(diskguard.New(&configuration.Config{
+		Watcher: configuration.WatcherConfig{
+			Dirs: []*configuration.WatchDir{{Dir: dir}},
+		},
+		DiskGuard: configuration.DiskGuardConfig{
+			Enabled: true,
+			MinFree: 1,
+		},
+	}))
+
+	got := h.Report(nil).DiskSpace
+	if got == nil || len(got.Dirs) != 1 {
+		t.Fatalf("expected the free space of the watched directory, got %+v", got)
+	}
+
+	d := got.Dirs[0]
+	if d.Path != dir || d.Kind != diskguard.KindDownload || d.Error != "" || d.Low {
+		t.Errorf("invalid disk space status %+v", d)
+	}
+
+	// The disk space is not reported without disk guard
+	h.SetDiskGuard(nil)
+	if got := h.Report(nil).DiskSpace; got != nil {
+		t.Errorf("expected no disk space status, got %+v", got)
+	}
+}
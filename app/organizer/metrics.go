@@ -0,0 +1,32 @@
+package organizer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Organizer steps
+const (
+	stepExtract   = "extract"
+	stepGuess     = "guess"
+	stepDetails   = "details"
+	stepStore     = "store"
+	stepSubtitles = "subtitles"
+)
+
+var steps = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "polochon",
+	Subsystem: "organizer",
+	Name:      "steps_total",
+	Help:      "Number of files going through the organizer steps by step and result.",
+}, []string{"step", "result"})
+
+// observeStep records the result of an organizer step
+func observeStep(step string, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+
+	steps.WithLabelValues(step, result).Inc()
+}
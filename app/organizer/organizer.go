@@ -1,35 +1,68 @@
 package organizer
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/odwrtw/errors"
+	"github.com/odwrtw/polochon/app/diskguard"
 	"github.com/odwrtw/polochon/app/subapp"
 	"github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/archive"
 	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/history"
 	"github.com/odwrtw/polochon/lib/library"
+	"github.com/odwrtw/polochon/lib/mediainfo"
 	"github.com/sirupsen/logrus"
 )
 
 // AppName is the application name
 const AppName = "organizer"
 
+// Custom errors
+var (
+	// ErrImportDeferred is returned when a video is not imported because the
+	// library has not enough free space for it
+	ErrImportDeferred = errors.New("organizer: not enough free space, import deferred")
+	// ErrAiredDateDeferred is returned when a daily show episode is not
+	// imported because its air date is not in the show calendar yet
+	ErrAiredDateDeferred = errors.New("organizer: air date not in the calendar yet, import deferred")
+)
+
 // Organizer represents the organizer
 type Organizer struct {
 	*subapp.Base
 
 	config  *configuration.Config
 	library *library.Library
+	history *history.History
+	guard   *diskguard.Guard
 	event   chan string
+
+	// mu protects the deferred files
+	mu sync.Mutex
+	// deferred holds the files waiting for enough free space to be imported
+	deferred map[string]struct{}
+	// airedDeferred holds the daily show episodes waiting for their air date
+	// to be in the calendar, and the time they were first deferred
+	airedDeferred map[string]time.Time
 }
 
-// New returns a new organizer
-func New(config *configuration.Config, vs *library.Library) *Organizer {
+// New returns a new organizer, the disk guard is optional and used to defer
+// the imports when the library is full
+func New(config *configuration.Config, vs *library.Library, h *history.History, g *diskguard.Guard) *Organizer {
 	return &Organizer{
-		Base:    subapp.NewBase(AppName),
-		config:  config,
-		library: vs,
+		Base:          subapp.NewBase(AppName),
+		config:        config,
+		library:       vs,
+		history:       h,
+		guard:         g,
+		deferred:      map[string]struct{}{},
+		airedDeferred: map[string]time.Time{},
 	}
 }
 
@@ -48,7 +81,7 @@ func (o *Organizer) Run(log *logrus.Entry) error {
 	return o.startFsNotifier(log)
 }
 
-// startFsNotifier starts the FsNotifier
+// startFsNotifier starts a FsNotifier for each watched directory
 func (o *Organizer) startFsNotifier(log *logrus.Entry) error {
 	ctx := polochon.FsNotifierCtx{
 		Event: o.event,
@@ -56,13 +89,40 @@ func (o *Organizer) startFsNotifier(log *logrus.Entry) error {
 		Wg:    &o.Wg,
 	}
 
-	// Send a notification to organize the whole folder on app start
-	watcherPath := o.config.Watcher.Dir
-	ctx.Event <- watcherPath
+	for _, d := range o.config.Watcher.Dirs {
+		// Launch the FsNotifier
+		if err := d.FsNotifier.Watch(d.Dir, ctx, log); err != nil {
+			o.Stop(log)
+			o.Wg.Wait()
+			return err
+		}
+	}
 
-	// Launch the FsNotifier
-	if err := o.config.Watcher.FsNotifier.Watch(watcherPath, ctx, log); err != nil {
-		return err
+	// Send a notification to organize the whole folders on app start
+	go func() {
+		for _, d := range o.config.Watcher.Dirs {
+			select {
+			case ctx.Event <- d.Dir:
+			case <-o.Done:
+				return
+			}
+		}
+	}()
+
+	// The deferred files are retried periodically
+	var retry <-chan time.Time
+	if o.config.DiskGuard.RetryInterval > 0 {
+		ticker := time.NewTicker(o.config.DiskGuard.RetryInterval)
+		defer ticker.Stop()
+		retry = ticker.C
+	}
+
+	// The episodes missing from the calendar are retried on their own
+	var airedRetry <-chan time.Time
+	if o.config.AiredDateRetry.Interval > 0 {
+		ticker := time.NewTicker(o.config.AiredDateRetry.Interval)
+		defer ticker.Stop()
+		airedRetry = ticker.C
 	}
 
 	var err error
@@ -82,9 +142,13 @@ func (o *Organizer) startFsNotifier(log *logrus.Entry) error {
 			select {
 			case file := <-ctx.Event:
 				log.WithField("event", file).Debugf("got an event")
-				if err := o.organize(file, log); err != nil {
+				if err := o.Organize(file, log); err != nil {
 					log.Errorf("failed to organize file: %q", err)
 				}
+			case <-retry:
+				o.retryDeferred(log)
+			case <-airedRetry:
+				o.retryAiredDate(log)
 			case <-o.Done:
 				log.Debug("organizer done handling events")
 				return
@@ -98,7 +162,13 @@ func (o *Organizer) startFsNotifier(log *logrus.Entry) error {
 }
 
 // Organize stores the videos in the video library
-func (o *Organizer) organize(filePath string, log *logrus.Entry) error {
+func (o *Organizer) Organize(filePath string, log *logrus.Entry) error {
+	// The extracted archives are organized along with their archive
+	if archive.IsTempDir(filePath) {
+		log.WithField("path", filePath).Debug("the path is an archive extraction")
+		return nil
+	}
+
 	// Get the file infos from the path
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -124,12 +194,134 @@ func (o *Organizer) organizeFile(filePath string, log *logrus.Entry) error {
 	// Create a file
 	file := polochon.NewFileWithConfig(filePath, o.config.File)
 
+	// Use the policies of the watched directory of the file
+	if d := o.config.Watcher.WatchDir(filePath); d != nil {
+		file.TypeHint = d.Type
+		file.ImportMode = d.ImportMode
+	}
+
 	// Check if file really exists
 	if !file.Exists() {
 		log.Warning("the file has been removed")
 		return nil
 	}
 
+	var err error
+	if o.config.Archive.Enabled && archive.IsArchive(file.Path) {
+		err = o.organizeArchive(file, log)
+	} else {
+		err = o.organizeVideo(file, log)
+	}
+
+	if err == ErrAiredDateDeferred {
+		if o.deferAiredDate(file.Path) {
+			log.Warn(err)
+			return nil
+		}
+
+		log.Errorf("giving up the import, the air date is still not in the calendar after %s", o.config.AiredDateRetry.MaxAge)
+		o.trackFailure(file, "air date not in the calendar", log)
+		return file.Ignore()
+	}
+
+	o.mu.Lock()
+	delete(o.airedDeferred, file.Path)
+	if err == ErrImportDeferred {
+		log.Warn(err)
+		o.deferred[file.Path] = struct{}{}
+		err = nil
+	}
+	o.mu.Unlock()
+
+	return err
+}
+
+// deferAiredDate records the first time a file is deferred for its air date,
+// it returns false once the file waited longer than the max age
+func (o *Organizer) deferAiredDate(path string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	since, ok := o.airedDeferred[path]
+	if !ok {
+		o.airedDeferred[path] = time.Now()
+		return true
+	}
+
+	if maxAge := o.config.AiredDateRetry.MaxAge; maxAge > 0 && time.Since(since) > maxAge {
+		delete(o.airedDeferred, path)
+		return false
+	}
+
+	return true
+}
+
+// retryAiredDate organizes again the files deferred for their air date, the
+// removed files are forgotten
+func (o *Organizer) retryAiredDate(log *logrus.Entry) {
+	o.mu.Lock()
+	paths := make([]string, 0, len(o.airedDeferred))
+	for path := range o.airedDeferred {
+		paths = append(paths, path)
+	}
+	o.mu.Unlock()
+
+	for _, path := range paths {
+		log := log.WithField("path", path)
+		if _, err := os.Stat(path); err != nil {
+			log.Debug("the deferred file has been removed")
+			o.mu.Lock()
+			delete(o.airedDeferred, path)
+			o.mu.Unlock()
+			continue
+		}
+
+		log.Debug("retrying the import deferred for the air date")
+		if err := o.Organize(path, log); err != nil {
+			log.Errorf("failed to organize deferred file: %q", err)
+		}
+	}
+}
+
+// retryDeferred organizes again the files deferred for lack of free space,
+// they're deferred again if there is still not enough space
+func (o *Organizer) retryDeferred(log *logrus.Entry) {
+	o.mu.Lock()
+	files := o.deferred
+	o.deferred = map[string]struct{}{}
+	o.mu.Unlock()
+
+	for path := range files {
+		log := log.WithField("path", path)
+		log.Debug("retrying deferred import")
+		if err := o.Organize(path, log); err != nil {
+			log.Errorf("failed to organize deferred file: %q", err)
+		}
+	}
+}
+
+// hasSpace returns true if the library has enough free space to import the
+// video file, the hard links don't use any space
+func (o *Organizer) hasSpace(video polochon.Video, file *polochon.File, log *logrus.Entry) bool {
+	if o.guard == nil || file.ImportMode == polochon.ImportHardlink {
+		return true
+	}
+
+	fi, err := os.Stat(file.Path)
+	if err != nil {
+		return true
+	}
+
+	kind := diskguard.KindMovie
+	if _, ok := video.(*polochon.ShowEpisode); ok {
+		kind = diskguard.KindShow
+	}
+
+	return o.guard.Check(log).Fits(kind, uint64(fi.Size()))
+}
+
+// organizeVideo stores the video file in the video library
+func (o *Organizer) organizeVideo(file *polochon.File, log *logrus.Entry) error {
 	// Check if file is a video
 	if !file.IsVideo() {
 		log.Debug("the file is not a video")
@@ -157,30 +349,98 @@ func (o *Organizer) organizeFile(filePath string, log *logrus.Entry) error {
 	// Guess the video inforamtion
 	video, err := file.Guess(o.config.Movie, o.config.Show, log)
 	if err != nil {
+		observeStep(stepGuess, err)
 		errors.LogErrors(log, err)
 		return file.Ignore()
 	}
 	if video == nil {
-		errors.LogErrors(log, errors.New("invalid guess"))
+		err := errors.New("invalid guess")
+		observeStep(stepGuess, err)
+		errors.LogErrors(log, err)
 		return file.Ignore()
 	}
+	observeStep(stepGuess, nil)
+
+	// Read the streams from the video file headers
+	o.inspect(video, log)
+
+	// The season and the episode guessed from the release are numbered by
+	// the scene
+	episode, sceneNumbered := video.(*polochon.ShowEpisode)
+	sceneNumbered = sceneNumbered && episode.Season != 0 && episode.Episode != 0
+
+	// Find the season and the episode of the daily shows episodes from their
+	// air date
+	if e, ok := video.(*polochon.ShowEpisode); ok && e.IsDateBased() {
+		if err := polochon.ResolveAiredDate(e, log); err != nil {
+			observeStep(stepDetails, err)
+
+			// The calendar does not list the episode yet, it's retried
+			// later
+			if err == polochon.ErrNoEpisodeAiredOn {
+				return ErrAiredDateDeferred
+			}
+
+			errors.LogErrors(log, err)
+			return file.Ignore()
+		}
+	}
 
 	// Get video details
-	if err := polochon.GetDetails(video, log); err != nil {
+	err = polochon.GetDetails(video, log)
+	if sceneNumbered {
+		changed, sErr := polochon.ResolveSceneNumber(episode)
+		if sErr != nil {
+			observeStep(stepDetails, sErr)
+			errors.LogErrors(log, sErr)
+			return file.Ignore()
+		}
+
+		if changed {
+			log.Debugf("retrying the details with the metadata number S%02dE%02d", episode.Season, episode.Episode)
+			err = polochon.GetDetails(video, log)
+		}
+	}
+	if err != nil && o.useAbsoluteNumber(video) {
+		log.Debug("retrying the details with the absolute number")
+		err = polochon.GetDetails(video, log)
+	}
+	observeStep(stepDetails, err)
+	if err != nil {
 		errors.LogErrors(log, err)
 		if errors.IsFatal(err) {
 			return file.Ignore()
 		}
 	}
 
+	// Wait for enough free space rather than failing halfway through the
+	// import
+	if !o.hasSpace(video, file, log) {
+		return ErrImportDeferred
+	}
+
 	// Store the video
-	if err := o.library.Add(video, log); err != nil {
+	err = o.library.Add(video, log)
+	observeStep(stepStore, err)
+	if err != nil {
 		errors.LogErrors(log, err)
+		o.track(video, err, log)
 		return file.Ignore()
 	}
+	o.track(video, nil, log)
+
+	// The copied and hard linked files stay in the watched directory, they're
+	// ignored not to be imported again
+	if file.ImportMode == polochon.ImportCopy || file.ImportMode == polochon.ImportHardlink {
+		if err := file.Ignore(); err != nil {
+			log.Warnf("failed to ignore the imported file: %q", err)
+		}
+	}
 
 	// Get subtitles
-	if _, err = o.library.AddSubtitles(video, o.config.SubtitleLanguages, log); err != nil {
+	_, err = o.library.AddSubtitles(video, o.config.SubtitleLanguages, log)
+	observeStep(stepSubtitles, err)
+	if err != nil {
 		errors.LogErrors(log, err)
 	}
 
@@ -190,12 +450,168 @@ func (o *Organizer) organizeFile(filePath string, log *logrus.Entry) error {
 	return nil
 }
 
+// useAbsoluteNumber reads the guessed episode number of an anime show as its
+// absolute number, the releases of these shows are often numbered from the
+// first episode of the show, it returns false if the episode is unchanged
+func (o *Organizer) useAbsoluteNumber(video polochon.Video) bool {
+	e, ok := video.(*polochon.ShowEpisode)
+	if !ok || e.AbsoluteNumber != 0 || e.Episode == 0 {
+		return false
+	}
+
+	// The detailers find the show even if the episode is not found
+	imdbID := e.ShowImdbID
+	if imdbID == "" && e.Show != nil {
+		imdbID = e.Show.ImdbID
+	}
+
+	if !o.config.Show.IsAnime(imdbID) {
+		return false
+	}
+
+	e.AbsoluteNumber = e.Episode
+	e.Season = 0
+	e.Episode = 0
+	e.EpisodeEnd = 0
+	return true
+}
+
+// organizeArchive extracts the archive and organizes the videos it contains,
+// the archive is kept to be seeded and ignored not to be extracted again
+func (o *Organizer) organizeArchive(file *polochon.File, log *logrus.Entry) error {
+	// Only the first volume is extracted, the others are read by the extractor
+	if _, first := archive.Volume(file.Path); !first {
+		log.Debug("the file is not the first volume of the archive")
+		return nil
+	}
+
+	if file.IsIgnored() {
+		log.Debug("the archive is ignored")
+		return nil
+	}
+
+	// Extract the archive next to it to move the videos into the library
+	dir, err := archive.TempDir(file.Path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warnf("failed to remove the extracted archive: %q", err)
+		}
+	}()
+
+	log.Debug("extracting the archive")
+	extractor := &archive.Extractor{Unrar: o.config.Archive.Unrar}
+	err = extractor.Extract(file.Path, dir)
+	observeStep(stepExtract, err)
+	if err != nil {
+		// Password protected and corrupt archives are ignored and their
+		// torrent is failed
+		log.Errorf("failed to extract the archive: %q", err)
+		o.trackFailure(file, fmt.Sprintf("failed to extract the archive: %s", err), log)
+		return file.Ignore()
+	}
+
+	var deferred error
+	err = filepath.Walk(dir, func(filePath string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() {
+			return err
+		}
+
+		// The extracted copy is always moved into the library
+		f := polochon.NewFileWithConfig(filePath, o.config.File)
+		f.TypeHint = file.TypeHint
+		f.ImportMode = polochon.ImportMove
+
+		err = o.organizeVideo(f, log.WithField("extracted_path", filePath))
+		if err == ErrImportDeferred || err == ErrAiredDateDeferred {
+			deferred = err
+			return nil
+		}
+
+		return err
+	})
+	if err != nil {
+		log.Errorf("failed to organize the extracted archive: %q", err)
+	}
+
+	// The archive is extracted again once the videos can be imported
+	if deferred != nil {
+		return deferred
+	}
+
+	return file.Ignore()
+}
+
+// track updates the grab history of the video with the result of its import
+func (o *Organizer) track(video polochon.Video, importErr error, log *logrus.Entry) {
+	m := history.Metadata(video)
+	if m == nil {
+		return
+	}
+
+	e, err := o.history.Get(m)
+	if err != nil {
+		// The video was not downloaded by polochon
+		return
+	}
+
+	if importErr == nil {
+		err = o.history.SetState(m, history.StateImported)
+	} else if e.InFlight() {
+		err = o.history.Failed(m, importErr.Error())
+	}
+
+	if err != nil {
+		log.Errorf("failed to update the history: %q", err)
+	}
+}
+
+// trackFailure fails the grab of the torrent holding a file which can't be
+// imported, the torrent is named after the first element of the path of the
+// file in its watched directory
+func (o *Organizer) trackFailure(file *polochon.File, reason string, log *logrus.Entry) {
+	d := o.config.Watcher.WatchDir(file.Path)
+	if d == nil {
+		return
+	}
+
+	rel, err := filepath.Rel(d.Dir, file.Path)
+	if err != nil {
+		return
+	}
+	name := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+
+	err = o.history.FailedTorrent(name, reason)
+	if err != nil && err != history.ErrNotFound {
+		log.Errorf("failed to update the history: %q", err)
+	}
+}
+
+// inspect reads the video file headers to get the real resolution, codecs,
+// audio tracks and embedded subtitles of the video
+func (o *Organizer) inspect(video polochon.Video, log *logrus.Entry) {
+	info, err := mediainfo.Parse(video.GetFile().Path)
+	if err != nil {
+		log.Debugf("failed to inspect the video file: %q", err)
+		return
+	}
+
+	info.Update(video.GetMetadata())
+}
+
 // OrganizeFolder organize each file  in a folder
 func (o *Organizer) organizeFolder(folderPath string, log *logrus.Entry) error {
 	log.WithField("folder_path", folderPath).Debug("organize folder")
 
 	// Walk movies
 	err := filepath.Walk(folderPath, func(filePath string, file os.FileInfo, err error) error {
+		// The extracted archives are organized along with their archive
+		if file.IsDir() && archive.IsTempDir(file.Name()) {
+			return filepath.SkipDir
+		}
+
 		// Nothing to do on dir
 		if file.IsDir() {
 			return nil
@@ -6,6 +6,7 @@ import (
 	"os"
 
 	"github.com/odwrtw/polochon/app/app"
+	"github.com/odwrtw/polochon/app/cli"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,6 +22,11 @@ func main() {
 	tokenPath := flag.String("tokenPath", "", "path of the token file")
 	versionFlag := flag.Bool("version", false, "show version number and quit")
 
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command]\n", os.Args[0])
+		flag.PrintDefaults()
+		cli.Usage(flag.CommandLine.Output())
+	}
 	flag.Parse()
 
 	if *versionFlag {
@@ -28,6 +34,14 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Run a one-shot command instead of the app
+	if flag.NArg() > 0 {
+		if err := cli.Run(*configPath, flag.Args()); err != nil {
+			cli.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
 	app, err := app.NewApp(*configPath, *tokenPath)
 	if err != nil {
 		logrus.Fatal(err)
@@ -11,6 +11,7 @@ import (
 	_ "github.com/odwrtw/polochon/modules/mock"
 	_ "github.com/odwrtw/polochon/modules/openguessit"
 	_ "github.com/odwrtw/polochon/modules/opensubtitles"
+	_ "github.com/odwrtw/polochon/modules/polling"
 	_ "github.com/odwrtw/polochon/modules/pushover"
 	_ "github.com/odwrtw/polochon/modules/tmdb"
 	_ "github.com/odwrtw/polochon/modules/tpb"
@@ -18,28 +18,90 @@ const (
 
 // Safeguard prevents the app from entering in a panic loop
 type Safeguard struct {
+	sync.Mutex
 	event chan struct{}
 	done  chan struct{}
 	count int
 	wg    sync.WaitGroup
+
+	// restarts holds the restarts of each sub app
+	restarts map[string]*restarts
+}
+
+// restarts represents the restarts of a sub app
+type restarts struct {
+	count int
+	// recent holds the times of the restarts during the last MaxEventDelay
+	recent []time.Time
 }
 
 // New returns a new safeguard
 func New() *Safeguard {
 	return &Safeguard{
-		event: make(chan struct{}),
-		done:  make(chan struct{}),
+		event:    make(chan struct{}),
+		done:     make(chan struct{}),
+		restarts: map[string]*restarts{},
 	}
 }
 
-// Event sends an event to the safeguard
-func (s *Safeguard) Event() {
+// Event sends an event to the safeguard when a sub app restarts
+func (s *Safeguard) Event(app string) {
+	s.Lock()
+	r, ok := s.restarts[app]
+	if !ok {
+		r = &restarts{}
+		s.restarts[app] = r
+	}
+	r.count++
+	r.recent = append(recentEvents(r.recent), time.Now())
+	s.Unlock()
+
 	// Send the event in a goroutine to prevent deadlocks
 	go func() {
 		s.event <- struct{}{}
 	}()
 }
 
+// Restarts returns the number of restarts of a sub app
+func (s *Safeguard) Restarts(app string) int {
+	s.Lock()
+	defer s.Unlock()
+
+	r, ok := s.restarts[app]
+	if !ok {
+		return 0
+	}
+
+	return r.count
+}
+
+// PanicLoop returns true if the sub app restarted more than once during the
+// last MaxEventDelay
+func (s *Safeguard) PanicLoop(app string) bool {
+	s.Lock()
+	defer s.Unlock()
+
+	r, ok := s.restarts[app]
+	if !ok {
+		return false
+	}
+
+	return len(recentEvents(r.recent)) > 1
+}
+
+// recentEvents returns the events which happened during the last
+// MaxEventDelay
+func recentEvents(events []time.Time) []time.Time {
+	recent := []time.Time{}
+	for _, t := range events {
+		if time.Since(t) < MaxEventDelay {
+			recent = append(recent, t)
+		}
+	}
+
+	return recent
+}
+
 // Run runs the safeguard
 func (s *Safeguard) Run(log *logrus.Entry) error {
 	s.wg.Add(1)
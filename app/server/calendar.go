@@ -0,0 +1,383 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/odwrtw/errors"
+	"github.com/odwrtw/polochon/app/downloader"
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/sirupsen/logrus"
+)
+
+// Status of the episodes in the calendar
+const (
+	CalendarDownloaded = "downloaded"
+	CalendarGrabbed    = "grabbed"
+	CalendarMissing    = "missing"
+	CalendarNotAired   = "not_aired"
+)
+
+// calendarTTL is the time during which the calendar of a show is kept in
+// cache
+const calendarTTL = 6 * time.Hour
+
+// calendarWishlistTTL is the time during which the wished shows are kept in
+// cache
+const calendarWishlistTTL = 10 * time.Minute
+
+// Default range of the calendar
+const (
+	calendarDefaultPast   = 7 * 24 * time.Hour
+	calendarDefaultFuture = 30 * 24 * time.Hour
+)
+
+// CalendarEpisode represents an episode in the calendar
+type CalendarEpisode struct {
+	ShowImdbID string    `json:"show_imdb_id"`
+	ShowTitle  string    `json:"show_title"`
+	Season     int       `json:"season"`
+	Episode    int       `json:"episode"`
+	AiredDate  time.Time `json:"aired_date"`
+	Status     string    `json:"status"`
+}
+
+// cachedCalendar represents the calendar of a show in cache
+type cachedCalendar struct {
+	title     string
+	calendar  *polochon.ShowCalendar
+	fetchedAt time.Time
+}
+
+// calendarCache keeps the calendars of the shows by imdb id and the ids of
+// the wished shows
+type calendarCache struct {
+	sync.Mutex
+	ttl         time.Duration
+	entries     map[string]*cachedCalendar
+	wishlistTTL time.Duration
+	wished      []string
+	wishedAt    time.Time
+}
+
+func newCalendarCache(ttl, wishlistTTL time.Duration) *calendarCache {
+	return &calendarCache{
+		ttl:         ttl,
+		entries:     map[string]*cachedCalendar{},
+		wishlistTTL: wishlistTTL,
+	}
+}
+
+// get returns the calendar of a show from the cache, the calendar is fetched
+// if it's not in the cache or if it has expired. The expired calendar is
+// returned if the fetch fails. The cache is not locked during the fetch
+func (c *calendarCache) get(imdbID string, now time.Time, fetch func() (*cachedCalendar, error)) (*cachedCalendar, error) {
+	c.Lock()
+	cached, ok := c.entries[imdbID]
+	c.Unlock()
+
+	if ok && now.Sub(cached.fetchedAt) < c.ttl {
+		return cached, nil
+	}
+
+	fresh, err := fetch()
+	if err != nil {
+		if ok {
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	fresh.fetchedAt = now
+
+	c.Lock()
+	c.entries[imdbID] = fresh
+	c.Unlock()
+
+	return fresh, nil
+}
+
+// wishedShows returns the ids of the wished shows from the cache, they're
+// fetched if they have expired. The expired ids are returned if the fetch
+// fails
+func (c *calendarCache) wishedShows(now time.Time, fetch func() ([]string, error)) ([]string, error) {
+	c.Lock()
+	wished, wishedAt := c.wished, c.wishedAt
+	c.Unlock()
+
+	if !wishedAt.IsZero() && now.Sub(wishedAt) < c.wishlistTTL {
+		return wished, nil
+	}
+
+	fresh, err := fetch()
+	if err != nil {
+		if !wishedAt.IsZero() {
+			return wished, nil
+		}
+		return nil, err
+	}
+
+	c.Lock()
+	c.wished, c.wishedAt = fresh, now
+	c.Unlock()
+
+	return fresh, nil
+}
+
+// calendarRange returns the range of the calendar from the query params
+func calendarRange(req *http.Request, now time.Time) (time.Time, time.Time, error) {
+	from := now.Add(-calendarDefaultPast)
+	to := now.Add(calendarDefaultFuture)
+
+	for param, value := range map[string]*time.Time{
+		"from": &from,
+		"to":   &to,
+	} {
+		v := req.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return from, to, &Error{
+				Code:    http.StatusBadRequest,
+				Message: fmt.Sprintf("invalid %s date %q", param, v),
+			}
+		}
+		*value = date
+	}
+
+	return from, to, nil
+}
+
+// calendarShowIDs returns the ids of the wished and indexed shows
+func (s *Server) calendarShowIDs(now time.Time, log *logrus.Entry) map[string]struct{} {
+	ids := map[string]struct{}{}
+	for id := range s.library.ShowIDs() {
+		ids[id] = struct{}{}
+	}
+
+	wished, err := s.calendars.wishedShows(now, s.fetchWishedShows(log))
+	if err != nil {
+		log.Warnf("failed to fetch the wishlist: %q", err)
+		return ids
+	}
+
+	for _, id := range wished {
+		ids[id] = struct{}{}
+	}
+
+	return ids
+}
+
+// fetchWishedShows returns a function fetching the ids of the wished shows
+func (s *Server) fetchWishedShows(log *logrus.Entry) func() ([]string, error) {
+	return func() ([]string, error) {
+		wl := polochon.NewWishlist(s.config.Wishlist, log)
+		if err := wl.Fetch(); err != nil {
+			return nil, err
+		}
+
+		ids := make([]string, 0, len(wl.Shows))
+		for _, ws := range wl.Shows {
+			ids = append(ids, ws.ImdbID)
+		}
+
+		return ids, nil
+	}
+}
+
+// fetchCalendar returns a function fetching the calendar of a show
+func (s *Server) fetchCalendar(imdbID string, log *logrus.Entry) func() (*cachedCalendar, error) {
+	return func() (*cachedCalendar, error) {
+		show := polochon.NewShow(s.config.Show)
+		show.ImdbID = imdbID
+
+		calendar, err := show.GetCalendar(log)
+		if err != nil {
+			return nil, err
+		}
+
+		return &cachedCalendar{
+			title:    show.Title,
+			calendar: calendar,
+		}, nil
+	}
+}
+
+// calendarEpisodes returns the episodes of the wished and indexed shows aired
+// in the given range
+func (s *Server) calendarEpisodes(from, to, now time.Time, log *logrus.Entry) []*CalendarEpisode {
+	episodes := []*CalendarEpisode{}
+
+	for id := range s.calendarShowIDs(now, log) {
+		log := log.WithField("imdb_id", id)
+		cached, err := s.calendars.get(id, now, s.fetchCalendar(id, log))
+		if err != nil {
+			errors.LogErrors(log, err)
+			continue
+		}
+
+		for _, e := range cached.calendar.Episodes {
+			if e.AiredDate == nil || e.AiredDate.IsZero() {
+				continue
+			}
+
+			if e.AiredDate.Before(from) || e.AiredDate.After(to) {
+				continue
+			}
+
+			episodes = append(episodes, &CalendarEpisode{
+				ShowImdbID: id,
+				ShowTitle:  cached.title,
+				Season:     e.Season,
+				Episode:    e.Episode,
+				AiredDate:  *e.AiredDate,
+				Status:     s.calendarStatus(id, e, now),
+			})
+		}
+	}
+
+	sort.Slice(episodes, func(i, j int) bool {
+		a, b := episodes[i], episodes[j]
+		if !a.AiredDate.Equal(b.AiredDate) {
+			return a.AiredDate.Before(b.AiredDate)
+		}
+		if a.ShowTitle != b.ShowTitle {
+			return a.ShowTitle < b.ShowTitle
+		}
+		if a.Season != b.Season {
+			return a.Season < b.Season
+		}
+		return a.Episode < b.Episode
+	})
+
+	return episodes
+}
+
+// calendarStatus returns the status of an episode of the calendar
+func (s *Server) calendarStatus(imdbID string, e *polochon.ShowCalendarEpisode, now time.Time) string {
+	if ok, _ := s.library.HasShowEpisode(imdbID, e.Season, e.Episode); ok {
+		return CalendarDownloaded
+	}
+
+	if d, ok := s.subApp(downloader.AppName).(*downloader.Downloader); ok {
+		entry, err := d.History().Get(&polochon.DownloadableMetadata{
+			Type:    "episode",
+			ImdbID:  imdbID,
+			Season:  e.Season,
+			Episode: e.Episode,
+		})
+		if err == nil && entry.InFlight() {
+			return CalendarGrabbed
+		}
+	}
+
+	if e.AiredDate.After(now) {
+		return CalendarNotAired
+	}
+
+	return CalendarMissing
+}
+
+func (s *Server) calendar(w http.ResponseWriter, req *http.Request) {
+	now := time.Now()
+	from, to, err := calendarRange(req, now)
+	if err != nil {
+		s.renderError(w, err)
+		return
+	}
+
+	log := s.log.WithField("function", "calendar")
+	s.renderOK(w, s.calendarEpisodes(from, to, now, log))
+}
+
+func (s *Server) calendarICS(w http.ResponseWriter, req *http.Request) {
+	now := time.Now()
+	from, to, err := calendarRange(req, now)
+	if err != nil {
+		s.renderError(w, err)
+		return
+	}
+
+	log := s.log.WithField("function", "calendar_ics")
+	episodes := s.calendarEpisodes(from, to, now, log)
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="polochon.ics"`)
+	if err := writeICS(w, episodes, now); err != nil {
+		log.Errorf("failed to write the calendar: %q", err)
+	}
+}
+
+// icsEscaper escapes the special characters of the iCalendar texts
+var icsEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\n", `\n`,
+)
+
+// icsLine folds the line to 75 octets as required by the RFC 5545
+func icsLine(line string) string {
+	const max = 75
+
+	var b strings.Builder
+	for len(line) > max {
+		// Don't split a multi-byte character
+		cut := max
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// writeICS writes the episodes as an iCalendar feed
+func writeICS(w io.Writer, episodes []*CalendarEpisode, now time.Time) error {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//polochon//calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Polochon",
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	for _, e := range episodes {
+		summary := fmt.Sprintf("%s - S%02dE%02d", e.ShowTitle, e.Season, e.Episode)
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:%s-s%02de%02d@polochon", e.ShowImdbID, e.Season, e.Episode),
+			"DTSTAMP:"+stamp,
+			"DTSTART;VALUE=DATE:"+e.AiredDate.Format("20060102"),
+			"DTEND;VALUE=DATE:"+e.AiredDate.AddDate(0, 0, 1).Format("20060102"),
+			"SUMMARY:"+icsEscaper.Replace(summary),
+			"DESCRIPTION:"+icsEscaper.Replace("Status: "+e.Status),
+			"CATEGORIES:"+icsEscaper.Replace(e.Status),
+			"END:VEVENT",
+		)
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	for _, l := range lines {
+		if _, err := io.WriteString(w, icsLine(l)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
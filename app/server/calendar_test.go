@@ -0,0 +1,164 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+func TestWriteICS(t *testing.T) {
+	now := time.Date(2020, time.May, 4, 12, 30, 0, 0, time.UTC)
+	episodes := []*CalendarEpisode{
+		{
+			ShowImdbID: "tt0944947",
+			ShowTitle:  "Game of Thrones, the show",
+			Season:     8,
+			Episode:    6,
+			AiredDate:  time.Date(2019, time.May, 19, 0, 0, 0, 0, time.UTC),
+			Status:     CalendarDownloaded,
+		},
+	}
+
+	var b bytes.Buffer
+	if err := writeICS(&b, episodes, now); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//polochon//calendar//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Polochon",
+		"BEGIN:VEVENT",
+		"UID:tt0944947-s08e06@polochon",
+		"DTSTAMP:20200504T123000Z",
+		"DTSTART;VALUE=DATE:20190519",
+		"DTEND;VALUE=DATE:20190520",
+		`SUMMARY:Game of Thrones\, the show - S08E06`,
+		"DESCRIPTION:Status: downloaded",
+		"CATEGORIES:downloaded",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	if got := b.String(); got != expected {
+		t.Errorf("invalid calendar, expected\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestICSLineFolding(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 50)
+	folded := icsLine(line)
+
+	parts := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n ")
+	if len(parts) != 2 {
+		t.Fatalf("expected the line to be folded once, got %q", folded)
+	}
+
+	for _, p := range parts {
+		if len(p) > 75 {
+			t.Errorf("line longer than 75 octets: %q", p)
+		}
+	}
+
+	if strings.Join(parts, "") != line {
+		t.Errorf("the folded line does not match the original line")
+	}
+}
+
+func TestCalendarCache(t *testing.T) {
+	cache := newCalendarCache(time.Hour, time.Minute)
+	now := time.Now()
+
+	calls := 0
+	fetch := func() (*cachedCalendar, error) {
+		calls++
+		return &cachedCalendar{
+			title:    "Game of Thrones",
+			calendar: polochon.NewShowCalendar("tt0944947"),
+		}, nil
+	}
+	failingFetch := func() (*cachedCalendar, error) {
+		calls++
+		return nil, errors.New("tvdb is down")
+	}
+
+	for _, step := range []struct {
+		name          string
+		now           time.Time
+		fetch         func() (*cachedCalendar, error)
+		expectedCalls int
+	}{
+		{name: "not cached", now: now, fetch: fetch, expectedCalls: 1},
+		{name: "cached", now: now.Add(30 * time.Minute), fetch: fetch, expectedCalls: 1},
+		{name: "expired", now: now.Add(2 * time.Hour), fetch: fetch, expectedCalls: 2},
+		{name: "stale on error", now: now.Add(4 * time.Hour), fetch: failingFetch, expectedCalls: 3},
+	} {
+		cached, err := cache.get("tt0944947", step.now, step.fetch)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %q", step.name, err)
+		}
+
+		if cached.title != "Game of Thrones" {
+			t.Errorf("%s: invalid cached calendar %+v", step.name, cached)
+		}
+
+		if calls != step.expectedCalls {
+			t.Errorf("%s: expected %d calls, got %d", step.name, step.expectedCalls, calls)
+		}
+	}
+
+	if _, err := cache.get("tt2085059", now, failingFetch); err == nil {
+		t.Errorf("expected an error for a show not in cache")
+	}
+}
+
+func TestCalendarWishedShows(t *testing.T) {
+	cache := newCalendarCache(time.Hour, time.Minute)
+	now := time.Now()
+
+	calls := 0
+	fetch := func() ([]string, error) {
+		calls++
+		return []string{"tt0944947"}, nil
+	}
+	failingFetch := func() ([]string, error) {
+		calls++
+		return nil, errors.New("wishlister is down")
+	}
+
+	if _, err := cache.wishedShows(now, failingFetch); err == nil {
+		t.Errorf("expected an error without wished shows in cache")
+	}
+
+	for _, step := range []struct {
+		name          string
+		now           time.Time
+		fetch         func() ([]string, error)
+		expectedCalls int
+	}{
+		{name: "not cached", now: now, fetch: fetch, expectedCalls: 2},
+		{name: "cached", now: now.Add(30 * time.Second), fetch: fetch, expectedCalls: 2},
+		{name: "expired", now: now.Add(2 * time.Minute), fetch: fetch, expectedCalls: 3},
+		{name: "stale on error", now: now.Add(4 * time.Minute), fetch: failingFetch, expectedCalls: 4},
+	} {
+		wished, err := cache.wishedShows(step.now, step.fetch)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %q", step.name, err)
+		}
+
+		if len(wished) != 1 || wished[0] != "tt0944947" {
+			t.Errorf("%s: invalid wished shows %v", step.name, wished)
+		}
+
+		if calls != step.expectedCalls {
+			t.Errorf("%s: expected %d calls, got %d", step.name, step.expectedCalls, calls)
+		}
+	}
+}
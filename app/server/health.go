@@ -0,0 +1,30 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/odwrtw/polochon/app/subapp"
+)
+
+// healthz tells that the process is alive
+func (s *Server) healthz(w http.ResponseWriter, req *http.Request) {
+	s.renderOK(w, map[string]string{"status": "ok"})
+}
+
+// readyz reports the state of the sub apps and the free space of the disks,
+// it fails while the library index is built or if a critical sub app is not
+// running
+func (s *Server) readyz(w http.ResponseWriter, req *http.Request) {
+	apps := make([]subapp.App, 0, len(s.subApps)+1)
+	apps = append(apps, s)
+	apps = append(apps, s.subApps...)
+
+	report := s.health.Report(apps)
+
+	status := http.StatusOK
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	s.render.JSON(w, status, report)
+}
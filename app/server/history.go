@@ -0,0 +1,62 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/odwrtw/polochon/app/downloader"
+	"github.com/odwrtw/polochon/lib/history"
+)
+
+// getHistory returns the grab history, or nil if the downloader is not
+// running
+func (s *Server) getHistory(w http.ResponseWriter) *history.History {
+	d, ok := s.subApp(downloader.AppName).(*downloader.Downloader)
+	if !ok {
+		s.renderError(w, &Error{
+			Code:    http.StatusServiceUnavailable,
+			Message: "downloader not enabled in your polochon",
+		})
+		return nil
+	}
+
+	return d.History()
+}
+
+func (s *Server) history(w http.ResponseWriter, req *http.Request) {
+	h := s.getHistory(w)
+	if h == nil {
+		return
+	}
+
+	s.renderOK(w, h.List())
+}
+
+func (s *Server) clearHistory(w http.ResponseWriter, req *http.Request) {
+	h := s.getHistory(w)
+	if h == nil {
+		return
+	}
+
+	if err := h.Clear(); err != nil {
+		s.renderError(w, err)
+		return
+	}
+
+	s.renderOK(w, nil)
+}
+
+func (s *Server) deleteHistoryEntry(w http.ResponseWriter, req *http.Request) {
+	h := s.getHistory(w)
+	if h == nil {
+		return
+	}
+
+	vars := mux.Vars(req)
+	if err := h.Remove(vars["id"]); err != nil {
+		s.renderError(w, err)
+		return
+	}
+
+	s.renderOK(w, nil)
+}
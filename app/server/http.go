@@ -9,6 +9,8 @@ import (
 	"gopkg.in/unrolled/render.v1"
 
 	"github.com/odwrtw/polochon/app/auth"
+	"github.com/odwrtw/polochon/app/downloader"
+	"github.com/odwrtw/polochon/app/health"
 	"github.com/odwrtw/polochon/app/subapp"
 	polochon "github.com/odwrtw/polochon/lib"
 	"github.com/odwrtw/polochon/lib/configuration"
@@ -29,19 +31,38 @@ type Server struct {
 	gracefulServer *http.Server
 	log            *logrus.Entry
 	render         *render.Render
+	subApps        []subapp.App
+	calendars      *calendarCache
+	modulesStatus  *configuration.StatusChecker
+	health         *health.Health
 }
 
-// New returns a new server
-func New(config *configuration.Config, vs *library.Library, auth *auth.Manager) *Server {
+// New returns a new server, the sub apps are the other apps run by polochon
+func New(config *configuration.Config, vs *library.Library, auth *auth.Manager, status *configuration.StatusChecker, h *health.Health, subApps []subapp.App) *Server {
 	return &Server{
-		Base:        subapp.NewBase(AppName),
-		config:      config,
-		library:     vs,
-		authManager: auth,
-		render:      render.New(),
+		Base:          subapp.NewBase(AppName),
+		config:        config,
+		library:       vs,
+		authManager:   auth,
+		render:        render.New(),
+		subApps:       subApps,
+		calendars:     newCalendarCache(calendarTTL, calendarWishlistTTL),
+		modulesStatus: status,
+		health:        h,
 	}
 }
 
+// subApp returns the sub app with the given name, or nil if it's not running
+func (s *Server) subApp(name string) subapp.App {
+	for _, app := range s.subApps {
+		if app.Name() == name {
+			return app
+		}
+	}
+
+	return nil
+}
+
 // Run starts the server
 func (s *Server) Run(log *logrus.Entry) error {
 	s.log = log.WithField("app", AppName)
@@ -73,6 +94,19 @@ func (s *Server) wishlist(w http.ResponseWriter, req *http.Request) {
 	s.renderOK(w, wl)
 }
 
+func (s *Server) wishlistStatus(w http.ResponseWriter, req *http.Request) {
+	d, ok := s.subApp(downloader.AppName).(*downloader.Downloader)
+	if !ok {
+		s.renderError(w, &Error{
+			Code:    http.StatusServiceUnavailable,
+			Message: "downloader not enabled in your polochon",
+		})
+		return
+	}
+
+	s.renderOK(w, d.WishlistStatus())
+}
+
 func (s *Server) serveFile(w http.ResponseWriter, r *http.Request, file *polochon.File) {
 	// Set the header so that when downloading, the real filename will be given
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(file.Path)))
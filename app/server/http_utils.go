@@ -3,6 +3,7 @@ package server
 import (
 	"net/http"
 
+	"github.com/odwrtw/polochon/lib/history"
 	"github.com/odwrtw/polochon/lib/media_index"
 )
 
@@ -31,7 +32,7 @@ func (s *Server) renderError(w http.ResponseWriter, input error) {
 	case *Error:
 		err = e
 	default:
-		if e == index.ErrNotFound {
+		if e == index.ErrNotFound || e == history.ErrNotFound {
 			err = &Error{
 				Code:    http.StatusNotFound,
 				Message: "URL not found",
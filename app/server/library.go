@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/odwrtw/polochon/app/verifier"
+	"github.com/odwrtw/polochon/lib/library"
+)
+
+type verifyReport struct {
+	LastRun *time.Time              `json:"last_run"`
+	Results []*library.VerifyResult `json:"results"`
+}
+
+func (s *Server) getLibraryVerify(w http.ResponseWriter, req *http.Request) {
+	v, ok := s.subApp(verifier.AppName).(*verifier.Verifier)
+	if !ok {
+		s.renderError(w, &Error{
+			Code:    http.StatusServiceUnavailable,
+			Message: "verifier not enabled in your polochon",
+		})
+		return
+	}
+
+	results, lastRun := v.Results()
+	s.renderOK(w, verifyReport{
+		LastRun: lastRun,
+		Results: results,
+	})
+}
+
+func (s *Server) verifyLibrary(w http.ResponseWriter, req *http.Request) {
+	log := s.log.WithField("function", "verify_library")
+
+	var results []*library.VerifyResult
+	var err error
+
+	// Use the verifier if it's enabled to requeue the broken videos
+	if v, ok := s.subApp(verifier.AppName).(*verifier.Verifier); ok {
+		results, err = v.Verify(log)
+	} else {
+		results, err = s.library.Verify(log)
+	}
+	if err != nil {
+		s.renderError(w, err)
+		return
+	}
+
+	now := time.Now()
+	s.renderOK(w, verifyReport{
+		LastRun: &now,
+		Results: results,
+	})
+}
+
+func (s *Server) libraryDoctor(w http.ResponseWriter, req *http.Request) {
+	log := s.log.WithField("function", "library_doctor")
+
+	// Only report the inconsistencies by default
+	repair, _ := strconv.ParseBool(req.URL.Query().Get("repair"))
+
+	report, err := s.library.Doctor(repair, log)
+	if err != nil {
+		s.renderError(w, err)
+		return
+	}
+
+	s.renderOK(w, report)
+}
+
+func (s *Server) libraryRename(w http.ResponseWriter, req *http.Request) {
+	log := s.log.WithField("function", "library_rename")
+
+	dryRun, _ := strconv.ParseBool(req.URL.Query().Get("dry_run"))
+
+	report, err := s.library.Rename(dryRun, log)
+	if err != nil {
+		s.renderError(w, err)
+		return
+	}
+
+	s.renderOK(w, report)
+}
+
+func (s *Server) getLibraryRoots(w http.ResponseWriter, req *http.Request) {
+	s.renderOK(w, s.library.RootsUsage())
+}
@@ -3,7 +3,7 @@ package server
 import "net/http"
 
 func (s *Server) getModulesStatus(w http.ResponseWriter, req *http.Request) {
-	status := s.config.ModulesStatus()
+	status := s.modulesStatus.ModulesStatus()
 
 	s.renderOK(w, status)
 }
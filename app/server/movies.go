@@ -2,14 +2,62 @@ package server
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/media_index"
 )
 
 func (s *Server) movieIndex(w http.ResponseWriter, req *http.Request) {
 	s.log.Debug("listing movie index")
-	s.renderOK(w, s.library.MovieIndex())
+
+	movies := s.library.MovieIndex()
+
+	query := req.URL.Query()
+	audioLang := polochon.Language(query.Get("audio_language"))
+	subtitleLang := polochon.Language(query.Get("subtitle_language"))
+	videoCodec := query.Get("video_codec")
+	if audioLang == "" && subtitleLang == "" && videoCodec == "" {
+		s.renderOK(w, movies)
+		return
+	}
+
+	filtered := map[string]*index.Movie{}
+	for id, m := range movies {
+		if audioLang != "" && !m.HasAudioLanguage(audioLang) {
+			continue
+		}
+
+		if subtitleLang != "" && !hasSubtitle(m, subtitleLang) {
+			continue
+		}
+
+		if videoCodec != "" && !strings.EqualFold(m.VideoCodec, videoCodec) {
+			continue
+		}
+
+		filtered[id] = m
+	}
+
+	s.renderOK(w, filtered)
+}
+
+// hasSubtitle returns true if the movie has an embedded or an external
+// subtitle in the given language
+func hasSubtitle(m *index.Movie, lang polochon.Language) bool {
+	if m.HasEmbeddedSubtitle(lang) {
+		return true
+	}
+
+	for _, l := range m.Subtitles {
+		if l == lang {
+			return true
+		}
+	}
+
+	return false
 }
 
 // TODO: handle this in a middleware
@@ -61,6 +109,33 @@ func (s *Server) serveMovie(w http.ResponseWriter, req *http.Request) {
 	s.serveFile(w, req, m.GetFile())
 }
 
+func (s *Server) serveMoviePart(w http.ResponseWriter, req *http.Request) {
+	m := s.getMovie(w, req)
+	if m == nil {
+		return
+	}
+
+	n, err := strconv.Atoi(mux.Vars(req)["part"])
+	if err != nil {
+		s.renderError(w, &Error{
+			Code:    http.StatusBadRequest,
+			Message: "invalid part",
+		})
+		return
+	}
+
+	part := m.Part(n)
+	if part == nil {
+		s.renderError(w, &Error{
+			Code:    http.StatusNotFound,
+			Message: "No such part",
+		})
+		return
+	}
+
+	s.serveFile(w, req, polochon.NewFile(part.Path))
+}
+
 func (s *Server) deleteMovie(w http.ResponseWriter, req *http.Request) {
 	m := s.getMovie(w, req)
 	if m == nil {
@@ -64,6 +64,13 @@ func (s *Server) httpServer(log *logrus.Entry) *http.Server {
 			handler:  s.serveMovie,
 			excluded: !s.config.HTTPServer.ServeFiles,
 		},
+		{
+			name:     "DownloadMoviePart",
+			path:     "/movies/{id}/parts/{part}/download",
+			methods:  "GET",
+			handler:  s.serveMoviePart,
+			excluded: !s.config.HTTPServer.ServeFiles,
+		},
 		{
 			name:     "DownloadMovieSubtitle",
 			path:     "/movies/{id}/subtitles/{lang}/download",
@@ -133,12 +140,84 @@ func (s *Server) httpServer(log *logrus.Entry) *http.Server {
 			handler:  s.serveEpisodeSubtitle,
 			excluded: !s.config.HTTPServer.ServeFiles,
 		},
+		{
+			name:    "GetMissingSubtitles",
+			path:    "/subtitles/missing",
+			methods: "GET",
+			handler: s.getMissingSubtitles,
+		},
+		{
+			name:    "GetLibraryVerify",
+			path:    "/library/verify",
+			methods: "GET",
+			handler: s.getLibraryVerify,
+		},
+		{
+			name:    "VerifyLibrary",
+			path:    "/library/verify",
+			methods: "POST",
+			handler: s.verifyLibrary,
+		},
+		{
+			name:    "LibraryDoctor",
+			path:    "/library/doctor",
+			methods: "POST",
+			handler: s.libraryDoctor,
+		},
+		{
+			name:    "LibraryRename",
+			path:    "/library/rename",
+			methods: "POST",
+			handler: s.libraryRename,
+		},
+		{
+			name:    "GetLibraryRoots",
+			path:    "/library/roots",
+			methods: "GET",
+			handler: s.getLibraryRoots,
+		},
+		{
+			name:    "GetCalendar",
+			path:    "/calendar",
+			methods: "GET",
+			handler: s.calendar,
+		},
+		{
+			name:    "GetCalendarICS",
+			path:    "/calendar.ics",
+			methods: "GET",
+			handler: s.calendarICS,
+		},
 		{
 			name:    "Wishlist",
 			path:    "/wishlist",
 			methods: "GET",
 			handler: s.wishlist,
 		},
+		{
+			name:    "WishlistStatus",
+			path:    "/wishlist/status",
+			methods: "GET",
+			handler: s.wishlistStatus,
+		},
+		{
+			name:    "GetHistory",
+			path:    "/history",
+			methods: "GET",
+			handler: s.history,
+		},
+		{
+			name:    "ClearHistory",
+			path:    "/history",
+			methods: "DELETE",
+			handler: s.clearHistory,
+		},
+		{
+			name:    "DeleteHistoryEntry",
+			path:    "/history/{id}",
+			methods: "DELETE",
+			handler: s.deleteHistoryEntry,
+		},
 		{
 			name:    "AddTorrent",
 			path:    "/torrents",
@@ -255,5 +334,12 @@ func (s *Server) httpServer(log *logrus.Entry) *http.Server {
 	// Wrap the router
 	n.UseHandler(mux)
 
-	return &http.Server{Addr: addr, Handler: n}
+	// The health probes are not behind the middlewares, the orchestrators
+	// probing them have no credentials
+	root := http.NewServeMux()
+	root.HandleFunc("/healthz", s.healthz)
+	root.HandleFunc("/readyz", s.readyz)
+	root.Handle("/", n)
+
+	return &http.Server{Addr: addr, Handler: root}
 }
@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gorilla/mux"
+	"github.com/odwrtw/polochon/app/backfill"
 	polochon "github.com/odwrtw/polochon/lib"
 )
 
@@ -75,3 +76,16 @@ func (s *Server) serveEpisodeSubtitle(w http.ResponseWriter, req *http.Request)
 
 	s.serveFile(w, req, file)
 }
+
+func (s *Server) getMissingSubtitles(w http.ResponseWriter, req *http.Request) {
+	b, ok := s.subApp(backfill.AppName).(*backfill.Backfill)
+	if !ok {
+		s.renderError(w, &Error{
+			Code:    http.StatusServiceUnavailable,
+			Message: "subtitle backfill not enabled in your polochon",
+		})
+		return
+	}
+
+	s.renderOK(w, b.Missing())
+}
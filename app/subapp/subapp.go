@@ -12,6 +12,18 @@ const (
 	Stopped
 )
 
+// String implements the Stringer interface
+func (s Status) String() string {
+	switch s {
+	case Started:
+		return "started"
+	case Stopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
 // App represents an application launched by main application
 type App interface {
 	// Status returns the current sub app status
@@ -0,0 +1,192 @@
+package verifier
+
+import (
+	"sync"
+	"time"
+
+	"github.com/odwrtw/errors"
+	"github.com/odwrtw/polochon/app/downloader"
+	"github.com/odwrtw/polochon/app/subapp"
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/history"
+	"github.com/odwrtw/polochon/lib/library"
+	"github.com/sirupsen/logrus"
+)
+
+// AppName is the application name
+const AppName = "verifier"
+
+// Verifier periodically checks the integrity of the library files
+type Verifier struct {
+	*subapp.Base
+
+	config     *configuration.Config
+	library    *library.Library
+	downloader *downloader.Downloader
+	event      chan struct{}
+
+	mu      sync.RWMutex
+	lastRun *time.Time
+	results []*library.VerifyResult
+}
+
+// New returns a new verifier, the downloader is optional and used to grab
+// again the videos failing the verification
+func New(config *configuration.Config, vs *library.Library, d *downloader.Downloader) *Verifier {
+	return &Verifier{
+		Base:       subapp.NewBase(AppName),
+		config:     config,
+		library:    vs,
+		downloader: d,
+		results:    []*library.VerifyResult{},
+	}
+}
+
+// Run starts the verifier
+func (v *Verifier) Run(log *logrus.Entry) error {
+	log = log.WithField("app", AppName)
+
+	// Init the app
+	v.InitStart(log)
+
+	v.event = make(chan struct{}, 1)
+
+	log.Debug("verifier started")
+
+	// Start the ticker
+	v.Wg.Add(1)
+	go func() {
+		defer v.Wg.Done()
+		v.ticker(log)
+	}()
+
+	// Start the verifier
+	var err error
+	v.Wg.Add(1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = errors.New("panic recovered").Fatal().AddContext(errors.Context{
+					"sub_app": AppName,
+				})
+				v.Stop(log)
+			}
+
+			v.Wg.Done()
+		}()
+		v.verifier(log)
+	}()
+
+	defer log.Debug("verifier stopped")
+
+	v.Wg.Wait()
+
+	return err
+}
+
+func (v *Verifier) ticker(log *logrus.Entry) {
+	ticker := time.NewTicker(v.config.Verify.Timer)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			log.Debug("verifier timer triggered")
+			v.event <- struct{}{}
+		case <-v.Done:
+			log.Debug("verifier timer stopped")
+			return
+		}
+	}
+}
+
+func (v *Verifier) verifier(log *logrus.Entry) {
+	for {
+		select {
+		case <-v.event:
+			log.Debug("verifier event")
+			if _, err := v.Verify(log); err != nil {
+				errors.LogErrors(log, err)
+			}
+		case <-v.Done:
+			log.Debug("verifier done handling events")
+			return
+		}
+	}
+}
+
+// Results returns the videos which failed the last verification and the
+// date of the last verification
+func (v *Verifier) Results() ([]*library.VerifyResult, *time.Time) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.results, v.lastRun
+}
+
+// Verify verifies the library files and grabs again the videos failing the
+// verification if configured to
+func (v *Verifier) Verify(log *logrus.Entry) ([]*library.VerifyResult, error) {
+	results, err := v.library.Verify(log)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	v.mu.Lock()
+	v.results = results
+	v.lastRun = &now
+	v.mu.Unlock()
+
+	if len(results) == 0 {
+		log.Debug("all the library files are intact")
+		return results, nil
+	}
+
+	for _, r := range results {
+		log.WithFields(logrus.Fields{
+			"type":    r.Type,
+			"imdb_id": r.ImdbID,
+			"status":  r.Status,
+		}).Warn("video file failed the verification")
+	}
+
+	if !v.config.Verify.Requeue || v.downloader == nil {
+		return results, nil
+	}
+
+	v.requeue(results, log)
+	return results, nil
+}
+
+// requeue removes the broken videos from the library and triggers the
+// downloader so that they're downloaded again
+func (v *Verifier) requeue(results []*library.VerifyResult, log *logrus.Entry) {
+	for _, r := range results {
+		var video polochon.Video
+		var err error
+		if r.IsMovie() {
+			video, err = v.library.GetMovie(r.ImdbID)
+		} else {
+			video, err = v.library.GetEpisode(r.ImdbID, r.Season, r.Episode)
+		}
+		if err != nil {
+			log.Errorf("failed to get video from the library: %q", err)
+			continue
+		}
+
+		if err := v.library.Delete(video, log); err != nil {
+			log.Errorf("failed to delete video from the library: %q", err)
+			continue
+		}
+
+		// Don't grab the same torrent again
+		err = v.downloader.History().Failed(history.Metadata(video), string(r.Status))
+		if err != nil && err != history.ErrNotFound {
+			log.Errorf("failed to update the history: %q", err)
+		}
+	}
+
+	log.Infof("%d video(s) requeued to the downloader", len(results))
+	v.downloader.Trigger()
+}
@@ -0,0 +1,227 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Custom errors
+var (
+	ErrUnknownFormat = errors.New("archive: unknown format")
+	ErrEncrypted     = errors.New("archive: password protected")
+	ErrCorrupt       = errors.New("archive: corrupt")
+)
+
+// Format represents the format of an archive
+type Format string
+
+// Available formats
+const (
+	FormatZip Format = "zip"
+	FormatRar Format = "rar"
+)
+
+// TempDirPrefix is the prefix of the directories where the archives are
+// extracted
+const TempDirPrefix = ".polochon-extract"
+
+var (
+	// rarPartRegexp matches the volumes named "name.part01.rar"
+	rarPartRegexp = regexp.MustCompile(`\.part(\d+)\.rar$`)
+	// oldVolumeRegexp matches the volumes following the first one in the old
+	// rar naming "name.r00" and in the split zip naming "name.z01"
+	oldVolumeRegexp = regexp.MustCompile(`\.[r-z]\d{2}$`)
+)
+
+// Volume returns the format of the archive volume and whether it's the first
+// volume of its set, the format is empty if the path is not an archive
+func Volume(path string) (Format, bool) {
+	name := strings.ToLower(filepath.Base(path))
+
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, true
+	case strings.HasSuffix(name, ".rar"):
+		m := rarPartRegexp.FindStringSubmatch(name)
+		if m == nil {
+			return FormatRar, true
+		}
+
+		n, _ := strconv.Atoi(m[1])
+		return FormatRar, n == 1
+	case oldVolumeRegexp.MatchString(name):
+		if strings.HasPrefix(filepath.Ext(name), ".z") {
+			return FormatZip, false
+		}
+		return FormatRar, false
+	default:
+		return "", false
+	}
+}
+
+// IsArchive returns true if the path is an archive volume
+func IsArchive(path string) bool {
+	format, _ := Volume(path)
+	return format != ""
+}
+
+// TempDir creates a directory next to the archive to extract it, it's on the
+// same filesystem so that the extracted files can be moved into the library.
+// The directory is named after the archive to be found by TempDirs
+func TempDir(path string) (string, error) {
+	return ioutil.TempDir(filepath.Dir(path), tempDirPattern(path))
+}
+
+// tempDirPattern returns the beginning of the name of the extraction
+// directories of an archive
+func tempDirPattern(path string) string {
+	return TempDirPrefix + "-" + filepath.Base(path) + "-"
+}
+
+// IsTempDir returns true if the path is in an extraction directory
+func IsTempDir(path string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(path), "/") {
+		if strings.HasPrefix(part, TempDirPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TempDirs returns the extraction directories of an archive
+func TempDirs(path string) ([]string, error) {
+	pattern := globEscaper.Replace(tempDirPattern(path)) + "*"
+	return filepath.Glob(filepath.Join(filepath.Dir(path), pattern))
+}
+
+// globEscaper escapes the special characters of the glob patterns
+var globEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+
+// Extractor extracts the archives
+type Extractor struct {
+	// Unrar is the path of the unrar binary
+	Unrar string
+}
+
+// Extract extracts the files of the archive in the destination directory, the
+// directories of the archive are not kept
+func (e *Extractor) Extract(path, dest string) error {
+	format, first := Volume(path)
+	if !first {
+		return ErrUnknownFormat
+	}
+
+	switch format {
+	case FormatZip:
+		return extractZip(path, dest)
+	case FormatRar:
+		return e.extractRar(path, dest)
+	default:
+		return ErrUnknownFormat
+	}
+}
+
+// extractZip extracts a zip archive
+func extractZip(path, dest string) error {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return ErrCorrupt
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		// The first bit of the flags tells that the file is encrypted
+		if f.Flags&0x1 != 0 {
+			return ErrEncrypted
+		}
+
+		if err := extractZipFile(f, filepath.Join(dest, filepath.Base(f.Name))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// extractZipFile extracts a file of a zip archive
+func extractZipFile(f *zip.File, path string) error {
+	src, err := f.Open()
+	if err != nil {
+		return ErrCorrupt
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		if err == zip.ErrChecksum || err == zip.ErrFormat || err == io.ErrUnexpectedEOF {
+			return ErrCorrupt
+		}
+		return err
+	}
+
+	return dst.Close()
+}
+
+// Exit codes of unrar
+const (
+	unrarCRCError      = 3
+	unrarWrongPassword = 11
+)
+
+// extractRar extracts a rar archive and its volumes with unrar
+func (e *Extractor) extractRar(path, dest string) error {
+	unrar := e.Unrar
+	if unrar == "" {
+		unrar = "unrar"
+	}
+
+	// Extract without the paths, overwrite the existing files and never ask
+	// for a password
+	var out bytes.Buffer
+	cmd := exec.Command(unrar, "e", "-p-", "-o+", "-y", "-idq", path, dest+string(os.PathSeparator))
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		return err
+	}
+
+	switch exitErr.ExitCode() {
+	case unrarCRCError:
+		// Older versions of unrar report the encrypted archives as corrupt
+		if strings.Contains(strings.ToLower(out.String()), "password") {
+			return ErrEncrypted
+		}
+		return ErrCorrupt
+	case unrarWrongPassword:
+		return ErrEncrypted
+	default:
+		return fmt.Errorf("archive: unrar failed with code %d: %s", exitErr.ExitCode(), strings.TrimSpace(out.String()))
+	}
+}
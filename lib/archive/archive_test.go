@@ -0,0 +1,162 @@
+package archive
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVolume(t *testing.T) {
+	for _, tc := range []struct {
+		path   string
+		format Format
+		first  bool
+	}{
+		{path: "/downloads/Movie.2020.720p.mkv", format: "", first: false},
+		{path: "/downloads/movie.zip", format: FormatZip, first: true},
+		{path: "/downloads/movie.z01", format: FormatZip, first: false},
+		{path: "/downloads/movie.rar", format: FormatRar, first: true},
+		{path: "/downloads/movie.r00", format: FormatRar, first: false},
+		{path: "/downloads/movie.s12", format: FormatRar, first: false},
+		{path: "/downloads/Movie.part01.RAR", format: FormatRar, first: true},
+		{path: "/downloads/movie.part1.rar", format: FormatRar, first: true},
+		{path: "/downloads/movie.part02.rar", format: FormatRar, first: false},
+	} {
+		format, first := Volume(tc.path)
+		if format != tc.format || first != tc.first {
+			t.Errorf("%s: expected %q %t, got %q %t", tc.path, tc.format, tc.first, format, first)
+		}
+	}
+}
+
+func TestIsTempDir(t *testing.T) {
+	if !IsTempDir("/downloads/Movie/" + TempDirPrefix + "123/movie.mkv") {
+		t.Error("expected the path to be in an extraction directory")
+	}
+
+	if IsTempDir("/downloads/Movie/movie.mkv") {
+		t.Error("expected the path not to be in an extraction directory")
+	}
+}
+
+// writeZip creates a zip archive with the given files
+func writeZip(t *testing.T, path string, files map[string]string, flags uint16) {
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	for name, content := range files {
+		fw, err := w.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Flags: flags})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractZip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "movie.zip")
+	writeZip(t, path, map[string]string{
+		"Movie/movie.mkv":      "video",
+		"../../etc/passwd.nfo": "nfo",
+	}, 0)
+
+	dest, err := TempDir(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsTempDir(dest) {
+		t.Errorf("expected %q to be an extraction directory", dest)
+	}
+
+	e := &Extractor{}
+	if err := e.Extract(path, dest); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for name, expected := range map[string]string{
+		"movie.mkv":  "video",
+		"passwd.nfo": "nfo",
+	} {
+		content, err := ioutil.ReadFile(filepath.Join(dest, name))
+		if err != nil {
+			t.Fatalf("expected %s to be extracted, got %q", name, err)
+		}
+
+		if string(content) != expected {
+			t.Errorf("%s: expected %q, got %q", name, expected, content)
+		}
+	}
+
+	// The extraction directories of the other archives are not listed
+	other, err := TempDir(filepath.Join(dir, "other [1].zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tempDirs, err := TempDirs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tempDirs) != 1 || tempDirs[0] != dest {
+		t.Errorf("expected the extraction directory %q, got %v", dest, tempDirs)
+	}
+
+	tempDirs, err = TempDirs(filepath.Join(dir, "other [1].zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tempDirs) != 1 || tempDirs[0] != other {
+		t.Errorf("expected the extraction directory %q, got %v", other, tempDirs)
+	}
+}
+
+func TestExtractZipErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	encrypted := filepath.Join(dir, "encrypted.zip")
+	writeZip(t, encrypted, map[string]string{"movie.mkv": "video"}, 0x1)
+
+	corrupt := filepath.Join(dir, "corrupt.zip")
+	if err := ioutil.WriteFile(corrupt, []byte("not a zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &Extractor{}
+	for path, expected := range map[string]error{
+		encrypted:                             ErrEncrypted,
+		corrupt:                               ErrCorrupt,
+		filepath.Join(dir, "movie.r00"):       ErrUnknownFormat,
+		filepath.Join(dir, "movie.mkv"):       ErrUnknownFormat,
+		filepath.Join(dir, "movie.part2.rar"): ErrUnknownFormat,
+	} {
+		if err := e.Extract(path, dir); err != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, err)
+		}
+	}
+}
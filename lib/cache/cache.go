@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Result tells where a value returned by the store comes from
+type Result string
+
+// Available results
+const (
+	// Hit means the value was in the store and not expired
+	Hit Result = "hit"
+	// Miss means the value was fetched
+	Miss Result = "miss"
+	// Stale means the fetch failed and the expired value was used
+	Stale Result = "stale"
+)
+
+// entry represents a value in the store
+type entry struct {
+	Key       string          `json:"key"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Store holds the responses of the modules
+type Store struct {
+	sync.Mutex
+	// dir is the directory where the entries are stored, the entries are
+	// only kept in memory if empty
+	dir     string
+	entries map[string]*entry
+
+	// now can be replaced in the tests
+	now func() time.Time
+}
+
+// New returns a new store keeping its entries in the given directory, the
+// directory is created if needed
+func New(dir string) (*Store, error) {
+	if dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Store{
+		dir:     dir,
+		entries: map[string]*entry{},
+		now:     time.Now,
+	}, nil
+}
+
+// Get decodes the value of the key in v, the value is fetched if it's missing
+// or older than the ttl. The expired value is used if the fetch fails
+func (s *Store) Get(key string, ttl time.Duration, v interface{}, fetch func() (interface{}, error)) (Result, error) {
+	now := s.now()
+	cached := s.get(key)
+	if cached != nil && now.Sub(cached.FetchedAt) < ttl {
+		return Hit, json.Unmarshal(cached.Data, v)
+	}
+
+	value, err := fetch()
+	if err != nil {
+		if cached != nil {
+			return Stale, json.Unmarshal(cached.Data, v)
+		}
+		return Miss, err
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Miss, err
+	}
+
+	if err := s.set(&entry{Key: key, FetchedAt: now, Data: data}); err != nil {
+		return Miss, err
+	}
+
+	return Miss, json.Unmarshal(data, v)
+}
+
+// path returns the path of the file of an entry
+func (s *Store) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// get returns an entry from memory or from the disk, nil if not found
+func (s *Store) get(key string) *entry {
+	s.Lock()
+	defer s.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		return e
+	}
+
+	if s.dir == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		return nil
+	}
+
+	e := &entry{}
+	if err := json.Unmarshal(data, e); err != nil || e.Key != key {
+		return nil
+	}
+
+	s.entries[key] = e
+	return e
+}
+
+// set adds an entry in memory and writes it on the disk
+func (s *Store) set(e *entry) error {
+	s.Lock()
+	defer s.Unlock()
+
+	s.entries[e.Key] = e
+	if s.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// Write in a temporary file first not to corrupt the entry if something
+	// goes wrong
+	tmp, err := ioutil.TempFile(s.dir, ".entry")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path(e.Key))
+}
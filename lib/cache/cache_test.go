@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+type value struct {
+	Title string `json:"title"`
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := New(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	calls := 0
+	fetch := func() (interface{}, error) {
+		calls++
+		return &value{Title: "Ghost Dog"}, nil
+	}
+	failingFetch := func() (interface{}, error) {
+		calls++
+		return nil, errors.New("tmdb is down")
+	}
+
+	for _, step := range []struct {
+		name           string
+		elapsed        time.Duration
+		fetch          func() (interface{}, error)
+		expectedResult Result
+		expectedCalls  int
+	}{
+		{name: "not cached", fetch: fetch, expectedResult: Miss, expectedCalls: 1},
+		{name: "cached", elapsed: 30 * time.Minute, fetch: fetch, expectedResult: Hit, expectedCalls: 1},
+		{name: "expired", elapsed: 2 * time.Hour, fetch: fetch, expectedResult: Miss, expectedCalls: 2},
+		{name: "stale on error", elapsed: 4 * time.Hour, fetch: failingFetch, expectedResult: Stale, expectedCalls: 3},
+	} {
+		store.now = func() time.Time { return now.Add(step.elapsed) }
+
+		v := &value{}
+		result, err := store.Get("movie/tt0165798", time.Hour, v, step.fetch)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %q", step.name, err)
+		}
+
+		if result != step.expectedResult {
+			t.Errorf("%s: expected result %q, got %q", step.name, step.expectedResult, result)
+		}
+
+		if v.Title != "Ghost Dog" {
+			t.Errorf("%s: invalid value %+v", step.name, v)
+		}
+
+		if calls != step.expectedCalls {
+			t.Errorf("%s: expected %d calls, got %d", step.name, step.expectedCalls, calls)
+		}
+	}
+
+	if _, err := store.Get("movie/tt0110413", time.Hour, &value{}, failingFetch); err == nil {
+		t.Errorf("expected an error for a key not in cache")
+	}
+
+	// The entries should be read from the disk by a new store
+	other, err := New(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	other.now = func() time.Time { return now.Add(150 * time.Minute) }
+
+	v := &value{}
+	result, err := other.Get("movie/tt0165798", time.Hour, v, failingFetch)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if result != Hit || v.Title != "Ghost Dog" {
+		t.Errorf("expected the value to be read from the disk, got %q %+v", result, v)
+	}
+}
@@ -0,0 +1,255 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+// movieDetails holds the fields of a movie set by the detailers
+type movieDetails struct {
+	ImdbID        string   `json:"imdb_id"`
+	OriginalTitle string   `json:"original_title"`
+	Plot          string   `json:"plot"`
+	Rating        float32  `json:"rating"`
+	Runtime       int      `json:"runtime"`
+	SortTitle     string   `json:"sort_title"`
+	Tagline       string   `json:"tag_line"`
+	Thumb         string   `json:"thumb"`
+	Fanart        string   `json:"fanart"`
+	Title         string   `json:"title"`
+	TmdbID        int      `json:"tmdb_id"`
+	Votes         int      `json:"votes"`
+	Year          int      `json:"year"`
+	Genres        []string `json:"genres"`
+	// DetailSources is set when the details of several detailers are merged
+	DetailSources map[string]string `json:"detail_sources,omitempty"`
+}
+
+func newMovieDetails(m *polochon.Movie) *movieDetails {
+	return &movieDetails{
+		ImdbID:        m.ImdbID,
+		OriginalTitle: m.OriginalTitle,
+		Plot:          m.Plot,
+		Rating:        m.Rating,
+		Runtime:       m.Runtime,
+		SortTitle:     m.SortTitle,
+		Tagline:       m.Tagline,
+		Thumb:         m.Thumb,
+		Fanart:        m.Fanart,
+		Title:         m.Title,
+		TmdbID:        m.TmdbID,
+		Votes:         m.Votes,
+		Year:          m.Year,
+		Genres:        m.Genres,
+		DetailSources: m.DetailSources,
+	}
+}
+
+func (d *movieDetails) apply(m *polochon.Movie) {
+	m.ImdbID = d.ImdbID
+	m.OriginalTitle = d.OriginalTitle
+	m.Plot = d.Plot
+	m.Rating = d.Rating
+	m.Runtime = d.Runtime
+	m.SortTitle = d.SortTitle
+	m.Tagline = d.Tagline
+	m.Thumb = d.Thumb
+	m.Fanart = d.Fanart
+	m.Title = d.Title
+	m.TmdbID = d.TmdbID
+	m.Votes = d.Votes
+	m.Year = d.Year
+	m.Genres = d.Genres
+	m.DetailSources = d.DetailSources
+}
+
+// episodeDetails holds the fields of an episode set by the detailers
+type episodeDetails struct {
+	Title         string  `json:"title"`
+	ShowTitle     string  `json:"show_title"`
+	Season        int     `json:"season"`
+	Episode       int     `json:"episode"`
+	TvdbID        int     `json:"tvdb_id"`
+	Aired         string  `json:"aired"`
+	Plot          string  `json:"plot"`
+	Runtime       int     `json:"runtime"`
+	Thumb         string  `json:"thumb"`
+	Rating        float32 `json:"rating"`
+	ShowImdbID    string  `json:"show_imdb_id"`
+	ShowTvdbID    int     `json:"show_tvdb_id"`
+	EpisodeImdbID string  `json:"imdb_id"`
+	// EpisodeEnd and AbsoluteNumber are set for the multi-episode files and
+	// the anime shows
+	EpisodeEnd     int               `json:"episode_end,omitempty"`
+	AbsoluteNumber int               `json:"absolute_number,omitempty"`
+	DetailSources  map[string]string `json:"detail_sources,omitempty"`
+}
+
+func newEpisodeDetails(e *polochon.ShowEpisode) *episodeDetails {
+	return &episodeDetails{
+		Title:          e.Title,
+		ShowTitle:      e.ShowTitle,
+		Season:         e.Season,
+		Episode:        e.Episode,
+		TvdbID:         e.TvdbID,
+		Aired:          e.Aired,
+		Plot:           e.Plot,
+		Runtime:        e.Runtime,
+		Thumb:          e.Thumb,
+		Rating:         e.Rating,
+		ShowImdbID:     e.ShowImdbID,
+		ShowTvdbID:     e.ShowTvdbID,
+		EpisodeImdbID:  e.EpisodeImdbID,
+		EpisodeEnd:     e.EpisodeEnd,
+		AbsoluteNumber: e.AbsoluteNumber,
+		DetailSources:  e.DetailSources,
+	}
+}
+
+func (d *episodeDetails) apply(e *polochon.ShowEpisode) {
+	e.Title = d.Title
+	e.ShowTitle = d.ShowTitle
+	e.Season = d.Season
+	e.Episode = d.Episode
+	e.TvdbID = d.TvdbID
+	e.Aired = d.Aired
+	e.Plot = d.Plot
+	e.Runtime = d.Runtime
+	e.Thumb = d.Thumb
+	e.Rating = d.Rating
+	e.ShowImdbID = d.ShowImdbID
+	e.ShowTvdbID = d.ShowTvdbID
+	e.EpisodeImdbID = d.EpisodeImdbID
+	e.EpisodeEnd = d.EpisodeEnd
+	e.AbsoluteNumber = d.AbsoluteNumber
+	e.DetailSources = d.DetailSources
+}
+
+// showDetails holds the fields of a show set by the detailers
+type showDetails struct {
+	Title      string            `json:"title"`
+	Rating     float32           `json:"rating"`
+	Plot       string            `json:"plot"`
+	URL        string            `json:"url"`
+	TvdbID     int               `json:"tvdb_id"`
+	ImdbID     string            `json:"imdb_id"`
+	Year       int               `json:"year"`
+	FirstAired *time.Time        `json:"first_aired"`
+	Banner     string            `json:"banner"`
+	Fanart     string            `json:"fanart"`
+	Poster     string            `json:"poster"`
+	Episodes   []*episodeDetails `json:"episodes"`
+	// DetailSources is set when the details of several detailers are merged
+	DetailSources map[string]string `json:"detail_sources,omitempty"`
+}
+
+func newShowDetails(s *polochon.Show) *showDetails {
+	d := &showDetails{
+		Title:      s.Title,
+		Rating:     s.Rating,
+		Plot:       s.Plot,
+		URL:        s.URL,
+		TvdbID:     s.TvdbID,
+		ImdbID:     s.ImdbID,
+		Year:       s.Year,
+		FirstAired: s.FirstAired,
+		Banner:     s.Banner,
+		Fanart:     s.Fanart,
+		Poster:     s.Poster,
+
+		DetailSources: s.DetailSources,
+	}
+
+	for _, e := range s.Episodes {
+		d.Episodes = append(d.Episodes, newEpisodeDetails(e))
+	}
+
+	return d
+}
+
+func (d *showDetails) apply(s *polochon.Show) {
+	s.Title = d.Title
+	s.Rating = d.Rating
+	s.Plot = d.Plot
+	s.URL = d.URL
+	s.TvdbID = d.TvdbID
+	s.ImdbID = d.ImdbID
+	s.Year = d.Year
+	s.FirstAired = d.FirstAired
+	s.Banner = d.Banner
+	s.Fanart = d.Fanart
+	s.Poster = d.Poster
+	s.DetailSources = d.DetailSources
+
+	// The episodes share the config of their show
+	s.Episodes = nil
+	for _, ed := range d.Episodes {
+		e := polochon.NewShowEpisode(s.ShowConfig)
+		ed.apply(e)
+		s.Episodes = append(s.Episodes, e)
+	}
+}
+
+// newShowList returns the details of a list of shows, the shows are not
+// serialized directly as most of their details are not exported in JSON
+func newShowList(shows []*polochon.Show) []*showDetails {
+	list := make([]*showDetails, 0, len(shows))
+	for _, s := range shows {
+		list = append(list, newShowDetails(s))
+	}
+
+	return list
+}
+
+// showList returns the shows of a list of show details
+func showList(list []*showDetails) []*polochon.Show {
+	shows := make([]*polochon.Show, 0, len(list))
+	for _, d := range list {
+		s := polochon.NewShow(polochon.ShowConfig{})
+		d.apply(s)
+		shows = append(shows, s)
+	}
+
+	return shows
+}
+
+// detailsKey returns the key of the details of a video, an empty key means
+// the video can't be identified and its details are not cached
+func detailsKey(i interface{}) string {
+	switch v := i.(type) {
+	case *polochon.Movie:
+		switch {
+		case v.ImdbID != "":
+			return "movie/" + v.ImdbID
+		case v.TmdbID != 0:
+			return fmt.Sprintf("movie/tmdb-%d", v.TmdbID)
+		case v.Title != "":
+			return fmt.Sprintf("movie/%s-%d", v.Title, v.Year)
+		}
+	case *polochon.Show:
+		switch {
+		case v.ImdbID != "":
+			return "show/" + v.ImdbID
+		case v.TvdbID != 0:
+			return fmt.Sprintf("show/tvdb-%d", v.TvdbID)
+		case v.Title != "":
+			return fmt.Sprintf("show/%s-%d", v.Title, v.Year)
+		}
+	case *polochon.ShowEpisode:
+		if v.ShowImdbID == "" {
+			break
+		}
+
+		// The episodes of the anime shows might only be known by their
+		// absolute number
+		key := fmt.Sprintf("episode/%s-s%02de%02d", v.ShowImdbID, v.Season, v.Episode)
+		if v.AbsoluteNumber != 0 {
+			key += fmt.Sprintf("-a%d", v.AbsoluteNumber)
+		}
+		return key
+	}
+
+	return ""
+}
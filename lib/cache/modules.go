@@ -0,0 +1,194 @@
+package cache
+
+import (
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "polochon",
+	Subsystem: "module_cache",
+	Name:      "requests_total",
+	Help:      "Number of requests to the module cache by module and result.",
+}, []string{"module", "type", "result"})
+
+// module holds what is needed to cache the responses of a module
+type module struct {
+	store *Store
+	ttl   time.Duration
+	name  string
+	typ   polochon.ModuleType
+}
+
+// get gets a response of the module from the store
+func (m *module) get(key string, v interface{}, log *logrus.Entry, fetch func() (interface{}, error)) error {
+	result, err := m.store.Get(string(m.typ)+"/"+m.name+"/"+key, m.ttl, v, fetch)
+	requests.WithLabelValues(m.name, string(m.typ), string(result)).Inc()
+	if err != nil {
+		return err
+	}
+
+	if result == Stale {
+		log.WithField("module", m.name).Warn("module failed, using an expired response from the cache")
+	}
+
+	return nil
+}
+
+// Detailer returns a detailer caching the details for the ttl
+func Detailer(d polochon.Detailer, s *Store, ttl time.Duration) polochon.Detailer {
+	return &detailer{
+		Detailer: d,
+		module:   module{store: s, ttl: ttl, name: d.Name(), typ: polochon.TypeDetailer},
+	}
+}
+
+type detailer struct {
+	polochon.Detailer
+	module
+}
+
+func (d *detailer) GetDetails(i interface{}, log *logrus.Entry) error {
+	key := detailsKey(i)
+	if key == "" {
+		return d.Detailer.GetDetails(i, log)
+	}
+
+	switch v := i.(type) {
+	case *polochon.Movie:
+		details := &movieDetails{}
+		err := d.get(key, details, log, func() (interface{}, error) {
+			err := d.Detailer.GetDetails(v, log)
+			return newMovieDetails(v), err
+		})
+		if err != nil {
+			return err
+		}
+		details.apply(v)
+	case *polochon.Show:
+		details := &showDetails{}
+		err := d.get(key, details, log, func() (interface{}, error) {
+			err := d.Detailer.GetDetails(v, log)
+			return newShowDetails(v), err
+		})
+		if err != nil {
+			return err
+		}
+		details.apply(v)
+	case *polochon.ShowEpisode:
+		details := &episodeDetails{}
+		err := d.get(key, details, log, func() (interface{}, error) {
+			err := d.Detailer.GetDetails(v, log)
+			return newEpisodeDetails(v), err
+		})
+		if err != nil {
+			return err
+		}
+		details.apply(v)
+	default:
+		return d.Detailer.GetDetails(i, log)
+	}
+
+	return nil
+}
+
+// Calendar returns a calendar caching the show calendars for the ttl
+func Calendar(c polochon.Calendar, s *Store, ttl time.Duration) polochon.Calendar {
+	return &calendar{
+		Calendar: c,
+		module:   module{store: s, ttl: ttl, name: c.Name(), typ: polochon.TypeCalendar},
+	}
+}
+
+type calendar struct {
+	polochon.Calendar
+	module
+}
+
+func (c *calendar) GetShowCalendar(s *polochon.Show, log *logrus.Entry) (*polochon.ShowCalendar, error) {
+	if s.ImdbID == "" {
+		return c.Calendar.GetShowCalendar(s, log)
+	}
+
+	calendar := &polochon.ShowCalendar{}
+	err := c.get(s.ImdbID, calendar, log, func() (interface{}, error) {
+		return c.Calendar.GetShowCalendar(s, log)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return calendar, nil
+}
+
+// Searcher returns a searcher caching the results for the ttl
+func Searcher(sr polochon.Searcher, s *Store, ttl time.Duration) polochon.Searcher {
+	return &searcher{
+		Searcher: sr,
+		module:   module{store: s, ttl: ttl, name: sr.Name(), typ: polochon.TypeSearcher},
+	}
+}
+
+type searcher struct {
+	polochon.Searcher
+	module
+}
+
+func (s *searcher) SearchMovie(key string, log *logrus.Entry) ([]*polochon.Movie, error) {
+	movies := []*polochon.Movie{}
+	err := s.get("movie/"+key, &movies, log, func() (interface{}, error) {
+		return s.Searcher.SearchMovie(key, log)
+	})
+	return movies, err
+}
+
+func (s *searcher) SearchShow(key string, log *logrus.Entry) ([]*polochon.Show, error) {
+	list := []*showDetails{}
+	err := s.get("show/"+key, &list, log, func() (interface{}, error) {
+		shows, err := s.Searcher.SearchShow(key, log)
+		return newShowList(shows), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return showList(list), nil
+}
+
+// Explorer returns an explorer caching the lists for the ttl
+func Explorer(e polochon.Explorer, s *Store, ttl time.Duration) polochon.Explorer {
+	return &explorer{
+		Explorer: e,
+		module:   module{store: s, ttl: ttl, name: e.Name(), typ: polochon.TypeExplorer},
+	}
+}
+
+type explorer struct {
+	polochon.Explorer
+	module
+}
+
+func (e *explorer) GetMovieList(option string, log *logrus.Entry) ([]*polochon.Movie, error) {
+	movies := []*polochon.Movie{}
+	err := e.get("movie/"+option, &movies, log, func() (interface{}, error) {
+		return e.Explorer.GetMovieList(option, log)
+	})
+	return movies, err
+}
+
+func (e *explorer) GetShowList(option string, log *logrus.Entry) ([]*polochon.Show, error) {
+	list := []*showDetails{}
+	err := e.get("show/"+option, &list, log, func() (interface{}, error) {
+		shows, err := e.Explorer.GetShowList(option, log)
+		return newShowList(shows), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return showList(list), nil
+}
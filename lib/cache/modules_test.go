@@ -0,0 +1,123 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/sirupsen/logrus"
+)
+
+type fakeDetailer struct {
+	calls int
+}
+
+func (d *fakeDetailer) Init([]byte) error { return nil }
+func (d *fakeDetailer) Name() string      { return "fake" }
+func (d *fakeDetailer) Status() (polochon.ModuleStatus, error) {
+	return polochon.StatusOK, nil
+}
+
+func (d *fakeDetailer) GetDetails(i interface{}, log *logrus.Entry) error {
+	d.calls++
+	s := i.(*polochon.Show)
+	s.Title = "Game of Thrones"
+	s.Banner = "banner.jpg"
+	s.Episodes = []*polochon.ShowEpisode{{Season: 1, Episode: 1, AbsoluteNumber: 1, Title: "Winter Is Coming"}}
+	return nil
+}
+
+func TestDetailer(t *testing.T) {
+	store, err := New("")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	fake := &fakeDetailer{}
+	d := Detailer(fake, store, time.Hour)
+	log := logrus.NewEntry(logrus.New())
+
+	conf := polochon.ShowConfig{AnimeShows: []string{"tt0944947"}}
+	for i := 0; i < 2; i++ {
+		s := polochon.NewShow(conf)
+		s.ImdbID = "tt0944947"
+		if err := d.GetDetails(s, log); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if s.Title != "Game of Thrones" || s.Banner != "banner.jpg" {
+			t.Errorf("invalid show details %+v", s)
+		}
+
+		if len(s.Episodes) != 1 || s.Episodes[0].Title != "Winter Is Coming" || s.Episodes[0].AbsoluteNumber != 1 {
+			t.Errorf("invalid show episodes %+v", s.Episodes)
+		}
+
+		if !s.Episodes[0].IsAnime("tt0944947") {
+			t.Errorf("expected the episodes to keep the show config")
+		}
+	}
+
+	if fake.calls != 1 {
+		t.Errorf("expected the details to be fetched once, got %d calls", fake.calls)
+	}
+}
+
+type fakeExplorer struct {
+	calls int
+}
+
+func (e *fakeExplorer) Init([]byte) error { return nil }
+func (e *fakeExplorer) Name() string      { return "fake" }
+func (e *fakeExplorer) Status() (polochon.ModuleStatus, error) {
+	return polochon.StatusOK, nil
+}
+
+func (e *fakeExplorer) AvailableMovieOptions() []string { return nil }
+func (e *fakeExplorer) AvailableShowOptions() []string  { return []string{"popular"} }
+
+func (e *fakeExplorer) GetMovieList(string, *logrus.Entry) ([]*polochon.Movie, error) {
+	return nil, polochon.ErrNotAvailable
+}
+
+func (e *fakeExplorer) GetShowList(string, *logrus.Entry) ([]*polochon.Show, error) {
+	e.calls++
+	s := polochon.NewShow(polochon.ShowConfig{})
+	s.ImdbID = "tt0944947"
+	s.URL = "http://example.com/show"
+	s.Banner = "banner.jpg"
+	s.Fanart = "fanart.jpg"
+	s.Poster = "poster.jpg"
+	return []*polochon.Show{s}, nil
+}
+
+func TestExplorerShowList(t *testing.T) {
+	store, err := New("")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	fake := &fakeExplorer{}
+	e := Explorer(fake, store, time.Hour)
+	log := logrus.NewEntry(logrus.New())
+
+	for i := 0; i < 2; i++ {
+		shows, err := e.GetShowList("popular", log)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if len(shows) != 1 {
+			t.Fatalf("expected one show, got %d", len(shows))
+		}
+
+		s := shows[0]
+		if s.ImdbID != "tt0944947" || s.URL != "http://example.com/show" || s.Banner != "banner.jpg" || s.Fanart != "fanart.jpg" || s.Poster != "poster.jpg" {
+			t.Errorf("invalid show %+v", s)
+		}
+	}
+
+	if fake.calls != 1 {
+		t.Errorf("expected the list to be fetched once, got %d calls", fake.calls)
+	}
+}
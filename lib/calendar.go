@@ -1,11 +1,25 @@
 package polochon
 
 import (
+	"errors"
 	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// AiredDateFormat is the format of the air date of the episodes
+const AiredDateFormat = "2006-01-02"
+
+// Custom errors
+var (
+	// ErrNoEpisodeAiredOn is returned if no episode of the show aired on a
+	// date
+	ErrNoEpisodeAiredOn = errors.New("calendar: no episode aired on the date")
+	// ErrNotDailyShow is returned if an episode is identified by its air date
+	// but its show is not a daily show
+	ErrNotDailyShow = errors.New("calendar: the show is not a daily show")
+)
+
 // Calendar is an interface to get the calendar for movies and shows
 type Calendar interface {
 	Module
@@ -26,11 +40,73 @@ func NewShowCalendar(imdbID string) *ShowCalendar {
 	}
 }
 
+// EpisodeAiredOn returns the first episode aired on the date, nil if no
+// episode aired that day
+func (c *ShowCalendar) EpisodeAiredOn(date time.Time) *ShowCalendarEpisode {
+	y, m, d := date.Date()
+	for _, e := range c.Episodes {
+		// Skip the specials aired along with the regular episodes
+		if e.AiredDate == nil || e.Season == 0 {
+			continue
+		}
+
+		ey, em, ed := e.AiredDate.Date()
+		if ey == y && em == m && ed == d {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// ResolveAiredDate finds the season and the episode of an episode identified
+// by its air date in the calendar of its show, only the episodes of the daily
+// shows are identified by their air date
+func ResolveAiredDate(e *ShowEpisode, log *logrus.Entry) error {
+	aired, err := time.Parse(AiredDateFormat, e.Aired)
+	if err != nil {
+		return err
+	}
+
+	show := e.Show
+	if show == nil {
+		show = NewShowFromEpisode(e)
+		show.Calendar = e.Calendar
+	}
+
+	calendar, cErr := show.GetCalendar(log)
+	if cErr != nil {
+		return cErr
+	}
+
+	imdbID := e.ShowImdbID
+	if imdbID == "" {
+		imdbID = calendar.ImdbID
+	}
+	if !e.IsDaily(imdbID) {
+		return ErrNotDailyShow
+	}
+
+	ce := calendar.EpisodeAiredOn(aired)
+	if ce == nil {
+		return ErrNoEpisodeAiredOn
+	}
+
+	e.Season = ce.Season
+	e.Episode = ce.Episode
+	if e.ShowImdbID == "" {
+		e.ShowImdbID = calendar.ImdbID
+	}
+
+	return nil
+}
+
 // ShowCalendarEpisode holds the episode calendar infos
 type ShowCalendarEpisode struct {
-	Season    int
-	Episode   int
-	AiredDate *time.Time
+	Season         int
+	Episode        int
+	AbsoluteNumber int
+	AiredDate      *time.Time
 }
 
 // IsAvailable tells if the episode is currently available
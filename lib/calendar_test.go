@@ -3,6 +3,8 @@ package polochon
 import (
 	"testing"
 	"time"
+
+	"github.com/sirupsen/logrus"
 )
 
 func TestNewShowCalendar(t *testing.T) {
@@ -85,3 +87,73 @@ func TestShowCalendarIsOlder(t *testing.T) {
 		})
 	}
 }
+
+// fakeCalendar returns the same calendar for every show
+type fakeCalendar struct {
+	calendar *ShowCalendar
+}
+
+func (f *fakeCalendar) Init(p []byte) error           { return nil }
+func (f *fakeCalendar) Name() string                  { return "fake" }
+func (f *fakeCalendar) Status() (ModuleStatus, error) { return StatusOK, nil }
+func (f *fakeCalendar) GetShowCalendar(s *Show, log *logrus.Entry) (*ShowCalendar, error) {
+	return f.calendar, nil
+}
+
+func TestResolveAiredDate(t *testing.T) {
+	day := func(d int) *time.Time {
+		date := time.Date(2026, time.October, d, 23, 30, 0, 0, time.UTC)
+		return &date
+	}
+
+	calendar := NewShowCalendar("tt0115147")
+	calendar.Episodes = []*ShowCalendarEpisode{
+		{Season: 0, Episode: 1, AiredDate: day(15)},
+		{Season: 31, Episode: 11, AiredDate: day(14)},
+		{Season: 31, Episode: 12, AiredDate: day(15)},
+		{Season: 31, Episode: 13},
+	}
+
+	log := logrus.NewEntry(logrus.New())
+	conf := ShowConfig{
+		Calendar:   &fakeCalendar{calendar: calendar},
+		DailyShows: []string{"tt0115147"},
+	}
+
+	e := NewShowEpisode(conf)
+	e.ShowTitle = "The Daily Show"
+	e.Aired = "2026-10-15"
+	if !e.IsDateBased() {
+		t.Fatal("expected the episode to be date based")
+	}
+
+	if err := ResolveAiredDate(e, log); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if e.Season != 31 || e.Episode != 12 || e.ShowImdbID != "tt0115147" {
+		t.Errorf("expected S31E12 of tt0115147, got S%02dE%02d of %s", e.Season, e.Episode, e.ShowImdbID)
+	}
+
+	if e.IsDateBased() {
+		t.Error("expected the episode not to be date based anymore")
+	}
+
+	e = NewShowEpisode(conf)
+	e.Aired = "2026-10-16"
+	if err := ResolveAiredDate(e, log); err != ErrNoEpisodeAiredOn {
+		t.Errorf("expected %q, got %q", ErrNoEpisodeAiredOn, err)
+	}
+
+	// Only the episodes of the daily shows are identified by their air date
+	conf.DailyShows = nil
+	e = NewShowEpisode(conf)
+	e.Aired = "2026-10-15"
+	if err := ResolveAiredDate(e, log); err != ErrNotDailyShow {
+		t.Errorf("expected %q, got %q", ErrNotDailyShow, err)
+	}
+
+	if e.Season != 0 || e.Episode != 0 {
+		t.Errorf("expected the episode not to be resolved, got S%02dE%02d", e.Season, e.Episode)
+	}
+}
@@ -3,9 +3,13 @@ package configuration
 import (
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/disk"
+	"github.com/odwrtw/polochon/lib/naming"
 	"github.com/robfig/cron/v3"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -24,19 +28,51 @@ type Config struct {
 	Library           LibraryConfig
 	Notifiers         []polochon.Notifier
 	SubtitleLanguages []polochon.Language
+	SubtitleBackfill  SubtitleBackfillConfig
+	Verify            VerifyConfig
+	Archive           ArchiveConfig
+	DiskGuard         DiskGuardConfig
+	AiredDateRetry    AiredDateRetryConfig
+
+	// modulesParams holds the limiters shared by the modules
+	modulesParams *ModulesParams
+}
+
+// Close stops the limiters of the modules, the calls waiting for them are let
+// through
+func (c *Config) Close() {
+	if c.modulesParams != nil {
+		c.modulesParams.close()
+	}
 }
 
 // UnmarshalYAML implements the Unmarshaler interface
 func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	// Read the module params to be used later
 	params := struct {
-		ModulesParams *ModulesParams `yaml:"modules_params"`
+		ModulesParams *ModulesParams             `yaml:"modules_params"`
+		RateLimits    map[string]RateLimitConfig `yaml:"rate_limits"`
+		ModuleCache   *ModuleCacheConfig         `yaml:"module_cache"`
 	}{}
 
 	if err := unmarshal(&params); err != nil {
 		return err
 	}
 
+	// The modules, their rate limits and their cache can't be set up
+	// without the module params
+	if params.ModulesParams == nil {
+		return ErrMissingModuleParams
+	}
+
+	params.ModulesParams.setRateLimits(params.RateLimits)
+
+	if params.ModuleCache != nil {
+		if err := params.ModulesParams.setCache(params.ModuleCache); err != nil {
+			return err
+		}
+	}
+
 	// Read the rest of the file and use the module params to initiate the modules
 	cf := &configFile{modulesParams: params.ModulesParams}
 	if err := unmarshal(cf); err != nil {
@@ -44,19 +80,99 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	}
 
 	// Load the configuration
+	c.modulesParams = params.ModulesParams
 	return loadConfig(cf, c)
 }
 
+// Placement is the policy choosing the root of the library storing a new
+// video when the library has several roots
+type Placement string
+
+// Placement policies
+const (
+	// PlacementMostFree stores the videos on the root with the most free
+	// space
+	PlacementMostFree Placement = "most_free"
+	// PlacementInOrder fills the roots in the order of the configuration
+	PlacementInOrder Placement = "in_order"
+	// PlacementSameRoot stores the episodes of a show on the root of its
+	// existing seasons, the new shows are stored on the root with the most
+	// free space
+	PlacementSameRoot Placement = "same_root"
+)
+
 // LibraryConfig represents configuration for the library
 type LibraryConfig struct {
 	MovieDir string
 	ShowDir  string
+	// MovieDirs and ShowDirs are the other roots of the library, the new
+	// videos are stored on the roots according to the placement policies
+	MovieDirs      []string
+	ShowDirs       []string
+	MoviePlacement Placement
+	ShowPlacement  Placement
+	// Checksum enables the SHA-256 checksum of the video files
+	Checksum bool
+	// Naming names the directories and the files of the library
+	Naming *naming.Namer
 }
 
-// WatcherConfig represents the configuration for the detailers
+// roots returns the main directory followed by the other roots
+func roots(dir string, dirs []string) []string {
+	r := []string{}
+	if dir != "" {
+		r = append(r, dir)
+	}
+
+	return append(r, dirs...)
+}
+
+// MovieRoots returns the directories storing the movies
+func (c LibraryConfig) MovieRoots() []string {
+	return roots(c.MovieDir, c.MovieDirs)
+}
+
+// ShowRoots returns the directories storing the shows
+func (c LibraryConfig) ShowRoots() []string {
+	return roots(c.ShowDir, c.ShowDirs)
+}
+
+// WatcherConfig represents the configuration of the watched directories
 type WatcherConfig struct {
+	Dirs []*WatchDir
+}
+
+// WatchDir represents a watched directory
+type WatchDir struct {
 	Dir        string
 	FsNotifier polochon.FsNotifier
+	// Type forces the type of the videos found in the directory, it's empty
+	// if the directory holds both movies and shows
+	Type polochon.VideoType
+	// ImportMode tells how the videos are imported in the library
+	ImportMode polochon.ImportMode
+	// Clean tells if the cleaner removes the finished downloads from the
+	// directory
+	Clean bool
+}
+
+// WatchDir returns the watched directory containing the path, nil if the
+// path is not in a watched directory
+func (c WatcherConfig) WatchDir(path string) *WatchDir {
+	var found *WatchDir
+	for _, d := range c.Dirs {
+		rel, err := filepath.Rel(d.Dir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			continue
+		}
+
+		// Keep the deepest directory if they're nested
+		if found == nil || len(d.Dir) > len(found.Dir) {
+			found = d
+		}
+	}
+
+	return found
 }
 
 // DownloaderConfig represents the configuration for the downloader
@@ -66,6 +182,41 @@ type DownloaderConfig struct {
 	Schedule        cron.Schedule
 	Client          polochon.Downloader
 	Cleaner         CleanerConfig
+	// HistoryFile is the path of the file where the grab history is stored
+	HistoryFile   string
+	EpisodeSearch EpisodeSearchConfig
+	// Workers is the number of wished items processed at the same time
+	Workers int
+}
+
+// EpisodeSearchConfig represents the configuration of the episodes search in
+// the configuration file
+type EpisodeSearchConfig struct {
+	// AirDelay is the time to wait after the air date before searching an
+	// episode
+	AirDelay time.Duration `yaml:"air_delay"`
+	// GiveUpAfter is the time after which a missing episode is not
+	// searched anymore
+	GiveUpAfter time.Duration `yaml:"give_up_after"`
+	// MaxSearchInterval caps the time between two searches of a missing
+	// episode
+	MaxSearchInterval time.Duration `yaml:"max_search_interval"`
+	// Shows holds the per show configurations by imdb id
+	Shows map[string]ShowSearchConfig `yaml:"shows"`
+}
+
+// ShowSearchConfig represents the search configuration of a show
+type ShowSearchConfig struct {
+	AirDelay time.Duration `yaml:"air_delay"`
+}
+
+// ShowAirDelay returns the air delay of a show
+func (c EpisodeSearchConfig) ShowAirDelay(imdbID string) time.Duration {
+	if show, ok := c.Shows[imdbID]; ok {
+		return show.AirDelay
+	}
+
+	return c.AirDelay
 }
 
 // CleanerConfig represents the configuration for the cleaner in the configuration file
@@ -73,6 +224,60 @@ type CleanerConfig struct {
 	Enabled bool          `yaml:"enabled"`
 	Timer   time.Duration `yaml:"timer"`
 	Ratio   float32       `yaml:"ratio"`
+	// StallTimeout is the time after which a torrent without any progress
+	// is considered stalled
+	StallTimeout time.Duration `yaml:"stall_timeout"`
+	// MaxDownloadTime is the time after which an unfinished torrent is
+	// considered dead
+	MaxDownloadTime time.Duration `yaml:"max_download_time"`
+}
+
+// SubtitleBackfillConfig represents the configuration for the subtitle
+// backfill in the configuration file
+type SubtitleBackfillConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Timer     time.Duration `yaml:"timer"`
+	MaxPerRun int           `yaml:"max_per_run"`
+	Backoff   time.Duration `yaml:"backoff"`
+	MaxAge    time.Duration `yaml:"max_age"`
+}
+
+// VerifyConfig represents the configuration for the verification of the
+// library files in the configuration file
+type VerifyConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Timer    time.Duration `yaml:"timer"`
+	Checksum bool          `yaml:"checksum"`
+	Requeue  bool          `yaml:"requeue"`
+}
+
+// ArchiveConfig represents the configuration for the extraction of the
+// archives in the configuration file
+type ArchiveConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Unrar is the path of the unrar binary used to extract the rar archives
+	Unrar string `yaml:"unrar"`
+}
+
+// DiskGuardConfig represents the configuration of the free space checks of
+// the watched and library directories in the configuration file
+type DiskGuardConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// MinFree is the free space under which the downloader stops grabbing
+	// and the organizer defers the imports
+	MinFree disk.Size `yaml:"min_free"`
+	// RetryInterval is the time between two attempts to import the deferred
+	// files
+	RetryInterval time.Duration `yaml:"retry_interval"`
+}
+
+// AiredDateRetryConfig represents the configuration of the retries of the
+// daily show episodes missing from the show calendar in the configuration file
+type AiredDateRetryConfig struct {
+	// Interval is the time between two attempts to import the episodes
+	Interval time.Duration `yaml:"interval"`
+	// MaxAge is the time after which the import is given up
+	MaxAge time.Duration `yaml:"max_age"`
 }
 
 // HTTPServer represents the configuration for the HTTP Server
@@ -2,11 +2,16 @@ package configuration
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
 	"reflect"
 	"testing"
 	"time"
 
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/disk"
+	"github.com/odwrtw/polochon/lib/instrument"
+	"github.com/odwrtw/polochon/lib/naming"
 	"github.com/odwrtw/polochon/modules/mock"
 	"github.com/robfig/cron/v3"
 )
@@ -22,10 +27,21 @@ downloader:
   launch_at_startup: true
   schedule: "@every 4h"
   client: mock
+  history_file: /tmp/history.json
+  workers: 4
+  episode_search:
+    air_delay: 2h
+    give_up_after: 720h
+    max_search_interval: 24h
+    shows:
+      tt0944947:
+        air_delay: 6h
   cleaner:
     enabled: true
     timer: 30s
     ratio: 0
+    stall_timeout: 48h
+    max_download_time: 336h
 http_server:
   enable: true
   port: 8080
@@ -63,6 +79,23 @@ video:
   subtitle_languages:
   - fr_FR
   - en_US
+  subtitle_backfill:
+    enabled: true
+    timer: 6h
+    max_per_run: 20
+    backoff: 1h
+    max_age: 720h
+  verify:
+    enabled: true
+    timer: 24h
+    checksum: true
+    requeue: true
+  archive:
+    enabled: true
+    unrar: /usr/bin/unrar
+  disk_guard:
+    enabled: true
+    min_free: 20GB
 show:
   calendar: mock
   dir: /tmp
@@ -72,8 +105,13 @@ show:
     - mock
   subtitlers:
     - mock
+  anime:
+    - tt0388629
+  daily:
+    - tt0115147
 movie:
   dir: /tmp
+  detailers_merge: true
   torrenters:
     - mock
   detailers:
@@ -101,10 +139,14 @@ func TestReadConfig(t *testing.T) {
 	}
 	got.Logger = nil
 
+	// The module params are only kept to stop the limiters
+	got.modulesParams = nil
+
 	expected := &Config{
 		Watcher: WatcherConfig{
-			Dir:        "/tmp",
-			FsNotifier: mock,
+			Dirs: []*WatchDir{
+				{Dir: "/tmp", FsNotifier: mock, Clean: true},
+			},
 		},
 		Downloader: DownloaderConfig{
 			Enabled:         true,
@@ -115,9 +157,21 @@ func TestReadConfig(t *testing.T) {
 			Client: mock,
 			Cleaner: CleanerConfig{
 
-				Enabled: true,
-				Timer:   30 * time.Second,
-				Ratio:   0,
+				Enabled:         true,
+				Timer:           30 * time.Second,
+				Ratio:           0,
+				StallTimeout:    48 * time.Hour,
+				MaxDownloadTime: 14 * 24 * time.Hour,
+			},
+			HistoryFile: "/tmp/history.json",
+			Workers:     4,
+			EpisodeSearch: EpisodeSearchConfig{
+				AirDelay:          2 * time.Hour,
+				GiveUpAfter:       30 * 24 * time.Hour,
+				MaxSearchInterval: 24 * time.Hour,
+				Shows: map[string]ShowSearchConfig{
+					"tt0944947": {AirDelay: 6 * time.Hour},
+				},
 			},
 		},
 		HTTPServer: HTTPServer{
@@ -135,15 +189,18 @@ func TestReadConfig(t *testing.T) {
 			MovieDefaultQualities: []polochon.Quality{"1080p", "720p"},
 		},
 		Movie: polochon.MovieConfig{
-			Torrenters: []polochon.Torrenter{mock},
-			Detailers:  []polochon.Detailer{mock},
-			Subtitlers: []polochon.Subtitler{mock},
+			Torrenters:     []polochon.Torrenter{instrument.Torrenter(mock)},
+			Detailers:      []polochon.Detailer{instrument.Detailer(mock)},
+			Subtitlers:     []polochon.Subtitler{instrument.Subtitler(mock)},
+			DetailersMerge: true,
 		},
 		Show: polochon.ShowConfig{
-			Calendar:   mock,
-			Torrenters: []polochon.Torrenter{mock},
-			Detailers:  []polochon.Detailer{mock},
-			Subtitlers: []polochon.Subtitler{mock},
+			Calendar:   instrument.Calendar(mock),
+			Torrenters: []polochon.Torrenter{instrument.Torrenter(mock)},
+			Detailers:  []polochon.Detailer{instrument.Detailer(mock)},
+			Subtitlers: []polochon.Subtitler{instrument.Subtitler(mock)},
+			AnimeShows: []string{"tt0388629"},
+			DailyShows: []string{"tt0115147"},
 		},
 		File: polochon.FileConfig{
 			ExcludeFileContaining:     []string{"sample"},
@@ -152,14 +209,390 @@ func TestReadConfig(t *testing.T) {
 			Guesser:                   mock,
 		},
 		Library: LibraryConfig{
-			MovieDir: "/tmp",
-			ShowDir:  "/tmp",
+			MovieDir:       "/tmp",
+			ShowDir:        "/tmp",
+			MoviePlacement: PlacementMostFree,
+			ShowPlacement:  PlacementSameRoot,
+			Checksum:       true,
+			Naming:         naming.Default(),
 		},
 		Notifiers:         []polochon.Notifier{mock},
 		SubtitleLanguages: []polochon.Language{"fr_FR", "en_US"},
+		SubtitleBackfill: SubtitleBackfillConfig{
+			Enabled:   true,
+			Timer:     6 * time.Hour,
+			MaxPerRun: 20,
+			Backoff:   time.Hour,
+			MaxAge:    720 * time.Hour,
+		},
+		Verify: VerifyConfig{
+			Enabled:  true,
+			Timer:    24 * time.Hour,
+			Checksum: true,
+			Requeue:  true,
+		},
+		Archive: ArchiveConfig{
+			Enabled: true,
+			Unrar:   "/usr/bin/unrar",
+		},
+		DiskGuard: DiskGuardConfig{
+			Enabled:       true,
+			MinFree:       20 * disk.GB,
+			RetryInterval: 10 * time.Minute,
+		},
+		AiredDateRetry: AiredDateRetryConfig{
+			Interval: time.Hour,
+			MaxAge:   168 * time.Hour,
+		},
 	}
 
 	if !reflect.DeepEqual(got, expected) {
 		t.Fatalf("invalid configuration\ngot:\n%+v\nexpected:\n%+v", got, expected)
 	}
 }
+
+func TestRateLimits(t *testing.T) {
+	polochon.ClearRegisteredModules()
+	m := &mock.Mock{}
+	polochon.RegisterModule(m)
+
+	buf := bytes.NewBufferString(`
+movie:
+  torrenters:
+    - mock
+  detailers:
+    - mock
+show:
+  calendar: mock
+  detailers:
+    - mock
+rate_limits:
+  mock:
+    rate: 1
+    burst: 2
+    concurrency: 1
+modules_params:
+  - name: mock
+`)
+	got, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("should not get any error but got %q", err)
+	}
+
+	for _, module := range []struct {
+		got          polochon.Module
+		instrumented polochon.Module
+	}{
+		{got.Movie.Torrenters[0], instrument.Torrenter(m)},
+		{got.Movie.Detailers[0], instrument.Detailer(m)},
+		{got.Show.Detailers[0], instrument.Detailer(m)},
+		{got.Show.Calendar, instrument.Calendar(m)},
+	} {
+		if reflect.DeepEqual(module.got, module.instrumented) {
+			t.Errorf("expected the module to be rate limited")
+		}
+
+		if module.got.Name() != m.Name() {
+			t.Errorf("expected module name %q, got %q", m.Name(), module.got.Name())
+		}
+	}
+
+	// The rate limits are not ignored without module params
+	buf = bytes.NewBufferString("rate_limits:\n  mock:\n    rate: 1\n")
+	if _, err := LoadConfig(buf); err != ErrMissingModuleParams {
+		t.Errorf("expected error %q, got %q", ErrMissingModuleParams, err)
+	}
+}
+
+func TestModuleCache(t *testing.T) {
+	polochon.ClearRegisteredModules()
+	m := &mock.Mock{}
+	polochon.RegisterModule(m)
+
+	buf := bytes.NewBufferString(`
+movie:
+  torrenters:
+    - mock
+  detailers:
+    - mock
+module_cache:
+  ttl:
+    detailer: 24h
+modules_params:
+  - name: mock
+`)
+	got, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("should not get any error but got %q", err)
+	}
+
+	if reflect.DeepEqual(got.Movie.Detailers[0], instrument.Detailer(m)) {
+		t.Errorf("expected the detailer to be cached")
+	}
+
+	if !reflect.DeepEqual(got.Movie.Torrenters[0], instrument.Torrenter(m)) {
+		t.Errorf("expected the torrenter not to be cached")
+	}
+}
+
+func TestShowAirDelay(t *testing.T) {
+	conf := EpisodeSearchConfig{
+		AirDelay: 2 * time.Hour,
+		Shows: map[string]ShowSearchConfig{
+			"tt0944947": {AirDelay: 12 * time.Hour},
+		},
+	}
+
+	for imdbID, expected := range map[string]time.Duration{
+		"tt0944947": 12 * time.Hour,
+		"tt2085059": 2 * time.Hour,
+	} {
+		if got := conf.ShowAirDelay(imdbID); got != expected {
+			t.Errorf("expected delay %s for %q, got %s", expected, imdbID, got)
+		}
+	}
+}
+
+func TestWatchDirs(t *testing.T) {
+	polochon.ClearRegisteredModules()
+	m := &mock.Mock{}
+	polochon.RegisterModule(m)
+
+	buf := bytes.NewBufferString(`
+watcher:
+  fsnotifier: mock
+  dir: /downloads
+  dirs:
+  - dir: /downloads/movies
+    type: movie
+    import_mode: hardlink
+  - dir: /mnt/shows
+    type: show
+    import_mode: copy
+    clean: false
+modules_params:
+  - name: mock
+`)
+	got, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("should not get any error but got %q", err)
+	}
+
+	expected := WatcherConfig{
+		Dirs: []*WatchDir{
+			{Dir: "/downloads", FsNotifier: m, Clean: true},
+			{Dir: "/downloads/movies", FsNotifier: m, Type: polochon.VideoTypeMovie, ImportMode: polochon.ImportHardlink, Clean: true},
+			{Dir: "/mnt/shows", FsNotifier: m, Type: polochon.VideoTypeShow, ImportMode: polochon.ImportCopy},
+		},
+	}
+	if !reflect.DeepEqual(got.Watcher, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, got.Watcher)
+	}
+
+	for path, expectedDir := range map[string]string{
+		"/downloads/video.mkv":            "/downloads",
+		"/downloads/movies/Movie/vid.mkv": "/downloads/movies",
+		"/downloads/moviesandmore/a.mkv":  "/downloads",
+		"/mnt/shows/show.s01e01.mkv":      "/mnt/shows",
+		"/mnt/other/video.mkv":            "",
+	} {
+		dir := got.Watcher.WatchDir(path)
+		switch {
+		case dir == nil && expectedDir != "":
+			t.Errorf("expected %q to be in %q, got no dir", path, expectedDir)
+		case dir != nil && dir.Dir != expectedDir:
+			t.Errorf("expected %q to be in %q, got %q", path, expectedDir, dir.Dir)
+		}
+	}
+
+	for name, data := range map[string]string{
+		"missing dir":         "  - type: movie\n",
+		"invalid type":        "  - dir: /downloads\n    type: music\n",
+		"invalid import mode": "  - dir: /downloads\n    import_mode: teleport\n",
+	} {
+		buf := bytes.NewBufferString("watcher:\n  fsnotifier: mock\n  dirs:\n" + data + "modules_params:\n  - name: mock\n")
+		if _, err := LoadConfig(buf); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLibraryRoots(t *testing.T) {
+	polochon.ClearRegisteredModules()
+	polochon.RegisterModule(&mock.Mock{})
+
+	buf := bytes.NewBufferString(`
+movie:
+  dir: /tmp
+  dirs:
+    - /
+  placement: in_order
+show:
+  dir: /tmp
+  dirs:
+    - /
+  placement: most_free
+modules_params:
+  - name: mock
+`)
+	got, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("should not get any error but got %q", err)
+	}
+
+	if !reflect.DeepEqual(got.Library.MovieDirs, []string{"/"}) {
+		t.Errorf("invalid movie dirs %+v", got.Library.MovieDirs)
+	}
+
+	if !reflect.DeepEqual(got.Library.ShowDirs, []string{"/"}) {
+		t.Errorf("invalid show dirs %+v", got.Library.ShowDirs)
+	}
+
+	if got.Library.MoviePlacement != PlacementInOrder {
+		t.Errorf("expected movie placement %q, got %q", PlacementInOrder, got.Library.MoviePlacement)
+	}
+
+	if got.Library.ShowPlacement != PlacementMostFree {
+		t.Errorf("expected show placement %q, got %q", PlacementMostFree, got.Library.ShowPlacement)
+	}
+
+	for name, data := range map[string]string{
+		"invalid movie placement": "movie:\n  placement: same_root\n",
+		"invalid show placement":  "show:\n  placement: random\n",
+		"missing dir":             "movie:\n  dirs:\n    - /missing/polochon\n",
+	} {
+		buf := bytes.NewBufferString(data + "modules_params:\n  - name: mock\n")
+		if _, err := LoadConfig(buf); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSubtitleBackfillDefaults(t *testing.T) {
+	polochon.ClearRegisteredModules()
+	polochon.RegisterModule(&mock.Mock{})
+
+	buf := bytes.NewBufferString(`
+video:
+  subtitle_backfill:
+    enabled: true
+modules_params:
+  - name: mock
+`)
+	got, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("should not get any error but got %q", err)
+	}
+
+	expected := SubtitleBackfillConfig{
+		Enabled: true,
+		Timer:   6 * time.Hour,
+		Backoff: 6 * time.Hour,
+	}
+	if got.SubtitleBackfill != expected {
+		t.Errorf("expected %+v, got %+v", expected, got.SubtitleBackfill)
+	}
+
+	for name, data := range map[string]string{
+		"negative timer":   "video:\n  subtitle_backfill:\n    timer: -1h\n",
+		"negative backoff": "video:\n  subtitle_backfill:\n    backoff: -1h\n",
+		"negative max age": "video:\n  subtitle_backfill:\n    max_age: -1h\n",
+	} {
+		buf := bytes.NewBufferString(data + "modules_params:\n  - name: mock\n")
+		if _, err := LoadConfig(buf); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestVerifyDefaults(t *testing.T) {
+	polochon.ClearRegisteredModules()
+	polochon.RegisterModule(&mock.Mock{})
+
+	buf := bytes.NewBufferString(`
+video:
+  verify:
+    enabled: true
+modules_params:
+  - name: mock
+`)
+	got, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("should not get any error but got %q", err)
+	}
+
+	if got.Verify.Timer != 24*time.Hour {
+		t.Errorf("expected the default timer, got %s", got.Verify.Timer)
+	}
+
+	buf = bytes.NewBufferString("video:\n  verify:\n    timer: -1h\nmodules_params:\n  - name: mock\n")
+	if _, err := LoadConfig(buf); err == nil {
+		t.Error("expected an error for a negative timer")
+	}
+}
+
+func TestAiredDateRetryDefaults(t *testing.T) {
+	polochon.ClearRegisteredModules()
+	polochon.RegisterModule(&mock.Mock{})
+
+	buf := bytes.NewBufferString("video:\n  aired_date_retry:\n    interval: 30m\nmodules_params:\n  - name: mock\n")
+	got, err := LoadConfig(buf)
+	if err != nil {
+		t.Fatalf("should not get any error but got %q", err)
+	}
+
+	expected := AiredDateRetryConfig{Interval: 30 * time.Minute, MaxAge: 168 * time.Hour}
+	if got.AiredDateRetry != expected {
+		t.Errorf("expected %+v, got %+v", expected, got.AiredDateRetry)
+	}
+
+	buf = bytes.NewBufferString("video:\n  aired_date_retry:\n    max_age: -1h\nmodules_params:\n  - name: mock\n")
+	if _, err := LoadConfig(buf); err == nil {
+		t.Error("expected an error for a negative max age")
+	}
+}
+
+func TestLoadSceneMappings(t *testing.T) {
+	f, err := ioutil.TempFile("", "polochon-scene-mapping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	content := `
+tt0285403:
+  - scene: S01E02
+    metadata: S01E01
+  - scene: S01E03
+    metadata: S01E02
+`
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	got, err := loadSceneMappings(f.Name())
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := map[string]*polochon.SceneMapping{
+		"tt0285403": {
+			ImdbID: "tt0285403",
+			Episodes: []*polochon.SceneMappingEpisode{
+				{
+					Scene:    polochon.EpisodeNumber{Season: 1, Episode: 2},
+					Metadata: polochon.EpisodeNumber{Season: 1, Episode: 1},
+				},
+				{
+					Scene:    polochon.EpisodeNumber{Season: 1, Episode: 3},
+					Metadata: polochon.EpisodeNumber{Season: 1, Episode: 2},
+				},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
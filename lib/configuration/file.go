@@ -2,11 +2,18 @@ package configuration
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/disk"
+	"github.com/odwrtw/polochon/lib/naming"
 	"github.com/robfig/cron/v3"
 )
 
+// ErrMissingWatchDir is returned if a watched directory has no path
+var ErrMissingWatchDir = errors.New("configuration: missing watcher dir")
+
 type configFile struct {
 	modulesParams *ModulesParams
 
@@ -14,37 +21,57 @@ type configFile struct {
 
 	Watcher struct {
 		ModuleLoader `yaml:",inline"`
-		Dir          string `yaml:"dir"`
+		Dir          string         `yaml:"dir"`
+		Dirs         []watchDirFile `yaml:"dirs"`
 	} `yaml:"watcher"`
 
 	Downloader struct {
 		ModuleLoader    `yaml:",inline"`
-		LaunchAtStartup bool          `yaml:"launch_at_startup"`
-		Enabled         bool          `yaml:"enabled"`
-		Schedule        string        `yaml:"schedule"`
-		Cleaner         CleanerConfig `yaml:"cleaner"`
+		LaunchAtStartup bool                `yaml:"launch_at_startup"`
+		Enabled         bool                `yaml:"enabled"`
+		Schedule        string              `yaml:"schedule"`
+		Cleaner         CleanerConfig       `yaml:"cleaner"`
+		HistoryFile     string              `yaml:"history_file"`
+		EpisodeSearch   EpisodeSearchConfig `yaml:"episode_search"`
+		Workers         int                 `yaml:"workers"`
 	} `yaml:"downloader"`
 
 	HTTPServer HTTPServer `yaml:"http_server"`
 
 	Video struct {
 		ModuleLoader              `yaml:",inline"`
-		ExcludeFileContaining     []string            `yaml:"exclude_file_containing"`
-		VideoExtensions           []string            `yaml:"allowed_file_extensions"`
-		AllowedExtensionsToDelete []string            `yaml:"allowed_file_extensions_to_delete"`
-		SubtitleLanguages         []polochon.Language `yaml:"subtitle_languages"`
+		ExcludeFileContaining     []string               `yaml:"exclude_file_containing"`
+		VideoExtensions           []string               `yaml:"allowed_file_extensions"`
+		AllowedExtensionsToDelete []string               `yaml:"allowed_file_extensions_to_delete"`
+		SubtitleLanguages         []polochon.Language    `yaml:"subtitle_languages"`
+		SubtitleBackfill          SubtitleBackfillConfig `yaml:"subtitle_backfill"`
+		Verify                    VerifyConfig           `yaml:"verify"`
+		Archive                   ArchiveConfig          `yaml:"archive"`
+		DiskGuard                 DiskGuardConfig        `yaml:"disk_guard"`
+		AiredDateRetry            AiredDateRetryConfig   `yaml:"aired_date_retry"`
 	} `yaml:"video"`
 
 	Show struct {
-		ModuleLoader `yaml:",inline"`
-		Dir          string `yaml:"dir"`
+		ModuleLoader     `yaml:",inline"`
+		Dir              string    `yaml:"dir"`
+		Dirs             []string  `yaml:"dirs"`
+		Placement        Placement `yaml:"placement"`
+		DetailersMerge   bool      `yaml:"detailers_merge"`
+		Anime            []string  `yaml:"anime"`
+		Daily            []string  `yaml:"daily"`
+		SceneMappingFile string    `yaml:"scene_mapping_file"`
 	} `yaml:"show"`
 
 	Movie struct {
-		ModuleLoader `yaml:",inline"`
-		Dir          string `yaml:"dir"`
+		ModuleLoader   `yaml:",inline"`
+		Dir            string    `yaml:"dir"`
+		Dirs           []string  `yaml:"dirs"`
+		Placement      Placement `yaml:"placement"`
+		DetailersMerge bool      `yaml:"detailers_merge"`
 	} `yaml:"movie"`
 
+	Naming naming.Templates `yaml:"naming"`
+
 	Wishlist struct {
 		ModuleLoader          `yaml:",inline"`
 		ShowDefaultQualities  []polochon.Quality `yaml:"show_default_qualities"`
@@ -52,6 +79,73 @@ type configFile struct {
 	} `yaml:"wishlist"`
 }
 
+// watchDirFile represents a watched directory in the configuration file
+type watchDirFile struct {
+	Dir        string              `yaml:"dir"`
+	FsNotifier string              `yaml:"fsnotifier"`
+	Type       polochon.VideoType  `yaml:"type"`
+	ImportMode polochon.ImportMode `yaml:"import_mode"`
+	Clean      *bool               `yaml:"clean"`
+}
+
+// watcherConfig returns the watched directories, the directory at the root of
+// the watcher section is watched along with the ones in the list
+func (cf *configFile) watcherConfig() (WatcherConfig, error) {
+	conf := WatcherConfig{}
+	if cf.Watcher.Dir != "" {
+		conf.Dirs = append(conf.Dirs, &WatchDir{
+			Dir:        cf.Watcher.Dir,
+			FsNotifier: cf.Watcher.fsNotifier,
+			Clean:      true,
+		})
+	}
+
+	for _, d := range cf.Watcher.Dirs {
+		if d.Dir == "" {
+			return conf, ErrMissingWatchDir
+		}
+
+		switch d.Type {
+		case "", polochon.VideoTypeMovie, polochon.VideoTypeShow:
+		default:
+			return conf, fmt.Errorf("configuration: invalid watcher type %q", d.Type)
+		}
+
+		if !d.ImportMode.IsValid() {
+			return conf, fmt.Errorf("configuration: invalid import mode %q", d.ImportMode)
+		}
+
+		// Use the fsnotifier of the watcher by default
+		fsNotifier := cf.Watcher.fsNotifier
+		if d.FsNotifier != "" {
+			var err error
+			fsNotifier, err = cf.modulesParams.getFsNotifier(d.FsNotifier)
+			if err != nil {
+				return conf, err
+			}
+		}
+
+		if fsNotifier == nil {
+			return conf, fmt.Errorf("configuration: missing fsnotifier for %s", d.Dir)
+		}
+
+		clean := true
+		if d.Clean != nil {
+			clean = *d.Clean
+		}
+
+		conf.Dirs = append(conf.Dirs, &WatchDir{
+			Dir:        d.Dir,
+			FsNotifier: fsNotifier,
+			Type:       d.Type,
+			ImportMode: d.ImportMode,
+			Clean:      clean,
+		})
+	}
+
+	return conf, nil
+}
+
 func loadConfig(cf *configFile, conf *Config) error {
 	// Load the configs in the module loaders
 	for _, ml := range []*ModuleLoader{
@@ -77,17 +171,22 @@ func loadConfig(cf *configFile, conf *Config) error {
 		}
 	}
 
-	conf.Logger = cf.Logs.logger
-	conf.Watcher = WatcherConfig{
-		Dir:        cf.Watcher.Dir,
-		FsNotifier: cf.Watcher.fsNotifier,
+	watcher, err := cf.watcherConfig()
+	if err != nil {
+		return err
 	}
+
+	conf.Logger = cf.Logs.logger
+	conf.Watcher = watcher
 	conf.Downloader = DownloaderConfig{
 		Enabled:         cf.Downloader.Enabled,
 		LaunchAtStartup: cf.Downloader.LaunchAtStartup,
 		Schedule:        schedule,
 		Client:          cf.Downloader.downloader,
 		Cleaner:         cf.Downloader.Cleaner,
+		HistoryFile:     cf.Downloader.HistoryFile,
+		EpisodeSearch:   cf.Downloader.EpisodeSearch,
+		Workers:         cf.Downloader.Workers,
 	}
 	conf.HTTPServer = cf.HTTPServer
 	conf.Wishlist = polochon.WishlistConfig{
@@ -96,19 +195,23 @@ func loadConfig(cf *configFile, conf *Config) error {
 		MovieDefaultQualities: cf.Wishlist.MovieDefaultQualities,
 	}
 	conf.Movie = polochon.MovieConfig{
-		Detailers:  cf.Movie.detailers,
-		Torrenters: cf.Movie.torrenters,
-		Subtitlers: cf.Movie.subtitlers,
-		Explorers:  cf.Movie.explorers,
-		Searchers:  cf.Movie.searchers,
+		Detailers:      cf.Movie.detailers,
+		Torrenters:     cf.Movie.torrenters,
+		Subtitlers:     cf.Movie.subtitlers,
+		Explorers:      cf.Movie.explorers,
+		Searchers:      cf.Movie.searchers,
+		DetailersMerge: cf.Movie.DetailersMerge,
 	}
 	conf.Show = polochon.ShowConfig{
-		Detailers:  cf.Show.detailers,
-		Torrenters: cf.Show.torrenters,
-		Subtitlers: cf.Show.subtitlers,
-		Explorers:  cf.Show.explorers,
-		Searchers:  cf.Show.searchers,
-		Calendar:   cf.Show.calendar,
+		Detailers:      cf.Show.detailers,
+		Torrenters:     cf.Show.torrenters,
+		Subtitlers:     cf.Show.subtitlers,
+		Explorers:      cf.Show.explorers,
+		Searchers:      cf.Show.searchers,
+		Calendar:       cf.Show.calendar,
+		DetailersMerge: cf.Show.DetailersMerge,
+		AnimeShows:     cf.Show.Anime,
+		DailyShows:     cf.Show.Daily,
 	}
 	conf.File = polochon.FileConfig{
 		ExcludeFileContaining:     cf.Video.ExcludeFileContaining,
@@ -116,9 +219,61 @@ func loadConfig(cf *configFile, conf *Config) error {
 		AllowedExtensionsToDelete: cf.Video.AllowedExtensionsToDelete,
 		Guesser:                   cf.Video.guesser,
 	}
-	conf.Library = LibraryConfig{}
+	namer, err := naming.New(cf.Naming)
+	if err != nil {
+		return err
+	}
+
+	conf.Library = LibraryConfig{
+		MoviePlacement: PlacementMostFree,
+		ShowPlacement:  PlacementSameRoot,
+		Checksum:       cf.Video.Verify.Checksum,
+		Naming:         namer,
+	}
 	conf.Notifiers = cf.Video.notifiers
 	conf.SubtitleLanguages = cf.Video.SubtitleLanguages
+	conf.SubtitleBackfill = cf.Video.SubtitleBackfill
+	if conf.SubtitleBackfill.Timer == 0 {
+		conf.SubtitleBackfill.Timer = 6 * time.Hour
+	}
+	if conf.SubtitleBackfill.Backoff == 0 {
+		conf.SubtitleBackfill.Backoff = 6 * time.Hour
+	}
+	if conf.SubtitleBackfill.Timer < 0 || conf.SubtitleBackfill.Backoff < 0 || conf.SubtitleBackfill.MaxAge < 0 {
+		return fmt.Errorf("configuration: invalid subtitle backfill durations %+v", conf.SubtitleBackfill)
+	}
+	conf.Verify = cf.Video.Verify
+	if conf.Verify.Timer == 0 {
+		conf.Verify.Timer = 24 * time.Hour
+	}
+	if conf.Verify.Timer < 0 {
+		return fmt.Errorf("configuration: invalid verify timer %s", conf.Verify.Timer)
+	}
+	conf.Archive = cf.Video.Archive
+	conf.DiskGuard = cf.Video.DiskGuard
+	if conf.DiskGuard.MinFree == 0 {
+		conf.DiskGuard.MinFree = 5 * disk.GB
+	}
+	if conf.DiskGuard.RetryInterval == 0 {
+		conf.DiskGuard.RetryInterval = 10 * time.Minute
+	}
+	conf.AiredDateRetry = cf.Video.AiredDateRetry
+	if conf.AiredDateRetry.Interval == 0 {
+		conf.AiredDateRetry.Interval = time.Hour
+	}
+	if conf.AiredDateRetry.MaxAge == 0 {
+		conf.AiredDateRetry.MaxAge = 7 * 24 * time.Hour
+	}
+	if conf.AiredDateRetry.Interval < 0 || conf.AiredDateRetry.MaxAge < 0 {
+		return fmt.Errorf("configuration: invalid aired date retry durations %+v", conf.AiredDateRetry)
+	}
+
+	if cf.Show.SceneMappingFile != "" {
+		conf.Show.SceneMappings, err = loadSceneMappings(cf.Show.SceneMappingFile)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Check the default show qualities
 	if err := checkQuality(conf.Wishlist.ShowDefaultQualities); err != nil {
@@ -137,5 +292,31 @@ func loadConfig(cf *configFile, conf *Config) error {
 		return err
 	}
 
+	conf.Library.MovieDirs, err = evalSymlinks(cf.Movie.Dirs)
+	if err != nil {
+		return err
+	}
+
+	conf.Library.ShowDirs, err = evalSymlinks(cf.Show.Dirs)
+	if err != nil {
+		return err
+	}
+
+	switch cf.Movie.Placement {
+	case "":
+	case PlacementMostFree, PlacementInOrder:
+		conf.Library.MoviePlacement = cf.Movie.Placement
+	default:
+		return fmt.Errorf("configuration: invalid movie placement %q", cf.Movie.Placement)
+	}
+
+	switch cf.Show.Placement {
+	case "":
+	case PlacementMostFree, PlacementInOrder, PlacementSameRoot:
+		conf.Library.ShowPlacement = cf.Show.Placement
+	default:
+		return fmt.Errorf("configuration: invalid show placement %q", cf.Show.Placement)
+	}
+
 	return nil
 }
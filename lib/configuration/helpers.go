@@ -2,9 +2,11 @@ package configuration
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 
 	polochon "github.com/odwrtw/polochon/lib"
+	"gopkg.in/yaml.v2"
 )
 
 func evalSymlink(dest *string, path string) error {
@@ -13,6 +15,20 @@ func evalSymlink(dest *string, path string) error {
 	return err
 }
 
+// evalSymlinks evaluates the symlinks of several paths
+func evalSymlinks(paths []string) ([]string, error) {
+	var evaluated []string
+	for _, path := range paths {
+		var dest string
+		if err := evalSymlink(&dest, path); err != nil {
+			return nil, err
+		}
+		evaluated = append(evaluated, dest)
+	}
+
+	return evaluated, nil
+}
+
 func checkQuality(qualities []polochon.Quality) error {
 	for _, quality := range qualities {
 		if !quality.IsAllowed() {
@@ -21,3 +37,25 @@ func checkQuality(qualities []polochon.Quality) error {
 	}
 	return nil
 }
+
+// loadSceneMappings reads the scene mappings of the shows by imdb id from a
+// yaml file
+func loadSceneMappings(path string) (map[string]*polochon.SceneMapping, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	episodes := map[string][]*polochon.SceneMappingEpisode{}
+	if err := yaml.NewDecoder(file).Decode(&episodes); err != nil {
+		return nil, fmt.Errorf("configuration: invalid scene mapping file: %s", err)
+	}
+
+	mappings := make(map[string]*polochon.SceneMapping, len(episodes))
+	for imdbID, e := range episodes {
+		mappings[imdbID] = &polochon.SceneMapping{ImdbID: imdbID, Episodes: e}
+	}
+
+	return mappings, nil
+}
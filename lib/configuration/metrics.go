@@ -0,0 +1,47 @@
+package configuration
+
+import (
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var moduleUpDesc = prometheus.NewDesc(
+	"polochon_module_up",
+	"Status of the modules, 1 if the module is ok and 0 if it fails.",
+	[]string{"section", "type", "module"}, nil,
+)
+
+// StatusCollector exposes the status of the modules as prometheus metrics
+type StatusCollector struct {
+	checker *StatusChecker
+}
+
+// NewStatusCollector returns a new collector for the status of the modules
+func NewStatusCollector(s *StatusChecker) *StatusCollector {
+	return &StatusCollector{checker: s}
+}
+
+// Describe implements the prometheus Collector interface
+func (c *StatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- moduleUpDesc
+}
+
+// Collect implements the prometheus Collector interface
+func (c *StatusCollector) Collect(ch chan<- prometheus.Metric) {
+	for section, types := range c.checker.ModulesStatus() {
+		for moduleType, modules := range types {
+			for _, m := range modules {
+				var up float64
+				switch m.Status {
+				case polochon.StatusOK:
+					up = 1
+				case polochon.StatusNotImplemented:
+					// Nothing to report
+					continue
+				}
+
+				ch <- prometheus.MustNewConstMetric(moduleUpDesc, prometheus.GaugeValue, up, section, moduleType, m.Name)
+			}
+		}
+	}
+}
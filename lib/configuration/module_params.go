@@ -4,8 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"time"
 
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/cache"
+	"github.com/odwrtw/polochon/lib/instrument"
+	"github.com/odwrtw/polochon/lib/ratelimit"
 	"gopkg.in/yaml.v2"
 )
 
@@ -17,7 +21,153 @@ var (
 
 // ModulesParams holds the module params in raw yaml
 type ModulesParams struct {
-	params map[string][]byte
+	params   map[string][]byte
+	limiters map[string]*ratelimit.Limiter
+	cache    *cache.Store
+	cacheTTL map[polochon.ModuleType]time.Duration
+}
+
+// RateLimitConfig represents the rate limit of a module in the configuration
+// file
+type RateLimitConfig struct {
+	// Rate is the number of calls allowed per second
+	Rate float64 `yaml:"rate"`
+	// Burst is the number of calls allowed at once
+	Burst int `yaml:"burst"`
+	// Concurrency is the number of calls allowed at the same time
+	Concurrency int `yaml:"concurrency"`
+}
+
+// setRateLimits creates a limiter for each rate limited module, the limiter is
+// shared by all the users of the module
+func (mp *ModulesParams) setRateLimits(limits map[string]RateLimitConfig) {
+	mp.limiters = map[string]*ratelimit.Limiter{}
+	for name, l := range limits {
+		mp.limiters[name] = ratelimit.New(l.Rate, l.Burst, l.Concurrency)
+	}
+}
+
+// close stops the limiters of the modules
+func (mp *ModulesParams) close() {
+	for _, l := range mp.limiters {
+		l.Close()
+	}
+}
+
+// ModuleCacheConfig represents the configuration of the cache of the module
+// responses in the configuration file
+type ModuleCacheConfig struct {
+	// Dir is the directory where the responses are stored, they are only
+	// kept in memory if empty
+	Dir string `yaml:"dir"`
+	// TTL holds the time during which the responses are cached by module
+	// type, only the detailers, calendars, searchers and explorers can be
+	// cached
+	TTL map[polochon.ModuleType]time.Duration `yaml:"ttl"`
+}
+
+// setCache sets the cache of the module responses
+func (mp *ModulesParams) setCache(conf *ModuleCacheConfig) error {
+	store, err := cache.New(conf.Dir)
+	if err != nil {
+		return err
+	}
+
+	mp.cache = store
+	mp.cacheTTL = conf.TTL
+	return nil
+}
+
+// cached returns the ttl of the responses of a module type, 0 if they are not
+// cached
+func (mp ModulesParams) cached(t polochon.ModuleType) time.Duration {
+	if mp.cache == nil {
+		return 0
+	}
+
+	return mp.cacheTTL[t]
+}
+
+// torrenter decorates a torrenter with its metrics and its rate limit
+func (mp ModulesParams) torrenter(t polochon.Torrenter) polochon.Torrenter {
+	t = instrument.Torrenter(t)
+
+	if l, ok := mp.limiters[t.Name()]; ok {
+		t = ratelimit.Torrenter(t, l)
+	}
+
+	return t
+}
+
+// subtitler decorates a subtitler with its metrics and its rate limit
+func (mp ModulesParams) subtitler(s polochon.Subtitler) polochon.Subtitler {
+	s = instrument.Subtitler(s)
+
+	if l, ok := mp.limiters[s.Name()]; ok {
+		s = ratelimit.Subtitler(s, l)
+	}
+
+	return s
+}
+
+// detailer decorates a detailer with its metrics, its rate limit and its cache
+func (mp ModulesParams) detailer(d polochon.Detailer) polochon.Detailer {
+	d = instrument.Detailer(d)
+
+	if l, ok := mp.limiters[d.Name()]; ok {
+		d = ratelimit.Detailer(d, l)
+	}
+
+	if ttl := mp.cached(polochon.TypeDetailer); ttl > 0 {
+		d = cache.Detailer(d, mp.cache, ttl)
+	}
+
+	return d
+}
+
+// calendar decorates a calendar with its metrics, its rate limit and its cache
+func (mp ModulesParams) calendar(c polochon.Calendar) polochon.Calendar {
+	c = instrument.Calendar(c)
+
+	if l, ok := mp.limiters[c.Name()]; ok {
+		c = ratelimit.Calendar(c, l)
+	}
+
+	if ttl := mp.cached(polochon.TypeCalendar); ttl > 0 {
+		c = cache.Calendar(c, mp.cache, ttl)
+	}
+
+	return c
+}
+
+// searcher decorates a searcher with its metrics, its rate limit and its cache
+func (mp ModulesParams) searcher(s polochon.Searcher) polochon.Searcher {
+	s = instrument.Searcher(s)
+
+	if l, ok := mp.limiters[s.Name()]; ok {
+		s = ratelimit.Searcher(s, l)
+	}
+
+	if ttl := mp.cached(polochon.TypeSearcher); ttl > 0 {
+		s = cache.Searcher(s, mp.cache, ttl)
+	}
+
+	return s
+}
+
+// explorer decorates an explorer with its metrics, its rate limit and its cache
+func (mp ModulesParams) explorer(e polochon.Explorer) polochon.Explorer {
+	e = instrument.Explorer(e)
+
+	if l, ok := mp.limiters[e.Name()]; ok {
+		e = ratelimit.Explorer(e, l)
+	}
+
+	if ttl := mp.cached(polochon.TypeExplorer); ttl > 0 {
+		e = cache.Explorer(e, mp.cache, ttl)
+	}
+
+	return e
 }
 
 // UnmarshalYAML implements the Unmarshaler interface
@@ -95,7 +245,7 @@ func (mp ModulesParams) getDetailers(names []string) ([]polochon.Detailer, error
 
 	res := []polochon.Detailer{}
 	for _, m := range modules {
-		res = append(res, m.(polochon.Detailer))
+		res = append(res, mp.detailer(m.(polochon.Detailer)))
 	}
 	return res, nil
 }
@@ -108,7 +258,7 @@ func (mp ModulesParams) getSubtitlers(names []string) ([]polochon.Subtitler, err
 
 	res := []polochon.Subtitler{}
 	for _, m := range modules {
-		res = append(res, m.(polochon.Subtitler))
+		res = append(res, mp.subtitler(m.(polochon.Subtitler)))
 	}
 	return res, nil
 }
@@ -121,7 +271,7 @@ func (mp ModulesParams) getExplorers(names []string) ([]polochon.Explorer, error
 
 	res := []polochon.Explorer{}
 	for _, m := range modules {
-		res = append(res, m.(polochon.Explorer))
+		res = append(res, mp.explorer(m.(polochon.Explorer)))
 	}
 	return res, nil
 }
@@ -134,7 +284,7 @@ func (mp ModulesParams) getSearchers(names []string) ([]polochon.Searcher, error
 
 	res := []polochon.Searcher{}
 	for _, m := range modules {
-		res = append(res, m.(polochon.Searcher))
+		res = append(res, mp.searcher(m.(polochon.Searcher)))
 	}
 	return res, nil
 }
@@ -177,7 +327,7 @@ func (mp ModulesParams) getTorrenters(names []string) ([]polochon.Torrenter, err
 
 	torrenters := []polochon.Torrenter{}
 	for _, m := range modules {
-		torrenters = append(torrenters, m.(polochon.Torrenter))
+		torrenters = append(torrenters, mp.torrenter(m.(polochon.Torrenter)))
 	}
 	return torrenters, nil
 }
@@ -215,5 +365,5 @@ func (mp ModulesParams) getCalendar(name string) (polochon.Calendar, error) {
 		return nil, err
 	}
 
-	return module.(polochon.Calendar), nil
+	return mp.calendar(module.(polochon.Calendar)), nil
 }
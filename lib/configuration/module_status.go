@@ -1,6 +1,16 @@
 package configuration
 
-import polochon "github.com/odwrtw/polochon/lib"
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+// statusTimeout is the time after which a module not giving its status is
+// considered as failing, it can be replaced in the tests
+var statusTimeout = 10 * time.Second
 
 // ModuleFetcher is an interface which allows to get torrenters and detailers ...
 type ModuleFetcher interface {
@@ -16,75 +26,208 @@ type ModuleStatus struct {
 	Name   string                `json:"name"`
 	Status polochon.ModuleStatus `json:"status"`
 	Error  string                `json:"error"`
+	// LatencyMS is the time taken by the module to give its status in
+	// milliseconds
+	LatencyMS int64 `json:"latency_ms"`
 }
 
-// ModulesStatuses represent the status of all the modules
+// ModulesStatuses represent the status of all the modules by section of the
+// configuration and by module type
 type ModulesStatuses map[string]map[string][]ModuleStatus
 
-// ModulesStatus gives the status of the modules configured
-func (c *Config) ModulesStatus() ModulesStatuses {
-	type module struct {
-		moduleType string
-		config     ModuleFetcher
+// statusProbe represents a module to check
+type statusProbe struct {
+	section    string
+	moduleType polochon.ModuleType
+	module     polochon.Module
+}
+
+// run gets the status of the module, waiting at most for the timeout
+func (p statusProbe) run(timeout time.Duration) ModuleStatus {
+	type result struct {
+		status polochon.ModuleStatus
+		err    error
 	}
 
-	result := ModulesStatuses{}
-	for _, module := range []module{
-		{
-			"movie",
-			&c.Movie,
-		},
-		{
-			"show",
-			&c.Show,
-		},
+	start := time.Now()
+	// The channel is buffered not to block the goroutine if the module
+	// answers after the timeout
+	done := make(chan result, 1)
+	go func() {
+		status, err := p.module.Status()
+		done <- result{status: status, err: err}
+	}()
+
+	ms := ModuleStatus{Name: p.module.Name()}
+	select {
+	case r := <-done:
+		ms.Status = r.status
+		ms.Error = errorMsg(r.err)
+	case <-time.After(timeout):
+		ms.Status = polochon.StatusFail
+		ms.Error = fmt.Sprintf("no status after %s", timeout)
+	}
+	ms.LatencyMS = time.Since(start).Milliseconds()
+
+	return ms
+}
+
+// statusProbes returns the probes of all the configured modules
+func (c *Config) statusProbes() []statusProbe {
+	probes := []statusProbe{}
+	add := func(section string, t polochon.ModuleType, m polochon.Module) {
+		probes = append(probes, statusProbe{section: section, moduleType: t, module: m})
+	}
+
+	for section, config := range map[string]ModuleFetcher{
+		"movie": &c.Movie,
+		"show":  &c.Show,
 	} {
-		result[module.moduleType] = map[string][]ModuleStatus{}
-		for _, detailer := range module.config.GetDetailers() {
-			status, err := detailer.Status()
-			result[module.moduleType]["detailer"] = append(result[module.moduleType]["detailer"], ModuleStatus{
-				Name:   detailer.Name(),
-				Status: status,
-				Error:  errorMsg(err),
-			})
+		for _, m := range config.GetDetailers() {
+			add(section, polochon.TypeDetailer, m)
+		}
+		for _, m := range config.GetSubtitlers() {
+			add(section, polochon.TypeSubtitler, m)
 		}
-		for _, subtitler := range module.config.GetSubtitlers() {
-			status, err := subtitler.Status()
-			result[module.moduleType]["subtitler"] = append(result[module.moduleType]["subtitler"], ModuleStatus{
-				Name:   subtitler.Name(),
-				Status: status,
-				Error:  errorMsg(err),
-			})
+		for _, m := range config.GetTorrenters() {
+			add(section, polochon.TypeTorrenter, m)
 		}
-		for _, torrenter := range module.config.GetTorrenters() {
-			status, err := torrenter.Status()
-			result[module.moduleType]["torrenter"] = append(result[module.moduleType]["torrenter"], ModuleStatus{
-				Name:   torrenter.Name(),
-				Status: status,
-				Error:  errorMsg(err),
-			})
+		for _, m := range config.GetExplorers() {
+			add(section, polochon.TypeExplorer, m)
 		}
-		for _, explorer := range module.config.GetExplorers() {
-			status, err := explorer.Status()
-			result[module.moduleType]["explorer"] = append(result[module.moduleType]["explorer"], ModuleStatus{
-				Name:   explorer.Name(),
-				Status: status,
-				Error:  errorMsg(err),
-			})
+		for _, m := range config.GetSearchers() {
+			add(section, polochon.TypeSearcher, m)
 		}
-		for _, searcher := range module.config.GetSearchers() {
-			status, err := searcher.Status()
-			result[module.moduleType]["searcher"] = append(result[module.moduleType]["searcher"], ModuleStatus{
-				Name:   searcher.Name(),
-				Status: status,
-				Error:  errorMsg(err),
-			})
+	}
+
+	if c.Show.Calendar != nil {
+		add("show", polochon.TypeCalendar, c.Show.Calendar)
+	}
+
+	if c.File.Guesser != nil {
+		add("video", polochon.TypeGuesser, c.File.Guesser)
+	}
+
+	for _, m := range c.Notifiers {
+		add("video", polochon.TypeNotifier, m)
+	}
+
+	// The same fsnotifier can watch several directories
+	fsNotifiers := map[polochon.FsNotifier]bool{}
+	for _, d := range c.Watcher.Dirs {
+		if d.FsNotifier == nil || fsNotifiers[d.FsNotifier] {
+			continue
+		}
+
+		fsNotifiers[d.FsNotifier] = true
+		add("watcher", polochon.TypeFsNotifier, d.FsNotifier)
+	}
+
+	if c.Downloader.Client != nil {
+		add("downloader", polochon.TypeDownloader, c.Downloader.Client)
+	}
+
+	for _, m := range c.Wishlist.Wishlisters {
+		add("wishlist", polochon.TypeWishlister, m)
+	}
+
+	return probes
+}
+
+// ModulesStatus gives the status of the modules configured, the modules are
+// checked concurrently
+func (c *Config) ModulesStatus() ModulesStatuses {
+	probes := c.statusProbes()
+	statuses := make([]ModuleStatus, len(probes))
+
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p statusProbe) {
+			defer wg.Done()
+			statuses[i] = p.run(statusTimeout)
+		}(i, p)
+	}
+	wg.Wait()
+
+	result := ModulesStatuses{}
+	for i, p := range probes {
+		if result[p.section] == nil {
+			result[p.section] = map[string][]ModuleStatus{}
 		}
+
+		t := string(p.moduleType)
+		result[p.section][t] = append(result[p.section][t], statuses[i])
 	}
 
 	return result
 }
 
+// statusTTL is the time during which the status of the modules is kept, the
+// status might require a request to the upstream service of each module
+const statusTTL = 5 * time.Minute
+
+// StatusChecker keeps the status of the modules to avoid checking them on
+// each request
+type StatusChecker struct {
+	sync.Mutex
+	config     *Config
+	statuses   ModulesStatuses
+	checkedAt  time.Time
+	refreshing bool
+
+	// first serializes the first check, when no status is known yet
+	first sync.Mutex
+}
+
+// NewStatusChecker returns a new status checker for the configured modules
+func NewStatusChecker(c *Config) *StatusChecker {
+	return &StatusChecker{config: c}
+}
+
+// ModulesStatus returns the status of the modules, the modules are checked
+// without holding the lock: once the previous status has expired it is still
+// returned while the modules are checked again in the background
+func (s *StatusChecker) ModulesStatus() ModulesStatuses {
+	s.Lock()
+	statuses := s.statuses
+	if statuses != nil {
+		if time.Since(s.checkedAt) > statusTTL && !s.refreshing {
+			s.refreshing = true
+			go s.refresh()
+		}
+		s.Unlock()
+		return statuses
+	}
+	s.Unlock()
+
+	// Nothing to return yet, wait for the first check
+	s.first.Lock()
+	defer s.first.Unlock()
+
+	s.Lock()
+	statuses = s.statuses
+	s.Unlock()
+	if statuses != nil {
+		return statuses
+	}
+
+	return s.refresh()
+}
+
+// refresh checks the modules and stores their status
+func (s *StatusChecker) refresh() ModulesStatuses {
+	statuses := s.config.ModulesStatus()
+
+	s.Lock()
+	defer s.Unlock()
+	s.statuses = statuses
+	s.checkedAt = time.Now()
+	s.refreshing = false
+
+	return statuses
+}
+
 func errorMsg(err error) string {
 	if err == nil {
 		return ""
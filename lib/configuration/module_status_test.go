@@ -3,11 +3,39 @@ package configuration
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	polochon "github.com/odwrtw/polochon/lib"
 	"github.com/odwrtw/polochon/modules/mock"
 )
 
+// slowDetailer is a detailer taking too long to give its status
+type slowDetailer struct {
+	*mock.Mock
+}
+
+func (s *slowDetailer) Name() string {
+	return "slow"
+}
+
+func (s *slowDetailer) Status() (polochon.ModuleStatus, error) {
+	time.Sleep(time.Second)
+	return polochon.StatusOK, nil
+}
+
+// withoutLatency removes the latencies of the statuses to compare them
+func withoutLatency(statuses ModulesStatuses) ModulesStatuses {
+	for _, types := range statuses {
+		for _, modules := range types {
+			for i := range modules {
+				modules[i].LatencyMS = 0
+			}
+		}
+	}
+
+	return statuses
+}
+
 func TestModulesStatus(t *testing.T) {
 	polochon.ClearRegisteredModules()
 	mock := &mock.Mock{}
@@ -23,53 +51,93 @@ func TestModulesStatus(t *testing.T) {
 		},
 		Show: polochon.ShowConfig{
 			Torrenters: []polochon.Torrenter{mock},
+			Calendar:   mock,
+		},
+		File: polochon.FileConfig{
+			Guesser: mock,
+		},
+		Notifiers: []polochon.Notifier{mock},
+		Watcher: WatcherConfig{
+			Dirs: []*WatchDir{
+				{Dir: "/downloads/movies", FsNotifier: mock},
+				{Dir: "/downloads/shows", FsNotifier: mock},
+			},
+		},
+		Downloader: DownloaderConfig{
+			Client: mock,
+		},
+		Wishlist: polochon.WishlistConfig{
+			Wishlisters: []polochon.Wishlister{mock},
+		},
+	}
+	modulesStatus := withoutLatency(c.ModulesStatus())
+
+	ok := []ModuleStatus{
+		{
+			Name:   "mock",
+			Status: polochon.StatusOK,
+			Error:  "",
 		},
 	}
-	modulesStatus := c.ModulesStatus()
 	expectedModulesStatus := ModulesStatuses{
 		"movie": {
-			"searcher": []ModuleStatus{
-				{
-					Name:   "mock",
-					Status: polochon.StatusOK,
-					Error:  "",
-				},
-			},
+			"searcher":  ok,
+			"detailer":  ok,
+			"explorer":  ok,
+			"torrenter": ok,
+			"subtitler": ok,
+		},
+		"show": {
+			"torrenter": ok,
+			"calendar":  ok,
+		},
+		"video": {
+			"guesser":  ok,
+			"notifier": ok,
+		},
+		"watcher": {
+			"fsnotifier": ok,
+		},
+		"downloader": {
+			"downloader": ok,
+		},
+		"wishlist": {
+			"wishlister": ok,
+		},
+	}
+	if !reflect.DeepEqual(modulesStatus, expectedModulesStatus) {
+		t.Errorf("Didn't get expected module status \n %+v \n %+v", modulesStatus, expectedModulesStatus)
+	}
+}
+
+func TestModulesStatusTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { statusTimeout = timeout }(statusTimeout)
+	statusTimeout = 50 * time.Millisecond
+
+	mock := &mock.Mock{}
+	c := Config{
+		Movie: polochon.MovieConfig{
+			Detailers: []polochon.Detailer{&slowDetailer{Mock: mock}, mock},
+		},
+	}
+
+	start := time.Now()
+	modulesStatus := withoutLatency(c.ModulesStatus())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the status not to wait for the slow module, took %s", elapsed)
+	}
+
+	expectedModulesStatus := ModulesStatuses{
+		"movie": {
 			"detailer": []ModuleStatus{
 				{
-					Name:   "mock",
-					Status: polochon.StatusOK,
-					Error:  "",
-				},
-			},
-			"explorer": []ModuleStatus{
-				{
-					Name:   "mock",
-					Status: polochon.StatusOK,
-					Error:  "",
-				},
-			},
-			"torrenter": []ModuleStatus{
-				{
-					Name:   "mock",
-					Status: polochon.StatusOK,
-					Error:  "",
-				},
-			},
-			"subtitler": []ModuleStatus{
-				{
-					Name:   "mock",
-					Status: polochon.StatusOK,
-					Error:  "",
+					Name:   "slow",
+					Status: polochon.StatusFail,
+					Error:  "no status after 50ms",
 				},
-			},
-		},
-		"show": {
-			"torrenter": []ModuleStatus{
 				{
 					Name:   "mock",
 					Status: polochon.StatusOK,
-					Error:  "",
 				},
 			},
 		},
@@ -78,3 +146,34 @@ func TestModulesStatus(t *testing.T) {
 		t.Errorf("Didn't get expected module status \n %+v \n %+v", modulesStatus, expectedModulesStatus)
 	}
 }
+
+func TestStatusCheckerExpired(t *testing.T) {
+	mock := &mock.Mock{}
+	c := &Config{
+		Movie: polochon.MovieConfig{
+			Detailers: []polochon.Detailer{&slowDetailer{Mock: mock}},
+		},
+	}
+
+	stale := ModulesStatuses{"movie": {}}
+	s := NewStatusChecker(c)
+	s.statuses = stale
+	s.checkedAt = time.Now().Add(-2 * statusTTL)
+
+	start := time.Now()
+	got := s.ModulesStatus()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the expired status not to wait for the modules, took %s", elapsed)
+	}
+
+	if !reflect.DeepEqual(got, stale) {
+		t.Errorf("expected the expired status %+v, got %+v", stale, got)
+	}
+
+	s.Lock()
+	refreshing := s.refreshing
+	s.Unlock()
+	if !refreshing {
+		t.Error("expected the modules to be checked again in the background")
+	}
+}
@@ -27,6 +27,10 @@ func GetDetails(v Detailable, log *logrus.Entry) error {
 		return c
 	}
 
+	if fields, sources, ok := detailsMerge(v); ok {
+		return mergeDetails(v, fields, sources, log)
+	}
+
 	var done bool
 	for _, d := range detailers {
 		detailerLog := log.WithField("detailer", d.Name())
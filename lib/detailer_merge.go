@@ -0,0 +1,162 @@
+package polochon
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/odwrtw/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// mergeFields holds the fields of a detailable type filled by the detailers,
+// the keys are the fields identifying the video given to the detailers
+type mergeFields struct {
+	fields   []string
+	keys     []string
+	required []string
+}
+
+var (
+	movieMergeFields = mergeFields{
+		fields: []string{
+			"ImdbID", "OriginalTitle", "Plot", "Rating", "Runtime",
+			"SortTitle", "Tagline", "Thumb", "Fanart", "Title", "TmdbID",
+			"Votes", "Year", "Genres",
+		},
+		keys:     []string{"ImdbID", "TmdbID", "Title", "Year"},
+		required: []string{"Title", "Year", "Thumb", "Fanart"},
+	}
+
+	showMergeFields = mergeFields{
+		fields: []string{
+			"Title", "Rating", "Plot", "URL", "TvdbID", "ImdbID", "Year",
+			"FirstAired", "Banner", "Fanart", "Poster", "Episodes",
+		},
+		keys:     []string{"ImdbID", "TvdbID", "Title", "Year"},
+		required: []string{"Title", "Year", "Banner", "Fanart", "Poster"},
+	}
+
+	episodeMergeFields = mergeFields{
+		fields: []string{
+			"Title", "ShowTitle", "Season", "Episode", "TvdbID", "Aired",
+			"Plot", "Runtime", "Thumb", "Rating", "ShowImdbID",
+			"ShowTvdbID", "EpisodeImdbID", "AbsoluteNumber",
+		},
+		keys: []string{
+			"ShowTitle", "Season", "Episode", "Aired", "ShowImdbID",
+			"ShowTvdbID", "AbsoluteNumber",
+		},
+		required: []string{"Title"},
+	}
+)
+
+// detailsMerge returns the merge fields and the detail sources of a
+// detailable if its details should be merged
+func detailsMerge(v Detailable) (*mergeFields, *map[string]string, bool) {
+	switch t := v.(type) {
+	case *Movie:
+		return &movieMergeFields, &t.DetailSources, t.DetailersMerge
+	case *Show:
+		return &showMergeFields, &t.DetailSources, t.DetailersMerge
+	case *ShowEpisode:
+		return &episodeMergeFields, &t.DetailSources, t.DetailersMerge
+	default:
+		return nil, nil, false
+	}
+}
+
+// mergeDetails runs all the detailers in order, each detailer only fills
+// the fields not supplied by the previous ones. The values known before the
+// detailers ran, such as the guessed title, are replaced by the first
+// detailer supplying the field. The required fields are checked once all the
+// detailers ran
+func mergeDetails(v Detailable, fields *mergeFields, sources *map[string]string, log *logrus.Entry) error {
+	c := errors.NewCollector()
+	supplied := map[string]string{}
+
+	keys := map[string]struct{}{}
+	for _, name := range fields.keys {
+		keys[name] = struct{}{}
+	}
+
+	dst := reflect.ValueOf(v).Elem()
+	var done bool
+	for _, d := range v.GetDetailers() {
+		detailerLog := log.WithField("detailer", d.Name())
+
+		// Each detailer works on a copy of the video so that it does not
+		// overwrite the fields already supplied. Only the keys are given to
+		// the detailer, the other fields it returns are the ones it supplied
+		cp := reflect.New(dst.Type())
+		cp.Elem().Set(dst)
+		for _, name := range fields.fields {
+			if _, ok := keys[name]; !ok {
+				f := cp.Elem().FieldByName(name)
+				f.Set(reflect.Zero(f.Type()))
+			}
+		}
+
+		if err := d.GetDetails(cp.Interface(), detailerLog); err != nil {
+			c.Push(errors.Wrap(err).Ctx("Detailer", d.Name()))
+			continue
+		}
+		done = true
+
+		// A known key left as is by the detailer is not supplied by it, the
+		// next detailers can still replace it
+		src := cp.Elem()
+		for _, name := range fields.fields {
+			key := strings.ToLower(name)
+			if _, ok := supplied[key]; ok {
+				continue
+			}
+
+			sf := src.FieldByName(name)
+			if isEmpty(sf) {
+				continue
+			}
+
+			df := dst.FieldByName(name)
+			if _, ok := keys[name]; ok && !isEmpty(df) && reflect.DeepEqual(sf.Interface(), df.Interface()) {
+				continue
+			}
+
+			df.Set(sf)
+			supplied[key] = d.Name()
+		}
+	}
+
+	if !done {
+		c.Push(errors.Wrap("All detailers failed").Fatal())
+		return c
+	}
+
+	*sources = supplied
+
+	missing := []string{}
+	for _, name := range fields.required {
+		if isEmpty(dst.FieldByName(name)) {
+			missing = append(missing, strings.ToLower(name))
+		}
+	}
+
+	if len(missing) > 0 {
+		c.Push(errors.Wrap(fmt.Sprintf("missing required details: %s", strings.Join(missing, ", "))))
+	}
+
+	if c.HasErrors() {
+		return c
+	}
+
+	return nil
+}
+
+// isEmpty returns true if the value is the zero value or an empty slice
+func isEmpty(v reflect.Value) bool {
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Map {
+		return v.Len() == 0
+	}
+
+	return v.IsZero()
+}
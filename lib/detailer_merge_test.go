@@ -0,0 +1,143 @@
+package polochon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// funcDetailer is a detailer running a function on the movies
+type funcDetailer struct {
+	testModule
+	details func(m *Movie) error
+}
+
+func (d *funcDetailer) GetDetails(i interface{}, log *logrus.Entry) error {
+	return d.details(i.(*Movie))
+}
+
+func TestMergeDetails(t *testing.T) {
+	tmdb := &funcDetailer{
+		testModule: testModule{name: "tmdb"},
+		details: func(m *Movie) error {
+			m.Title = "Ghost Dog: The Way of the Samurai"
+			m.Year = 1999
+			m.Thumb = "thumb.jpg"
+			m.Plot = ""
+			return nil
+		},
+	}
+	trakttv := &funcDetailer{
+		testModule: testModule{name: "trakttv"},
+		details: func(m *Movie) error {
+			m.Title = "Ghost Dog"
+			m.Plot = "A hitman follows the code of the samurai"
+			m.Fanart = "fanart.jpg"
+			m.Genres = []string{"crime"}
+			return nil
+		},
+	}
+
+	m := NewMovie(MovieConfig{
+		Detailers:      []Detailer{tmdb, trakttv},
+		DetailersMerge: true,
+	})
+	m.ImdbID = "tt0165798"
+	m.Title = "ghost dog"
+
+	if err := GetDetails(m, logrus.NewEntry(logrus.New())); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if m.ImdbID != "tt0165798" || m.Title != "Ghost Dog: The Way of the Samurai" || m.Plot != "A hitman follows the code of the samurai" || m.Fanart != "fanart.jpg" {
+		t.Errorf("invalid merged movie %+v", m)
+	}
+
+	// The imdb id left as is by the detailers is not recorded
+	expected := map[string]string{
+		"title":  "tmdb",
+		"year":   "tmdb",
+		"thumb":  "tmdb",
+		"plot":   "trakttv",
+		"fanart": "trakttv",
+		"genres": "trakttv",
+	}
+	if !reflect.DeepEqual(m.DetailSources, expected) {
+		t.Errorf("expected detail sources %v, got %v", expected, m.DetailSources)
+	}
+}
+
+func TestMergeDetailsMissingFields(t *testing.T) {
+	tmdb := &funcDetailer{
+		testModule: testModule{name: "tmdb"},
+		details: func(m *Movie) error {
+			m.Title = "Ghost Dog"
+			return nil
+		},
+	}
+
+	m := NewMovie(MovieConfig{
+		Detailers:      []Detailer{tmdb},
+		DetailersMerge: true,
+	})
+	m.ImdbID = "tt0165798"
+
+	err := GetDetails(m, logrus.NewEntry(logrus.New()))
+	if err == nil {
+		t.Fatal("expected an error for the missing fields")
+	}
+
+	if m.Title != "Ghost Dog" {
+		t.Errorf("expected the details to be set, got %+v", m)
+	}
+}
+
+func TestMergeDetailsKnownFields(t *testing.T) {
+	// The first detailer leaves the known title as is and replaces the known
+	// plot, the second one replaces the title but not the plot
+	tmdb := &funcDetailer{
+		testModule: testModule{name: "tmdb"},
+		details: func(m *Movie) error {
+			m.Year = 1999
+			m.Plot = "A hitman follows the code of the samurai"
+			return nil
+		},
+	}
+	trakttv := &funcDetailer{
+		testModule: testModule{name: "trakttv"},
+		details: func(m *Movie) error {
+			m.Title = "Ghost Dog: The Way of the Samurai"
+			m.Year = 2000
+			m.Plot = "Another plot"
+			m.Thumb = "thumb.jpg"
+			return nil
+		},
+	}
+
+	m := NewMovie(MovieConfig{
+		Detailers:      []Detailer{tmdb, trakttv},
+		DetailersMerge: true,
+	})
+	m.Title = "Ghost Dog"
+	m.Plot = "An old plot"
+
+	// The fanart is missing
+	if err := GetDetails(m, logrus.NewEntry(logrus.New())); err == nil {
+		t.Fatal("expected an error for the missing fields")
+	}
+
+	if m.Title != "Ghost Dog: The Way of the Samurai" || m.Year != 1999 || m.Plot != "A hitman follows the code of the samurai" || m.Thumb != "thumb.jpg" {
+		t.Errorf("invalid merged movie %+v", m)
+	}
+
+	expected := map[string]string{
+		"title": "trakttv",
+		"year":  "tmdb",
+		"plot":  "tmdb",
+		"thumb": "trakttv",
+	}
+	if !reflect.DeepEqual(m.DetailSources, expected) {
+		t.Errorf("expected detail sources %v, got %v", expected, m.DetailSources)
+	}
+}
@@ -0,0 +1,35 @@
+package disk
+
+import "syscall"
+
+// Usage represents the usage of the filesystem holding a directory, the sizes
+// are in bytes
+type Usage struct {
+	Path  string `json:"path"`
+	Total uint64 `json:"total"`
+	Free  uint64 `json:"free"`
+	Used  uint64 `json:"used"`
+}
+
+// GetUsage returns the usage of the filesystem holding a path, the free space
+// is the space available to unprivileged users
+func GetUsage(path string) (*Usage, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return nil, err
+	}
+
+	bsize := uint64(st.Bsize)
+	total := st.Blocks * bsize
+	return &Usage{
+		Path:  path,
+		Total: total,
+		Free:  st.Bavail * bsize,
+		Used:  total - st.Bfree*bsize,
+	}, nil
+}
+
+// Fits returns true if a file of the given size fits in the free space
+func (u *Usage) Fits(size int64) bool {
+	return size < 0 || uint64(size) <= u.Free
+}
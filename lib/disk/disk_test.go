@@ -0,0 +1,94 @@
+package disk
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestGetUsage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-disk")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	defer os.RemoveAll(dir)
+
+	u, err := GetUsage(dir)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if u.Path != dir {
+		t.Errorf("expected path %q, got %q", dir, u.Path)
+	}
+
+	if u.Total == 0 || u.Free > u.Total || u.Used > u.Total {
+		t.Errorf("invalid usage %+v", u)
+	}
+
+	if _, err := GetUsage(dir + "/missing"); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestUsageFits(t *testing.T) {
+	u := &Usage{Total: 100, Free: 10, Used: 90}
+
+	for size, expected := range map[int64]bool{
+		0:  true,
+		10: true,
+		11: false,
+	} {
+		if got := u.Fits(size); got != expected {
+			t.Errorf("size %d: expected %t, got %t", size, expected, got)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, expected := range map[string]Size{
+		"1024":   1024,
+		"512B":   512,
+		"1KB":    KB,
+		"500MB":  500 * MB,
+		"10GB":   10 * GB,
+		"10 gb":  10 * GB,
+		"1.5G":   GB + 512*MB,
+		"2T":     2 * TB,
+		" 0 ":    0,
+		"100 MB": 100 * MB,
+	} {
+		got, err := ParseSize(s)
+		if err != nil {
+			t.Errorf("%q: expected no error, got %q", s, err)
+			continue
+		}
+
+		if got != expected {
+			t.Errorf("%q: expected %d, got %d", s, expected, got)
+		}
+	}
+
+	for _, s := range []string{"", "GB", "ten GB", "-1GB", "10PB"} {
+		if _, err := ParseSize(s); err == nil {
+			t.Errorf("%q: expected an error", s)
+		}
+	}
+}
+
+func TestSizeString(t *testing.T) {
+	for size, expected := range map[Size]string{
+		0:           "0B",
+		512:         "512B",
+		KB:          "1KB",
+		500 * MB:    "500MB",
+		GB + 512*MB: "1.5GB",
+		10 * GB:     "10GB",
+		3 * TB:      "3TB",
+		GB + 100*KB: "1GB",
+	} {
+		if got := size.String(); got != expected {
+			t.Errorf("%d: expected %q, got %q", size, expected, got)
+		}
+	}
+}
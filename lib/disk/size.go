@@ -0,0 +1,76 @@
+package disk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Size represents a size in bytes, it's read from the configuration as a
+// number of bytes or with a unit: 500MB, 10GB, 1TB
+type Size uint64
+
+// Size units, the sizes are counted in powers of 1024
+const (
+	KB Size = 1 << (10 * (iota + 1))
+	MB
+	GB
+	TB
+)
+
+var units = []struct {
+	suffix string
+	size   Size
+}{
+	{"TB", TB}, {"GB", GB}, {"MB", MB}, {"KB", KB},
+	{"T", TB}, {"G", GB}, {"M", MB}, {"K", KB},
+	{"B", 1},
+}
+
+// ParseSize returns the size described by a string
+func ParseSize(s string) (Size, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	unit := Size(1)
+	for _, u := range units {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("disk: invalid size %q", s)
+	}
+
+	return Size(n * float64(unit)), nil
+}
+
+// UnmarshalYAML implements the yaml Unmarshaler interface
+func (s *Size) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+
+	size, err := ParseSize(str)
+	if err != nil {
+		return err
+	}
+
+	*s = size
+	return nil
+}
+
+// String returns the size with the largest unit holding it
+func (s Size) String() string {
+	for _, u := range units[:4] {
+		if s >= u.size {
+			value := strconv.FormatFloat(float64(s)/float64(u.size), 'f', 1, 64)
+			return strings.TrimSuffix(value, ".0") + u.suffix
+		}
+	}
+
+	return strconv.FormatUint(uint64(s), 10) + "B"
+}
@@ -48,3 +48,19 @@ type DownloadableInfos struct {
 	PercentDone    float32               `json:"percent_done"`
 	Metadata       *DownloadableMetadata `json:"metadata"`
 }
+
+// Download events sent to the notifiers
+const (
+	DownloadStalled  = "stalled"
+	DownloadRemoved  = "removed"
+	DownloadReplaced = "replaced"
+)
+
+// DownloadEvent represents an event happening to a torrent in the downloader
+// client
+type DownloadEvent struct {
+	Event    string                `json:"event"`
+	Torrent  string                `json:"torrent"`
+	Reason   string                `json:"reason,omitempty"`
+	Metadata *DownloadableMetadata `json:"metadata"`
+}
@@ -18,10 +18,51 @@ type FileConfig struct {
 	Guesser                   Guesser
 }
 
+// VideoType represents the type of the videos
+type VideoType string
+
+// Available video types
+const (
+	VideoTypeMovie VideoType = "movie"
+	VideoTypeShow  VideoType = "show"
+)
+
+// ImportMode tells how a file is imported in the library
+type ImportMode string
+
+// Available import modes
+const (
+	// ImportMove moves the file in the library
+	ImportMove ImportMode = "move"
+	// ImportSymlink moves the file in the library and leaves a symlink to it
+	// in its place
+	ImportSymlink ImportMode = "symlink"
+	// ImportHardlink creates a hard link to the file in the library
+	ImportHardlink ImportMode = "hardlink"
+	// ImportCopy copies the file in the library
+	ImportCopy ImportMode = "copy"
+)
+
+// IsValid returns true if the import mode is known, an empty mode is valid
+// and lets the library choose
+func (m ImportMode) IsValid() bool {
+	switch m {
+	case "", ImportMove, ImportSymlink, ImportHardlink, ImportCopy:
+		return true
+	default:
+		return false
+	}
+}
+
 // File handles polochon files
 type File struct {
 	FileConfig `xml:"-" json:"-"`
 	Path       string `xml:"-" json:"-"`
+	// TypeHint forces the type of the video guessed from the file, it's
+	// empty if the type is unknown
+	TypeHint VideoType `xml:"-" json:"-"`
+	// ImportMode tells how the file is imported in the library
+	ImportMode ImportMode `xml:"-" json:"-"`
 }
 
 // NewFile returns a new file from a path
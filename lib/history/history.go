@@ -0,0 +1,340 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+// Custom errors
+var (
+	ErrNotFound = errors.New("history: not found")
+)
+
+// State represents the state of a grab
+type State string
+
+// Grab states
+const (
+	StateGrabbed   State = "grabbed"
+	StateCompleted State = "completed"
+	StateImported  State = "imported"
+	StateFailed    State = "failed"
+)
+
+// Entry represents the last grab of a video
+type Entry struct {
+	ID          string           `json:"id"`
+	Type        string           `json:"type"`
+	ImdbID      string           `json:"imdb_id"`
+	Season      int              `json:"season,omitempty"`
+	Episode     int              `json:"episode,omitempty"`
+	Quality     polochon.Quality `json:"quality"`
+	Torrent     string           `json:"torrent"`
+	URL         string           `json:"url"`
+	Torrenter   string           `json:"torrenter"`
+	State       State            `json:"state"`
+	Error       string           `json:"error,omitempty"`
+	Blacklist   []string         `json:"blacklist,omitempty"`
+	DateGrabbed time.Time        `json:"date_grabbed"`
+	DateUpdated time.Time        `json:"date_updated"`
+}
+
+// InFlight returns true if the torrent of the entry is still in the
+// downloader client
+func (e *Entry) InFlight() bool {
+	return e.State == StateGrabbed || e.State == StateCompleted
+}
+
+// copy returns a copy of the entry which can be read while the history is
+// updated
+func (e *Entry) copy() *Entry {
+	c := *e
+	if e.Blacklist != nil {
+		c.Blacklist = append([]string{}, e.Blacklist...)
+	}
+
+	return &c
+}
+
+// History keeps track of the torrents sent to the downloader client
+type History struct {
+	// Mutex to protect reads / writes made concurrently by the http server
+	sync.RWMutex
+	// path of the file where the history is stored, the history is only
+	// kept in memory if empty
+	path    string
+	entries map[string]*Entry
+}
+
+// New returns a new history stored in the given file, the file is read if it
+// exists
+func New(path string) (*History, error) {
+	h := &History{
+		path:    path,
+		entries: map[string]*Entry{},
+	}
+
+	if path == "" {
+		return h, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return h, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// ID returns the ID of the entry of a video
+func ID(m *polochon.DownloadableMetadata) string {
+	if m.Type == "episode" {
+		return fmt.Sprintf("%s-s%02de%02d", m.ImdbID, m.Season, m.Episode)
+	}
+
+	return m.ImdbID
+}
+
+// Metadata returns the metadata identifying a video in the history
+func Metadata(v polochon.Video) *polochon.DownloadableMetadata {
+	switch v := v.(type) {
+	case *polochon.Movie:
+		return &polochon.DownloadableMetadata{
+			Type:   "movie",
+			ImdbID: v.ImdbID,
+		}
+	case *polochon.ShowEpisode:
+		return &polochon.DownloadableMetadata{
+			Type:    "episode",
+			ImdbID:  v.ShowImdbID,
+			Season:  v.Season,
+			Episode: v.Episode,
+		}
+	default:
+		return nil
+	}
+}
+
+// Get returns a copy of the entry of a video
+func (h *History) Get(m *polochon.DownloadableMetadata) (*Entry, error) {
+	h.RLock()
+	defer h.RUnlock()
+
+	e, ok := h.entries[ID(m)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return e.copy(), nil
+}
+
+// List returns copies of the entries, the most recently updated first
+func (h *History) List() []*Entry {
+	h.RLock()
+	defer h.RUnlock()
+
+	entries := make([]*Entry, 0, len(h.entries))
+	for _, e := range h.entries {
+		entries = append(entries, e.copy())
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].DateUpdated.After(entries[j].DateUpdated)
+	})
+
+	return entries
+}
+
+// IsBlacklisted returns true if the torrent failed for this video
+func (h *History) IsBlacklisted(m *polochon.DownloadableMetadata, url string) bool {
+	e, err := h.Get(m)
+	if err != nil {
+		return false
+	}
+
+	for _, u := range e.Blacklist {
+		if u == url {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Grabbed records a torrent sent to the downloader client
+func (h *History) Grabbed(m *polochon.DownloadableMetadata, t *polochon.Torrent) error {
+	h.Lock()
+	defer h.Unlock()
+
+	now := time.Now()
+	id := ID(m)
+
+	// Keep the blacklist of the previous grabs
+	var blacklist []string
+	if old, ok := h.entries[id]; ok {
+		blacklist = old.Blacklist
+	}
+
+	h.entries[id] = &Entry{
+		ID:          id,
+		Type:        m.Type,
+		ImdbID:      m.ImdbID,
+		Season:      m.Season,
+		Episode:     m.Episode,
+		Quality:     t.Quality,
+		Torrent:     t.Name,
+		URL:         t.URL,
+		Torrenter:   t.Source,
+		State:       StateGrabbed,
+		Blacklist:   blacklist,
+		DateGrabbed: now,
+		DateUpdated: now,
+	}
+
+	return h.save()
+}
+
+// SetState updates the state of the entry of a video
+func (h *History) SetState(m *polochon.DownloadableMetadata, state State) error {
+	h.Lock()
+	defer h.Unlock()
+
+	e, ok := h.entries[ID(m)]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if e.State == state {
+		return nil
+	}
+
+	e.State = state
+	e.Error = ""
+	e.DateUpdated = time.Now()
+
+	return h.save()
+}
+
+// Failed marks the grab of a video as failed and blacklists its torrent
+func (h *History) Failed(m *polochon.DownloadableMetadata, reason string) error {
+	h.Lock()
+	defer h.Unlock()
+
+	e, ok := h.entries[ID(m)]
+	if !ok {
+		return ErrNotFound
+	}
+
+	e.fail(reason)
+	return h.save()
+}
+
+// FailedTorrent marks the in flight grabs of a torrent as failed and
+// blacklists the torrent, it's used when the videos of the torrent are not
+// known such as for the archives which can't be extracted
+func (h *History) FailedTorrent(name, reason string) error {
+	h.Lock()
+	defer h.Unlock()
+
+	found := false
+	for _, e := range h.entries {
+		if e.Torrent != name || !e.InFlight() {
+			continue
+		}
+
+		e.fail(reason)
+		found = true
+	}
+
+	if !found {
+		return ErrNotFound
+	}
+
+	return h.save()
+}
+
+// fail marks the entry as failed and blacklists its torrent, the lock must be
+// held by the caller
+func (e *Entry) fail(reason string) {
+	e.State = StateFailed
+	e.Error = reason
+	e.DateUpdated = time.Now()
+
+	for _, u := range e.Blacklist {
+		if u == e.URL {
+			return
+		}
+	}
+
+	e.Blacklist = append(e.Blacklist, e.URL)
+}
+
+// Remove removes an entry from the history
+func (h *History) Remove(id string) error {
+	h.Lock()
+	defer h.Unlock()
+
+	if _, ok := h.entries[id]; !ok {
+		return ErrNotFound
+	}
+
+	delete(h.entries, id)
+	return h.save()
+}
+
+// Clear removes all the entries from the history
+func (h *History) Clear() error {
+	h.Lock()
+	defer h.Unlock()
+
+	h.entries = map[string]*Entry{}
+	return h.save()
+}
+
+// save writes the history in its file, the lock must be held by the caller
+func (h *History) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(h.entries)
+	if err != nil {
+		return err
+	}
+
+	// Write in a temporary file first not to corrupt the history if
+	// something goes wrong
+	tmp, err := ioutil.TempFile(filepath.Dir(h.path), ".history")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), h.path)
+}
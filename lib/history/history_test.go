@@ -0,0 +1,230 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+var (
+	mockMovie = &polochon.DownloadableMetadata{
+		Type:   "movie",
+		ImdbID: "tt2562232",
+	}
+	mockEpisode = &polochon.DownloadableMetadata{
+		Type:    "episode",
+		ImdbID:  "tt0944947",
+		Season:  1,
+		Episode: 2,
+	}
+	mockTorrent = &polochon.Torrent{
+		Name:    "Birdman.2014.720p",
+		Quality: polochon.Quality720p,
+		URL:     "magnet:?xt=urn:btih:birdman",
+		Source:  "mock",
+	}
+)
+
+func TestID(t *testing.T) {
+	if got := ID(mockMovie); got != "tt2562232" {
+		t.Errorf("expected movie ID %q, got %q", "tt2562232", got)
+	}
+
+	if got := ID(mockEpisode); got != "tt0944947-s01e02" {
+		t.Errorf("expected episode ID %q, got %q", "tt0944947-s01e02", got)
+	}
+}
+
+func TestHistoryLifecycle(t *testing.T) {
+	h, err := New("")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if _, err := h.Get(mockMovie); err != ErrNotFound {
+		t.Fatalf("expected %q, got %q", ErrNotFound, err)
+	}
+
+	if err := h.Grabbed(mockMovie, mockTorrent); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	e, err := h.Get(mockMovie)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if e.State != StateGrabbed || !e.InFlight() {
+		t.Errorf("expected an in flight grabbed entry, got %+v", e)
+	}
+
+	if e.Torrenter != "mock" || e.Quality != polochon.Quality720p {
+		t.Errorf("invalid entry %+v", e)
+	}
+
+	if err := h.SetState(mockMovie, StateCompleted); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	// The entries returned are copies
+	if e.State != StateGrabbed {
+		t.Errorf("expected the returned entry to be left untouched, got %+v", e)
+	}
+
+	e, err = h.Get(mockMovie)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if e.State != StateCompleted || !e.InFlight() {
+		t.Errorf("a completed entry should be in flight")
+	}
+
+	if err := h.Failed(mockMovie, "not imported"); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	e, err = h.Get(mockMovie)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if e.InFlight() || e.Error != "not imported" {
+		t.Errorf("invalid failed entry %+v", e)
+	}
+
+	if !h.IsBlacklisted(mockMovie, mockTorrent.URL) {
+		t.Errorf("the torrent should be blacklisted")
+	}
+
+	// The blacklist is kept when a new torrent is grabbed
+	other := &polochon.Torrent{URL: "magnet:?xt=urn:btih:other"}
+	if err := h.Grabbed(mockMovie, other); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if !h.IsBlacklisted(mockMovie, mockTorrent.URL) || h.IsBlacklisted(mockMovie, other.URL) {
+		t.Errorf("invalid blacklist")
+	}
+
+	if err := h.SetState(mockEpisode, StateImported); err != ErrNotFound {
+		t.Errorf("expected %q, got %q", ErrNotFound, err)
+	}
+
+	if err := h.Remove(ID(mockMovie)); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if len(h.List()) != 0 {
+		t.Errorf("expected an empty history")
+	}
+}
+
+func TestHistoryFailedTorrent(t *testing.T) {
+	h, err := New("")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := h.FailedTorrent(mockTorrent.Name, "corrupt archive"); err != ErrNotFound {
+		t.Errorf("expected %q, got %q", ErrNotFound, err)
+	}
+
+	if err := h.Grabbed(mockMovie, mockTorrent); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	other := &polochon.Torrent{Name: "Other.2014", URL: "magnet:?xt=urn:btih:other"}
+	if err := h.Grabbed(mockEpisode, other); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := h.FailedTorrent(mockTorrent.Name, "corrupt archive"); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	e, err := h.Get(mockMovie)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if e.State != StateFailed || e.Error != "corrupt archive" {
+		t.Errorf("invalid failed entry %+v", e)
+	}
+
+	if !h.IsBlacklisted(mockMovie, mockTorrent.URL) {
+		t.Errorf("the torrent should be blacklisted")
+	}
+
+	// The grabs of the other torrents are left untouched
+	e, err = h.Get(mockEpisode)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if e.State != StateGrabbed {
+		t.Errorf("expected the other entry to be left untouched, got %+v", e)
+	}
+
+	// The grabs which are not in flight anymore are not failed again
+	if err := h.FailedTorrent(mockTorrent.Name, "corrupt archive"); err != ErrNotFound {
+		t.Errorf("expected %q, got %q", ErrNotFound, err)
+	}
+}
+
+func TestHistoryPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-history")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "history.json")
+	h, err := New(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, m := range []*polochon.DownloadableMetadata{mockMovie, mockEpisode} {
+		if err := h.Grabbed(m, mockTorrent); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	}
+
+	if err := h.SetState(mockEpisode, StateImported); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	loaded, err := New(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, m := range []*polochon.DownloadableMetadata{mockMovie, mockEpisode} {
+		expected, _ := h.Get(m)
+		got, err := loaded.Get(m)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if got.State != expected.State || got.URL != expected.URL || !got.DateUpdated.Equal(expected.DateUpdated) {
+			t.Errorf("expected %+v, got %+v", expected, got)
+		}
+	}
+
+	if err := loaded.Clear(); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	loaded, err = New(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if len(loaded.List()) != 0 {
+		t.Errorf("expected an empty history")
+	}
+}
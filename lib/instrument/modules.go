@@ -0,0 +1,156 @@
+package instrument
+
+import (
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "polochon",
+		Subsystem: "module",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the requests made by the modules to their upstream service.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 10),
+	}, []string{"module", "type", "method"})
+
+	requestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "polochon",
+		Subsystem: "module",
+		Name:      "request_errors_total",
+		Help:      "Number of requests made by the modules which failed.",
+	}, []string{"module", "type", "method"})
+)
+
+// observe returns a function recording the duration and the error of a
+// request made by a module
+func observe(name string, t polochon.ModuleType, method string) func(err error) {
+	start := time.Now()
+	return func(err error) {
+		requestDuration.WithLabelValues(name, string(t), method).Observe(time.Since(start).Seconds())
+		if err != nil {
+			requestErrors.WithLabelValues(name, string(t), method).Inc()
+		}
+	}
+}
+
+// Detailer returns a detailer recording the metrics of its requests
+func Detailer(d polochon.Detailer) polochon.Detailer {
+	return &detailer{Detailer: d}
+}
+
+type detailer struct {
+	polochon.Detailer
+}
+
+func (d *detailer) GetDetails(i interface{}, log *logrus.Entry) error {
+	done := observe(d.Name(), polochon.TypeDetailer, "get_details")
+	err := d.Detailer.GetDetails(i, log)
+	done(err)
+	return err
+}
+
+// Torrenter returns a torrenter recording the metrics of its requests
+func Torrenter(t polochon.Torrenter) polochon.Torrenter {
+	return &torrenter{Torrenter: t}
+}
+
+type torrenter struct {
+	polochon.Torrenter
+}
+
+func (t *torrenter) GetTorrents(i interface{}, log *logrus.Entry) error {
+	done := observe(t.Name(), polochon.TypeTorrenter, "get_torrents")
+	err := t.Torrenter.GetTorrents(i, log)
+	done(err)
+	return err
+}
+
+func (t *torrenter) SearchTorrents(s string) ([]*polochon.Torrent, error) {
+	done := observe(t.Name(), polochon.TypeTorrenter, "search_torrents")
+	torrents, err := t.Torrenter.SearchTorrents(s)
+	done(err)
+	return torrents, err
+}
+
+// Subtitler returns a subtitler recording the metrics of its requests
+func Subtitler(s polochon.Subtitler) polochon.Subtitler {
+	return &subtitler{Subtitler: s}
+}
+
+type subtitler struct {
+	polochon.Subtitler
+}
+
+func (s *subtitler) GetSubtitle(i interface{}, lang polochon.Language, log *logrus.Entry) (polochon.Subtitle, error) {
+	done := observe(s.Name(), polochon.TypeSubtitler, "get_subtitle")
+	sub, err := s.Subtitler.GetSubtitle(i, lang, log)
+	done(err)
+	return sub, err
+}
+
+// Calendar returns a calendar recording the metrics of its requests
+func Calendar(c polochon.Calendar) polochon.Calendar {
+	return &calendar{Calendar: c}
+}
+
+type calendar struct {
+	polochon.Calendar
+}
+
+func (c *calendar) GetShowCalendar(s *polochon.Show, log *logrus.Entry) (*polochon.ShowCalendar, error) {
+	done := observe(c.Name(), polochon.TypeCalendar, "get_show_calendar")
+	calendar, err := c.Calendar.GetShowCalendar(s, log)
+	done(err)
+	return calendar, err
+}
+
+// Searcher returns a searcher recording the metrics of its requests
+func Searcher(s polochon.Searcher) polochon.Searcher {
+	return &searcher{Searcher: s}
+}
+
+type searcher struct {
+	polochon.Searcher
+}
+
+func (s *searcher) SearchMovie(key string, log *logrus.Entry) ([]*polochon.Movie, error) {
+	done := observe(s.Name(), polochon.TypeSearcher, "search_movie")
+	movies, err := s.Searcher.SearchMovie(key, log)
+	done(err)
+	return movies, err
+}
+
+func (s *searcher) SearchShow(key string, log *logrus.Entry) ([]*polochon.Show, error) {
+	done := observe(s.Name(), polochon.TypeSearcher, "search_show")
+	shows, err := s.Searcher.SearchShow(key, log)
+	done(err)
+	return shows, err
+}
+
+// Explorer returns an explorer recording the metrics of its requests
+func Explorer(e polochon.Explorer) polochon.Explorer {
+	return &explorer{Explorer: e}
+}
+
+type explorer struct {
+	polochon.Explorer
+}
+
+func (e *explorer) GetMovieList(option string, log *logrus.Entry) ([]*polochon.Movie, error) {
+	done := observe(e.Name(), polochon.TypeExplorer, "get_movie_list")
+	movies, err := e.Explorer.GetMovieList(option, log)
+	done(err)
+	return movies, err
+}
+
+func (e *explorer) GetShowList(option string, log *logrus.Entry) ([]*polochon.Show, error) {
+	done := observe(e.Name(), polochon.TypeExplorer, "get_show_list")
+	shows, err := e.Explorer.GetShowList(option, log)
+	done(err)
+	return shows, err
+}
@@ -0,0 +1,157 @@
+package polochon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// osdbChunkSize is the size of the chunks read at the beginning and at the end
+// of a file to compute its OpenSubtitles hash
+const osdbChunkSize = 64 * 1024
+
+// ErrFileTooSmall is returned when a file is too small to be hashed
+var ErrFileTooSmall = errors.New("polochon: file too small to be hashed")
+
+// IntegrityStatus represents the result of the verification of a video file
+type IntegrityStatus string
+
+// Integrity statuses
+const (
+	IntegrityOK        IntegrityStatus = "ok"
+	IntegrityMissing   IntegrityStatus = "missing"
+	IntegrityTruncated IntegrityStatus = "truncated"
+	IntegrityModified  IntegrityStatus = "modified"
+)
+
+// OSDbHash returns the OpenSubtitles hash of a file, it's the sum of the file
+// size and of the 64 bits words of the first and last 64KB of the file
+func OSDbHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	size := fi.Size()
+	if size < osdbChunkSize {
+		return "", ErrFileTooSmall
+	}
+
+	hash := uint64(size)
+	buf := make([]byte, osdbChunkSize)
+	for _, offset := range []int64{0, size - osdbChunkSize} {
+		if _, err := f.ReadAt(buf, offset); err != nil {
+			return "", err
+		}
+
+		for i := 0; i < osdbChunkSize; i += 8 {
+			hash += binary.LittleEndian.Uint64(buf[i : i+8])
+		}
+	}
+
+	return fmt.Sprintf("%016x", hash), nil
+}
+
+// SHA256 returns the SHA-256 checksum of a file
+func SHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ComputeHashes computes and stores the size and the hashes of the video file,
+// the SHA-256 checksum is only computed if asked as it needs to read the
+// whole file
+func (vm *VideoMetadata) ComputeHashes(path string, checksum bool) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	hash, err := OSDbHash(path)
+	if err != nil {
+		return err
+	}
+
+	vm.Size = fi.Size()
+	vm.OSDbHash = hash
+
+	if !checksum {
+		return nil
+	}
+
+	sum, err := SHA256(path)
+	if err != nil {
+		return err
+	}
+	vm.SHA256 = sum
+
+	return nil
+}
+
+// Verify checks the video file against the stored size and hashes, the
+// SHA-256 checksum is only checked if asked and known
+func (vm *VideoMetadata) Verify(path string, checksum bool) (IntegrityStatus, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return IntegrityMissing, nil
+		}
+		return "", err
+	}
+
+	// Nothing to verify
+	if vm.Size == 0 {
+		return IntegrityOK, nil
+	}
+
+	switch {
+	case fi.Size() < vm.Size:
+		return IntegrityTruncated, nil
+	case fi.Size() != vm.Size:
+		return IntegrityModified, nil
+	}
+
+	if vm.OSDbHash != "" {
+		hash, err := OSDbHash(path)
+		if err != nil {
+			return "", err
+		}
+
+		if hash != vm.OSDbHash {
+			return IntegrityModified, nil
+		}
+	}
+
+	if checksum && vm.SHA256 != "" {
+		sum, err := SHA256(path)
+		if err != nil {
+			return "", err
+		}
+
+		if sum != vm.SHA256 {
+			return IntegrityModified, nil
+		}
+	}
+
+	return IntegrityOK, nil
+}
@@ -0,0 +1,97 @@
+package polochon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, dir string, data []byte) string {
+	path := filepath.Join(dir, "video.mkv")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write test file: %q", err)
+	}
+	return path
+}
+
+func TestOSDbHash(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "polochon-integrity-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir for integrity tests")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	data := make([]byte, 2*osdbChunkSize)
+	// Set the first word of the file to 1 and the last word to 2
+	data[0] = 1
+	data[len(data)-8] = 2
+
+	path := writeTestFile(t, tmpDir, data)
+	got, err := OSDbHash(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	// size + first word + last word
+	expected := "0000000000020003"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	path = writeTestFile(t, tmpDir, []byte("too small"))
+	if _, err := OSDbHash(path); err != ErrFileTooSmall {
+		t.Errorf("expected %q, got %q", ErrFileTooSmall, err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "polochon-integrity-test")
+	if err != nil {
+		t.Fatalf("failed to create temp dir for integrity tests")
+	}
+	defer os.RemoveAll(tmpDir)
+
+	data := make([]byte, 3*osdbChunkSize)
+	path := writeTestFile(t, tmpDir, data)
+
+	vm := &VideoMetadata{}
+	if err := vm.ComputeHashes(path, true); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	modified := make([]byte, len(data))
+	modified[osdbChunkSize+1] = 1
+
+	for _, test := range []struct {
+		name     string
+		data     []byte
+		checksum bool
+		expected IntegrityStatus
+	}{
+		{"ok", data, true, IntegrityOK},
+		{"truncated", data[:2*osdbChunkSize], false, IntegrityTruncated},
+		{"bigger", append(data, 1), false, IntegrityModified},
+		// The modified bytes are not read by the OSDb hash
+		{"modified without checksum", modified, false, IntegrityOK},
+		{"modified with checksum", modified, true, IntegrityModified},
+	} {
+		path := writeTestFile(t, tmpDir, test.data)
+		got, err := vm.Verify(path, test.checksum)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %q", test.name, err)
+		}
+
+		if got != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+
+	got, err := vm.Verify(filepath.Join(tmpDir, "missing.mkv"), false)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if got != IntegrityMissing {
+		t.Errorf("expected %q, got %q", IntegrityMissing, got)
+	}
+}
@@ -1,5 +1,7 @@
 package polochon
 
+import "strings"
+
 // Language typc
 type Language string
 
@@ -12,11 +14,14 @@ const (
 // LangInfo represents differents infos of a Lang
 type LangInfo struct {
 	ShortForm string
+	// ISO6392 holds the ISO 639-2 codes of the language, both the
+	// bibliographic and terminologic forms are used in the video containers
+	ISO6392 []string
 }
 
 var langInfo = map[Language]LangInfo{
-	EN: {ShortForm: "en"},
-	FR: {ShortForm: "fr"},
+	EN: {ShortForm: "en", ISO6392: []string{"eng"}},
+	FR: {ShortForm: "fr", ISO6392: []string{"fre", "fra"}},
 }
 
 // ShortForm returns the short form of a lang
@@ -27,3 +32,18 @@ func (l Language) ShortForm() string {
 	// If there is no LangInfo for this lang, return its string form
 	return string(l)
 }
+
+// LanguageFromISO6392 returns a Language from an ISO 639-2 code as found in the
+// video containers, if the code is unknown the code itself is returned
+func LanguageFromISO6392(code string) Language {
+	code = strings.ToLower(code)
+	for lang, info := range langInfo {
+		for _, c := range info.ISO6392 {
+			if c == code {
+				return lang
+			}
+		}
+	}
+
+	return Language(code)
+}
@@ -28,3 +28,18 @@ func TestShortForm(t *testing.T) {
 		}
 	}
 }
+
+func TestLanguageFromISO6392(t *testing.T) {
+	for code, expected := range map[string]Language{
+		"eng": EN,
+		"fre": FR,
+		"fra": FR,
+		"FRE": FR,
+		"ger": Language("ger"),
+	} {
+		got := LanguageFromISO6392(code)
+		if got != expected {
+			t.Errorf("Expected %#v, got %#v for %s", expected, got, code)
+		}
+	}
+}
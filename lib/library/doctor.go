@@ -0,0 +1,473 @@
+package library
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/odwrtw/errors"
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of inconsistencies found by the doctor
+const (
+	IssueMissingNFO     = "missing_nfo"
+	IssueInvalidNFO     = "invalid_nfo"
+	IssueMissingArtwork = "missing_artwork"
+	IssueOrphanFile     = "orphan_file"
+	IssueEmptyDir       = "empty_dir"
+)
+
+// Kinds of videos checked by the doctor
+const (
+	doctorMovie   = "movie"
+	doctorEpisode = "episode"
+)
+
+// DoctorIssue represents an inconsistency found in the library
+type DoctorIssue struct {
+	Kind     string `json:"kind"`
+	Path     string `json:"path"`
+	Repaired bool   `json:"repaired"`
+	Error    string `json:"error,omitempty"`
+}
+
+// DoctorReport represents the inconsistencies found in the library
+type DoctorReport struct {
+	Repair bool           `json:"repair"`
+	Issues []*DoctorIssue `json:"issues"`
+}
+
+// doctor holds the state of a library check
+type doctor struct {
+	library *Library
+	repair  bool
+	log     *logrus.Entry
+	report  *DoctorReport
+	// removed holds the files removed or to be removed in dry-run mode
+	removed map[string]struct{}
+}
+
+// Doctor checks the library for inconsistencies: video files without a valid
+// NFO, missing artworks, orphan sidecar files and empty directories. The
+// videos are not added or deleted until the repair is done. If repair is
+// false nothing is changed and the report shows what would be repaired
+func (l *Library) Doctor(repair bool, log *logrus.Entry) (*DoctorReport, error) {
+	// The files of the videos added or deleted during the repair could be
+	// taken for orphans
+	if repair {
+		l.renameMu.Lock()
+		defer l.renameMu.Unlock()
+	}
+
+	d := &doctor{
+		library: l,
+		repair:  repair,
+		log:     log.WithField("function", "doctor"),
+		report: &DoctorReport{
+			Repair: repair,
+			Issues: []*DoctorIssue{},
+		},
+		removed: map[string]struct{}{},
+	}
+
+	if err := d.checkMovies(); err != nil {
+		return nil, err
+	}
+
+	if err := d.checkShows(); err != nil {
+		return nil, err
+	}
+
+	if !repair || len(d.report.Issues) == 0 {
+		return d.report, nil
+	}
+
+	// The files have changed, rebuild the index
+	if err := l.RebuildIndex(log); err != nil {
+		return nil, err
+	}
+
+	return d.report, nil
+}
+
+// issue adds an issue to the report and repairs it if needed
+func (d *doctor) issue(kind, path string, repair func() error) {
+	issue := &DoctorIssue{Kind: kind, Path: path}
+	d.report.Issues = append(d.report.Issues, issue)
+
+	log := d.log.WithFields(logrus.Fields{
+		"kind": kind,
+		"path": path,
+	})
+
+	if !d.repair {
+		log.Info("library inconsistency found")
+		return
+	}
+
+	if err := repair(); err != nil {
+		issue.Error = err.Error()
+		log.Errorf("failed to repair library inconsistency: %q", err)
+		return
+	}
+
+	issue.Repaired = true
+	log.Info("library inconsistency repaired")
+}
+
+// remove removes a file, the file is only marked as removed in dry-run mode
+func (d *doctor) remove(kind, path string) {
+	d.removed[path] = struct{}{}
+	d.issue(kind, path, func() error {
+		return os.Remove(path)
+	})
+}
+
+func (d *doctor) isVideo(filePath string) bool {
+	ext := strings.ToLower(path.Ext(filePath))
+	for _, e := range d.library.fileConfig.VideoExtensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// subDirs returns the sub directories of a directory
+func subDirs(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	return dirs, nil
+}
+
+// rootsSubDirs returns the sub directories of the roots of the library
+func rootsSubDirs(roots []string) ([]string, error) {
+	dirs := []string{}
+	for _, root := range roots {
+		sub, err := subDirs(root)
+		if err != nil {
+			return nil, err
+		}
+
+		dirs = append(dirs, sub...)
+	}
+
+	return dirs, nil
+}
+
+func (d *doctor) checkMovies() error {
+	dirs, err := rootsSubDirs(d.library.MovieRoots())
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		if err := d.checkVideoDir(dir, doctorMovie, nil); err != nil {
+			return err
+		}
+
+		if err := d.pruneEmptyDir(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *doctor) checkShows() error {
+	dirs, err := rootsSubDirs(d.library.ShowRoots())
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range dirs {
+		show := d.checkShow(dir)
+
+		seasons, err := subDirs(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, season := range seasons {
+			if err := d.checkVideoDir(season, doctorEpisode, show); err != nil {
+				return err
+			}
+
+			if err := d.pruneEmptyDir(season); err != nil {
+				return err
+			}
+		}
+
+		if err := d.pruneEmptyDir(dir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkShow checks the show NFO and artworks, the show is returned if its
+// NFO is valid or has been repaired
+func (d *doctor) checkShow(dir string) *polochon.Show {
+	nfoPath := d.library.showNFOPath(dir)
+
+	show, err := d.library.newShowFromPath(nfoPath)
+	if err != nil {
+		kind := IssueInvalidNFO
+		if !exists(nfoPath) {
+			kind = IssueMissingNFO
+		}
+
+		// The show is only created during the repair
+		show = nil
+		d.issue(kind, nfoPath, func() error {
+			show, err = d.regenerateShowNFO(dir)
+			return err
+		})
+	}
+
+	if show == nil {
+		return nil
+	}
+
+	for _, img := range []struct {
+		url  string
+		name string
+	}{
+		{url: show.Fanart, name: "fanart.jpg"},
+		{url: show.Poster, name: "poster.jpg"},
+		{url: show.Banner, name: "banner.jpg"},
+	} {
+		d.checkArtwork(img.url, filepath.Join(dir, img.name))
+	}
+
+	return show
+}
+
+// regenerateShowNFO writes a show NFO using the show infos stored in the
+// episodes NFOs
+func (d *doctor) regenerateShowNFO(dir string) (*polochon.Show, error) {
+	var ep *polochon.ShowEpisode
+	err := filepath.Walk(dir, func(filePath string, file os.FileInfo, err error) error {
+		if err != nil || file.IsDir() || ep != nil || !d.isVideo(filePath) {
+			return nil
+		}
+
+		e, err := d.library.newEpisodeFromPath(filePath)
+		if err != nil || e.ShowImdbID == "" {
+			return nil
+		}
+
+		ep = e
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if ep == nil {
+		return nil, ErrNoEpisodeNFO
+	}
+
+	ep.ShowConfig = d.library.showConfig
+	show := polochon.NewShowFromEpisode(ep)
+	if err := polochon.GetDetails(show, d.log); err != nil {
+		errors.LogErrors(d.log, err)
+		if errors.IsFatal(err) {
+			return nil, err
+		}
+	}
+
+	if err := writeNFOFile(d.library.showNFOPath(dir), show); err != nil {
+		return nil, err
+	}
+
+	return show, nil
+}
+
+// checkArtwork checks that an artwork exists and downloads it if needed
+func (d *doctor) checkArtwork(url, savePath string) {
+	if exists(savePath) {
+		return
+	}
+
+	d.issue(IssueMissingArtwork, savePath, func() error {
+		if url == "" {
+			return ErrMissingArtworkURL
+		}
+
+		return download(url, savePath)
+	})
+}
+
+// checkVideoDir checks the videos and the sidecar files of a movie or season
+// directory
+func (d *doctor) checkVideoDir(dir, kind string, show *polochon.Show) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	// Keep the paths without extension of the videos
+	videos := map[string]struct{}{}
+	for _, e := range entries {
+		filePath := filepath.Join(dir, e.Name())
+		if e.IsDir() || !d.isVideo(filePath) {
+			continue
+		}
+
+		videos[polochon.NewFile(filePath).PathWithoutExt()] = struct{}{}
+
+		// The sidecar files of a movie split in parts are named without the
+		// part
+		if base, part := polochon.SplitPart(filePath); kind == doctorMovie && part > 0 {
+			videos[base] = struct{}{}
+		}
+
+		d.checkVideo(filePath, kind, show)
+	}
+
+	for _, e := range entries {
+		filePath := filepath.Join(dir, e.Name())
+		if e.IsDir() || d.isVideo(filePath) {
+			continue
+		}
+
+		name := e.Name()
+		var video string
+		switch {
+		case name == "tvshow.nfo":
+			continue
+		case name == "poster.jpg" && kind == doctorMovie:
+			// The movie poster belongs to the directory
+			if len(videos) == 0 {
+				d.remove(IssueOrphanFile, filePath)
+			}
+			continue
+		case strings.HasSuffix(name, "-fanart.jpg"):
+			video = strings.TrimSuffix(filePath, "-fanart.jpg")
+		case strings.HasSuffix(name, ".nfo"):
+			video = strings.TrimSuffix(filePath, ".nfo")
+		case strings.HasSuffix(name, ".srt"):
+			// The subtitles are named after the video and the language
+			video = polochon.NewFile(strings.TrimSuffix(filePath, ".srt")).PathWithoutExt()
+		default:
+			continue
+		}
+
+		if _, ok := videos[video]; !ok {
+			d.remove(IssueOrphanFile, filePath)
+		}
+	}
+
+	return nil
+}
+
+// checkVideo checks the NFO and the artworks of a video
+func (d *doctor) checkVideo(filePath, kind string, show *polochon.Show) {
+	file := polochon.NewFileWithConfig(filePath, d.library.fileConfig)
+
+	var video polochon.Video
+	var err error
+	if kind == doctorMovie {
+		video, err = d.library.newMovieFromPath(filePath)
+	} else {
+		video, err = d.library.newEpisodeFromPath(filePath)
+	}
+
+	if err != nil {
+		issueKind := IssueInvalidNFO
+		if !exists(file.NfoPath()) {
+			issueKind = IssueMissingNFO
+		}
+
+		video = nil
+		d.issue(issueKind, file.NfoPath(), func() error {
+			video, err = d.regenerateNFO(file, show)
+			return err
+		})
+	}
+
+	movie, ok := video.(*polochon.Movie)
+	if !ok {
+		return
+	}
+
+	// The artworks of a movie split in parts are checked with its first part
+	if movie.Path != filePath {
+		return
+	}
+
+	d.checkArtwork(movie.Fanart, movie.MovieFanartPath())
+	d.checkArtwork(movie.Thumb, movie.MovieThumbPath())
+}
+
+// regenerateNFO guesses the video from its file, gets its details and writes
+// its NFO
+func (d *doctor) regenerateNFO(file *polochon.File, show *polochon.Show) (polochon.Video, error) {
+	l := d.library
+	if file.Guesser == nil {
+		return nil, ErrInvalidGuess
+	}
+
+	video, err := file.Guess(l.movieConfig, l.showConfig, d.log)
+	if err != nil {
+		return nil, err
+	}
+
+	if video == nil {
+		return nil, ErrInvalidGuess
+	}
+
+	// Use the show infos from the show NFO
+	if ep, ok := video.(*polochon.ShowEpisode); ok && show != nil {
+		ep.ShowImdbID = show.ImdbID
+		ep.ShowTvdbID = show.TvdbID
+		ep.ShowTitle = show.Title
+	}
+
+	if err := polochon.GetDetails(video, d.log); err != nil {
+		errors.LogErrors(d.log, err)
+		if errors.IsFatal(err) {
+			return nil, err
+		}
+	}
+
+	if err := writeNFOFile(file.NfoPath(), video); err != nil {
+		return nil, err
+	}
+
+	return video, nil
+}
+
+// pruneEmptyDir removes a directory if it is empty or if all its files have
+// been removed
+func (d *doctor) pruneEmptyDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if _, ok := d.removed[filepath.Join(dir, e.Name())]; !ok {
+			return nil
+		}
+	}
+
+	d.remove(IssueEmptyDir, dir)
+	return nil
+}
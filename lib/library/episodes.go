@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"path/filepath"
 
 	polochon "github.com/odwrtw/polochon/lib"
 	index "github.com/odwrtw/polochon/lib/media_index"
@@ -22,29 +21,62 @@ func (l *Library) AddShowEpisode(ep *polochon.ShowEpisode, log *logrus.Entry) er
 		return ErrMissingShowEpisodeFilePath
 	}
 
-	ok, err := l.HasShowEpisode(ep.ShowImdbID, ep.Season, ep.Episode)
-	if err != nil {
-		return err
+	// The episodes without number would replace each other
+	if ep.Episode == 0 {
+		return ErrMissingShowEpisodeNumber
 	}
-	if ok {
+
+	// Replace the files holding any of the episodes of the file
+	episodes := map[int]struct{}{}
+	for _, eNum := range ep.Episodes() {
+		episodes[eNum] = struct{}{}
+	}
+
+	oldEpisodes := map[string]*polochon.ShowEpisode{}
+	for _, eNum := range ep.Episodes() {
+		ok, err := l.HasShowEpisode(ep.ShowImdbID, ep.Season, eNum)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
 		// Get the old episode from the index
-		oldEpisode, err := l.GetEpisode(ep.ShowImdbID, ep.Season, ep.Episode)
+		oldEpisode, err := l.GetEpisode(ep.ShowImdbID, ep.Season, eNum)
 		if err != nil {
 			return err
 		}
 
+		// The other episodes of a multi-episode file would be lost
+		for _, oldNum := range oldEpisode.Episodes() {
+			if _, ok := episodes[oldNum]; !ok {
+				return ErrEpisodeInMultiEpisodeFile
+			}
+		}
+
+		oldEpisodes[oldEpisode.Path] = oldEpisode
+	}
+
+	for _, oldEpisode := range oldEpisodes {
 		if err := l.DeleteShowEpisode(oldEpisode, log); err != nil {
 			return err
 		}
 	}
 
+	// The season dir is chosen first, its show dir depends on the root
+	// storing the season
+	seasonDir, err := l.getSeasonDir(ep)
+	if err != nil {
+		return err
+	}
+
 	// Add the show
-	if err := l.addShow(ep, log); err != nil {
+	if err := l.addShow(ep, path.Dir(seasonDir), log); err != nil {
 		return err
 	}
 
 	// Create show season dir if necessary
-	seasonDir := l.getSeasonDir(ep)
 	if !exists(seasonDir) {
 		if err := os.Mkdir(seasonDir, os.ModePerm); err != nil {
 			return err
@@ -61,19 +93,31 @@ func (l *Library) AddShowEpisode(ep *polochon.ShowEpisode, log *logrus.Entry) er
 	// Save the old path
 	oldPath := ep.Path
 
+	// Hash the file before moving it
+	l.hashVideo(ep, log)
+
 	// Move the episode into the folder
-	newPath := filepath.Join(seasonDir, path.Base(ep.Path))
+	newPath, err := l.getEpisodePath(ep, seasonDir)
+	if err != nil {
+		return err
+	}
 	log.Debugf("Moving episode to folder Old path: %q, New path: %q", ep.Path, newPath)
-	if err := os.Rename(ep.Path, newPath); err != nil {
+	mode := l.importMode(&ep.File)
+	if err := importFile(mode, ep.Path, newPath); err != nil {
 		return err
 	}
 
 	// Set the new episode path
 	ep.Path = newPath
 
+	// Make sure the file is intact
+	if err := l.checkCopiedVideo(ep, mode, oldPath); err != nil {
+		return err
+	}
+
 	// Create a symlink between the new and the old location
-	// Only if the downloader is enabled
-	if l.downloaderConfig.Enabled {
+	// Only if the file is imported as a symlink
+	if mode == polochon.ImportSymlink {
 		if err := os.Symlink(ep.Path, oldPath); err != nil {
 			log.Warnf("Error while making symlink between %s and %s : %+v", oldPath, ep.Path, err)
 		}
@@ -118,7 +162,7 @@ func (l *Library) DeleteShowEpisode(se *polochon.ShowEpisode, log *logrus.Entry)
 	}
 	if ok {
 		// Delete the whole season
-		if err := l.DeleteSeason(se.ShowImdbID, se.Season, log); err != nil {
+		if err := l.deleteSeason(se.ShowImdbID, se.Season, log); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,146 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDoctor(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := lib.Add(m, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the movie: %q", err)
+	}
+
+	movieDir := filepath.Dir(m.Path)
+	emptyDir := filepath.Join(lib.MovieDir, "Empty")
+	orphanDir := filepath.Join(lib.MovieDir, "Orphans")
+	for _, dir := range []string{emptyDir, orphanDir} {
+		if err := os.Mkdir(dir, os.ModePerm); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	}
+
+	// Sidecar files of a deleted movie
+	orphans := []string{
+		filepath.Join(movieDir, "old.en.srt"),
+		filepath.Join(movieDir, "old.nfo"),
+		filepath.Join(orphanDir, "poster.jpg"),
+	}
+	for _, path := range orphans {
+		if _, err := os.Create(path); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	}
+
+	expected := []*DoctorIssue{
+		{Kind: IssueEmptyDir, Path: emptyDir},
+		{Kind: IssueOrphanFile, Path: orphans[0]},
+		{Kind: IssueOrphanFile, Path: orphans[1]},
+		{Kind: IssueOrphanFile, Path: orphans[2]},
+		{Kind: IssueEmptyDir, Path: orphanDir},
+	}
+
+	// Dry run
+	report, err := lib.Doctor(false, mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Errorf("invalid report, expected %+v got %+v", expected, report.Issues)
+	}
+
+	for _, path := range append(orphans, emptyDir, orphanDir) {
+		if !exists(path) {
+			t.Errorf("%q should not have been removed in dry run mode", path)
+		}
+	}
+
+	// Repair
+	report, err = lib.Doctor(true, mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, issue := range expected {
+		issue.Repaired = true
+	}
+
+	if !reflect.DeepEqual(report.Issues, expected) {
+		t.Errorf("invalid report, expected %+v got %+v", expected, report.Issues)
+	}
+
+	for _, path := range append(orphans, emptyDir, orphanDir) {
+		if exists(path) {
+			t.Errorf("%q should have been removed", path)
+		}
+	}
+
+	// The movie must still be there
+	if !exists(m.Path) || !exists(m.NfoPath()) {
+		t.Errorf("the movie files should not have been removed")
+	}
+
+	// Nothing left to repair
+	report, err = lib.Doctor(false, mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if len(report.Issues) != 0 {
+		t.Errorf("expected no issue, got %+v", report.Issues)
+	}
+}
+
+func TestDoctorRepairWaitsForChanges(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	// The repair starts once the ongoing import is done
+	lib.renameMu.RLock()
+	repaired := make(chan error, 1)
+	go func() {
+		_, err := lib.Doctor(true, mockLogEntry)
+		repaired <- err
+	}()
+
+	select {
+	case <-repaired:
+		t.Fatal("expected the repair to wait for the import")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lib.renameMu.RUnlock()
+
+	select {
+	case err := <-repaired:
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the repair to run after the import")
+	}
+
+	// The checks without repair don't wait
+	lib.renameMu.RLock()
+	defer lib.renameMu.RUnlock()
+	if _, err := lib.Doctor(false, mockLogEntry); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+}
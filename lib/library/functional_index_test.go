@@ -0,0 +1,111 @@
+package library
+
+import (
+	"reflect"
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestIndexStats(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := lib.Add(m, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the movie: %q", err)
+	}
+
+	show, err := lib.mockShow()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	show.Episodes = nil
+
+	e, err := lib.mockEpisode(show, "episodeTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := lib.Add(e, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the episode: %q", err)
+	}
+
+	if _, err := lib.AddSubtitles(e, []polochon.Language{polochon.FR, polochon.EN}, mockLogEntry); err != nil {
+		t.Fatalf("failed to add subtitles for the episode: %q", err)
+	}
+
+	expected := &IndexStats{
+		Movies:           1,
+		Shows:            1,
+		Seasons:          1,
+		Episodes:         1,
+		EpisodeSubtitles: 2,
+	}
+
+	got := lib.IndexStats()
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("invalid stats, expected %+v got %+v", expected, got)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	m.Quality = polochon.Quality720p
+
+	if err := lib.Add(m, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the movie: %q", err)
+	}
+
+	if _, err := lib.AddSubtitles(m, []polochon.Language{polochon.FR}, mockLogEntry); err != nil {
+		t.Fatalf("failed to add subtitles for the movie: %q", err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(lib.Collector()); err != nil {
+		t.Fatalf("failed to register the collector: %q", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather the metrics: %q", err)
+	}
+
+	got := map[string]float64{}
+	for _, f := range families {
+		for _, metric := range f.GetMetric() {
+			key := f.GetName()
+			for _, l := range metric.GetLabel() {
+				key += "," + l.GetName() + "=" + l.GetValue()
+			}
+			got[key] = metric.GetGauge().GetValue()
+		}
+	}
+
+	expected := map[string]float64{
+		"polochon_library_videos,quality=720p,type=movie":      1,
+		"polochon_library_size_bytes,quality=720p,type=movie":  0,
+		"polochon_library_subtitles,language=fr_FR,type=movie": 1,
+		"polochon_library_shows":                               0,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("invalid metrics, expected %v got %v", expected, got)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"syscall"
 	"testing"
 
 	polochon "github.com/odwrtw/polochon/lib"
@@ -168,6 +169,59 @@ func TestAddMovie(t *testing.T) {
 
 }
 
+func TestAddMovieAcrossDevices(t *testing.T) {
+	// The renames fail as if the library was on another device
+	rename = func(oldPath, newPath string) error {
+		return &os.LinkError{Op: "rename", Old: oldPath, New: newPath, Err: syscall.EXDEV}
+	}
+	defer func() { rename = os.Rename }()
+
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	oldMoviePath := m.Path
+
+	if err := ioutil.WriteFile(oldMoviePath, []byte("video content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lib.Add(m, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the movie: %q", err)
+	}
+
+	content, err := ioutil.ReadFile(m.Path)
+	if err != nil {
+		t.Fatalf("expected the movie to be copied, got %q", err)
+	}
+	if string(content) != "video content" {
+		t.Errorf("invalid movie content %q", content)
+	}
+
+	// The old path is now a symlink to the copy
+	fi, err := os.Lstat(oldMoviePath)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if fi.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("expected %q to be a symlink", oldMoviePath)
+	}
+
+	gotNewPath, err := filepath.EvalSymlinks(oldMoviePath)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if gotNewPath != m.Path {
+		t.Errorf("invalid symlink, expected %q got %q", m.Path, gotNewPath)
+	}
+}
+
 func TestDeleteMovie(t *testing.T) {
 	lib, err := newMockLibrary()
 	defer lib.cleanup()
@@ -205,8 +259,12 @@ func TestDeleteMovie(t *testing.T) {
 	}
 
 	// Ensure the movie folder has been deleted
-	if exists(lib.getMovieDir(m)) {
-		t.Fatal("the movie directory should have been deleted", err)
+	movieDir, err := lib.getMovieDir(m)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if exists(movieDir) {
+		t.Fatal("the movie directory should have been deleted")
 	}
 
 	// Rebuild the index, it should remain empty
@@ -221,3 +279,113 @@ func TestDeleteMovie(t *testing.T) {
 		t.Fatalf("the library should contains 0 movie instead of %d", movieCount)
 	}
 }
+
+func TestAddMovieParts(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	// Add the parts in the wrong order
+	for _, name := range []string{"movieTest.CD2.mp4", "movieTest.CD1.mp4"} {
+		m, err := lib.mockMovie(name)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if err := lib.Add(m, mockLogEntry); err != nil {
+			t.Fatalf("failed to add the movie part %s: %q", name, err)
+		}
+	}
+
+	movieDir := filepath.Join(lib.tmpDir, "movies/Movie tt12345 (2000)")
+	expectedParts := []polochon.MoviePart{
+		{Number: 1, Path: filepath.Join(movieDir, "movieTest.CD1.mp4")},
+		{Number: 2, Path: filepath.Join(movieDir, "movieTest.CD2.mp4")},
+	}
+
+	m, err := lib.GetMovie("tt12345")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if !reflect.DeepEqual(m.Parts, expectedParts) {
+		t.Errorf("invalid parts, expected %+v got %+v", expectedParts, m.Parts)
+	}
+
+	if m.Path != expectedParts[0].Path {
+		t.Errorf("invalid path, expected %q got %q", expectedParts[0].Path, m.Path)
+	}
+
+	// The NFO is named without the part
+	if !exists(filepath.Join(movieDir, "movieTest.nfo")) {
+		t.Error("the movie NFO should exist")
+	}
+
+	// Rebuild the index, the movie should be indexed once
+	if err := lib.RebuildIndex(mockLogEntry); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if count := len(lib.MovieIDs()); count != 1 {
+		t.Fatalf("the library should contains 1 movie instead of %d", count)
+	}
+
+	m, err = lib.GetMovie("tt12345")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if !reflect.DeepEqual(m.Parts, expectedParts) {
+		t.Errorf("invalid parts after the index rebuild, expected %+v got %+v", expectedParts, m.Parts)
+	}
+}
+
+func TestVerifyMovieParts(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, name := range []string{"movieTest.CD1.mp4", "movieTest.CD2.mp4"} {
+		m, err := lib.mockMovie(name)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if err := ioutil.WriteFile(m.Path, []byte("video "+name), 0644); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		m.Size = int64(len("video " + name))
+
+		if err := lib.Add(m, mockLogEntry); err != nil {
+			t.Fatalf("failed to add the movie part %s: %q", name, err)
+		}
+	}
+
+	results, err := lib.Verify(mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if len(results) != 0 {
+		t.Fatalf("expected no broken movie, got %+v", results)
+	}
+
+	// Truncate the second part
+	movieDir := filepath.Join(lib.tmpDir, "movies/Movie tt12345 (2000)")
+	if err := ioutil.WriteFile(filepath.Join(movieDir, "movieTest.CD2.mp4"), []byte("video"), 0644); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	results, err = lib.Verify(mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if len(results) != 1 || results[0].ImdbID != "tt12345" || results[0].Status != polochon.IntegrityTruncated {
+		t.Errorf("expected the movie to be truncated, got %+v", results)
+	}
+}
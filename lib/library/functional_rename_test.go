@@ -0,0 +1,328 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/naming"
+)
+
+func TestRenameMovie(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	// The symlinks of the downloads are updated
+	downloadDir := filepath.Join(lib.tmpDir, "downloads")
+	lib.watcherConfig = configuration.WatcherConfig{
+		Dirs: []*configuration.WatchDir{{Dir: downloadDir}},
+	}
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := lib.Add(m, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the movie: %q", err)
+	}
+
+	oldDir := filepath.Dir(m.Path)
+	if _, err := os.Create(filepath.Join(oldDir, "movieTest.en.srt")); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	lib.Naming, err = naming.New(naming.Templates{
+		MovieDir:  "{{.Title}} [{{.ImdbID}}]",
+		MovieFile: "{{.Title}} ({{.Year}})",
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	newDir := filepath.Join(lib.MovieDir, "Movie tt12345 [tt12345]")
+	expected := []*RenameOperation{
+		{Kind: renameMovie, OldPath: oldDir, NewPath: newDir},
+		{Kind: renameMovie, OldPath: filepath.Join(newDir, "movieTest-fanart.jpg"), NewPath: filepath.Join(newDir, "Movie tt12345 (2000)-fanart.jpg")},
+		{Kind: renameMovie, OldPath: filepath.Join(newDir, "movieTest.en.srt"), NewPath: filepath.Join(newDir, "Movie tt12345 (2000).en.srt")},
+		{Kind: renameMovie, OldPath: filepath.Join(newDir, "movieTest.mp4"), NewPath: filepath.Join(newDir, "Movie tt12345 (2000).mp4")},
+		{Kind: renameMovie, OldPath: filepath.Join(newDir, "movieTest.nfo"), NewPath: filepath.Join(newDir, "Movie tt12345 (2000).nfo")},
+	}
+
+	// Dry run
+	report, err := lib.Rename(true, mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	checkRenameReport(t, report, expected)
+
+	if !exists(m.Path) {
+		t.Error("the movie should not have been moved in dry run mode")
+	}
+
+	// Rename
+	report, err = lib.Rename(false, mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, op := range expected {
+		op.Done = true
+	}
+	checkRenameReport(t, report, expected)
+
+	for _, name := range []string{
+		"Movie tt12345 (2000).mp4",
+		"Movie tt12345 (2000).nfo",
+		"Movie tt12345 (2000).en.srt",
+		"Movie tt12345 (2000)-fanart.jpg",
+		"poster.jpg",
+	} {
+		if !exists(filepath.Join(newDir, name)) {
+			t.Errorf("%q should have been renamed", name)
+		}
+	}
+
+	// The index is updated
+	expectedPath := filepath.Join(newDir, "Movie tt12345 (2000).mp4")
+	got, err := lib.GetMovie("tt12345")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if got.Path != expectedPath {
+		t.Errorf("invalid movie path, expected %q got %q", expectedPath, got.Path)
+	}
+
+	// The symlink points to the new path
+	gotPath, err := filepath.EvalSymlinks(filepath.Join(downloadDir, "movieTest.mp4"))
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if gotPath != expectedPath {
+		t.Errorf("invalid symlink, expected %q got %q", expectedPath, gotPath)
+	}
+
+	// Nothing left to rename
+	report, err = lib.Rename(false, mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	checkRenameReport(t, report, []*RenameOperation{})
+}
+
+func TestRenameMovieParts(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, name := range []string{"movieTest.CD1.mp4", "movieTest.CD2.mp4"} {
+		m, err := lib.mockMovie(name)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+
+		if err := lib.Add(m, mockLogEntry); err != nil {
+			t.Fatalf("failed to add the movie part %s: %q", name, err)
+		}
+	}
+
+	lib.Naming, err = naming.New(naming.Templates{MovieFile: "{{.Title}}"})
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if _, err := lib.Rename(false, mockLogEntry); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	movieDir := filepath.Join(lib.MovieDir, "Movie tt12345 (2000)")
+	expectedParts := []polochon.MoviePart{
+		{Number: 1, Path: filepath.Join(movieDir, "Movie tt12345.part1.mp4")},
+		{Number: 2, Path: filepath.Join(movieDir, "Movie tt12345.part2.mp4")},
+	}
+
+	m, err := lib.GetMovie("tt12345")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if !reflect.DeepEqual(m.Parts, expectedParts) {
+		t.Errorf("invalid parts, expected %+v got %+v", expectedParts, m.Parts)
+	}
+
+	// The NFO is still named without the part
+	if !exists(filepath.Join(movieDir, "Movie tt12345.nfo")) {
+		t.Error("the movie NFO should have been renamed")
+	}
+}
+
+func TestRenameShow(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	show, err := lib.mockShow()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	show.Episodes = nil
+
+	episode, err := lib.mockEpisode(show, "episodeTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := lib.Add(episode, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the episode: %q", err)
+	}
+
+	lib.Naming, err = naming.New(naming.Templates{
+		ShowDir:     "{{.ShowTitle}} [{{.ShowImdbID}}]",
+		SeasonDir:   `S{{printf "%02d" .Season}}`,
+		EpisodeFile: `{{.ShowTitle}} - S{{printf "%02d" .Season}}E{{printf "%02d" .Episode}}`,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	oldShowDir := filepath.Join(lib.ShowDir, "Show tt12345")
+	newShowDir := filepath.Join(lib.ShowDir, "Show tt12345 [tt12345]")
+	newSeasonDir := filepath.Join(newShowDir, "S01")
+	expected := []*RenameOperation{
+		{Kind: renameShow, OldPath: oldShowDir, NewPath: newShowDir, Done: true},
+		{Kind: renameSeason, OldPath: filepath.Join(newShowDir, "Season 1"), NewPath: newSeasonDir, Done: true},
+		{Kind: renameEpisode, OldPath: filepath.Join(newSeasonDir, "episodeTest.mp4"), NewPath: filepath.Join(newSeasonDir, "Show tt12345 - S01E01.mp4"), Done: true},
+		{Kind: renameEpisode, OldPath: filepath.Join(newSeasonDir, "episodeTest.nfo"), NewPath: filepath.Join(newSeasonDir, "Show tt12345 - S01E01.nfo"), Done: true},
+	}
+
+	report, err := lib.Rename(false, mockLogEntry)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	checkRenameReport(t, report, expected)
+
+	for _, name := range []string{"tvshow.nfo", "banner.jpg", "fanart.jpg", "poster.jpg"} {
+		if !exists(filepath.Join(newShowDir, name)) {
+			t.Errorf("%q should have been moved with the show", name)
+		}
+	}
+
+	// The index is updated
+	expectedPath := filepath.Join(newSeasonDir, "Show tt12345 - S01E01.mp4")
+	got, err := lib.GetEpisode("tt12345", 1, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if got.Path != expectedPath {
+		t.Errorf("invalid episode path, expected %q got %q", expectedPath, got.Path)
+	}
+
+	// The new episodes of the show are stored in its new directory
+	episode, err = lib.mockEpisode(show, "episodeTest2.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	episode.Season = 2
+
+	if err := lib.Add(episode, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the episode: %q", err)
+	}
+
+	expectedPath = filepath.Join(newShowDir, "S02", "Show tt12345 - S02E01.mp4")
+	if episode.Path != expectedPath {
+		t.Errorf("invalid episode path, expected %q got %q", expectedPath, episode.Path)
+	}
+}
+
+func TestMovieDirCollision(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := lib.Add(m, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the movie: %q", err)
+	}
+
+	// Another movie with the same title and year
+	other := *m
+	other.ImdbID = "tt54321"
+
+	got, err := lib.getMovieDir(&other)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := filepath.Join(lib.MovieDir, "Movie tt12345 (2000) [tt54321]")
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func checkRenameReport(t *testing.T, report *RenameReport, expected []*RenameOperation) {
+	t.Helper()
+
+	if len(report.Operations) != len(expected) {
+		t.Fatalf("expected %d operations, got %d: %+v", len(expected), len(report.Operations), report.Operations)
+	}
+
+	for i, op := range report.Operations {
+		if *op != *expected[i] {
+			t.Errorf("invalid operation %d, expected %+v got %+v", i, expected[i], op)
+		}
+	}
+}
+
+func TestRenameBlocksChanges(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	// The movie is added once the ongoing rename is done
+	lib.renameMu.Lock()
+	added := make(chan error, 1)
+	go func() { added <- lib.Add(m, mockLogEntry) }()
+
+	select {
+	case <-added:
+		t.Fatal("expected the movie not to be added during the rename")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	lib.renameMu.Unlock()
+
+	select {
+	case err := <-added:
+		if err != nil {
+			t.Fatalf("failed to add the movie: %q", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the movie to be added after the rename")
+	}
+}
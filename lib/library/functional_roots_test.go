@@ -0,0 +1,144 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/odwrtw/polochon/lib/configuration"
+)
+
+func TestMultipleRoots(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	movieRoot := filepath.Join(lib.tmpDir, "movies2")
+	showRoot := filepath.Join(lib.tmpDir, "shows2")
+	for _, dir := range []string{movieRoot, showRoot} {
+		if err := os.Mkdir(dir, os.ModePerm); err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+	}
+
+	lib.MovieDirs = []string{movieRoot}
+	lib.ShowDirs = []string{showRoot}
+	lib.ShowPlacement = configuration.PlacementSameRoot
+
+	// The second roots have the most free space
+	free := map[string]uint64{
+		lib.MovieDir: 100,
+		movieRoot:    500,
+		lib.ShowDir:  100,
+		showRoot:     500,
+	}
+	lib.diskUsage = mockDiskUsage(free)
+
+	m, err := lib.mockMovie("movieTest.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if err := lib.Add(m, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the movie: %q", err)
+	}
+
+	if rootOf(lib.MovieRoots(), m.Path) != movieRoot {
+		t.Errorf("the movie should have been stored in %q, got %q", movieRoot, m.Path)
+	}
+
+	show, err := lib.mockShow()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	show.Episodes = nil
+
+	addEpisode := func(name string, season int) string {
+		t.Helper()
+
+		episode, err := lib.mockEpisode(show, name)
+		if err != nil {
+			t.Fatalf("expected no error, got %q", err)
+		}
+		episode.Season = season
+
+		if err := lib.Add(episode, mockLogEntry); err != nil {
+			t.Fatalf("failed to add the episode: %q", err)
+		}
+
+		return episode.Path
+	}
+
+	// A new show is stored on the root with the most free space
+	path := addEpisode("episodeTest1.mp4", 1)
+	if rootOf(lib.ShowRoots(), path) != showRoot {
+		t.Errorf("the episode should have been stored in %q, got %q", showRoot, path)
+	}
+
+	// The new seasons stay with the existing ones
+	free[lib.ShowDir] = 1000
+	path = addEpisode("episodeTest2.mp4", 2)
+	if rootOf(lib.ShowRoots(), path) != showRoot {
+		t.Errorf("the episode should have been stored in %q, got %q", showRoot, path)
+	}
+
+	// With the most free placement the show is split on both roots
+	lib.ShowPlacement = configuration.PlacementMostFree
+	path = addEpisode("episodeTest3.mp4", 3)
+	expected := filepath.Join(lib.ShowDir, "Show tt12345", "Season 3", "episodeTest3.mp4")
+	if path != expected {
+		t.Errorf("expected the episode in %q, got %q", expected, path)
+	}
+
+	if !exists(filepath.Join(lib.ShowDir, "Show tt12345", "tvshow.nfo")) {
+		t.Error("the show NFO should have been written on the new root")
+	}
+
+	// The index is built from all the roots
+	if err := lib.RebuildIndex(mockLogEntry); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if _, err := lib.GetMovie(m.ImdbID); err != nil {
+		t.Errorf("the movie should be in the index: %q", err)
+	}
+
+	for _, season := range []int{1, 2, 3} {
+		if _, err := lib.GetEpisode("tt12345", season, 1); err != nil {
+			t.Errorf("the episode of season %d should be in the index: %q", season, err)
+		}
+	}
+
+	videos := map[string]int{}
+	for _, u := range lib.RootsUsage() {
+		if u.Error != "" {
+			t.Errorf("unexpected error for the root %q: %s", u.Path, u.Error)
+		}
+		videos[u.Path] = u.Videos
+	}
+
+	expectedVideos := map[string]int{
+		lib.MovieDir: 0,
+		movieRoot:    1,
+		lib.ShowDir:  1,
+		showRoot:     2,
+	}
+	for root, count := range expectedVideos {
+		if videos[root] != count {
+			t.Errorf("expected %d videos on %q, got %d", count, root, videos[root])
+		}
+	}
+
+	// The show is removed from all the roots
+	if err := lib.DeleteShow("tt12345", mockLogEntry); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	for _, root := range []string{lib.ShowDir, showRoot} {
+		if exists(filepath.Join(root, "Show tt12345")) {
+			t.Errorf("the show should have been removed from %q", root)
+		}
+	}
+}
@@ -231,13 +231,18 @@ func TestAddEpisode(t *testing.T) {
 }
 
 func testShow(t *testing.T, episode *polochon.ShowEpisode, lib *mockLibrary) {
+	showDir, err := lib.getShowDir(episode)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
 	// Check the content of the downloaded images of the show
 	for _, name := range []string{
 		"banner.jpg",
 		"fanart.jpg",
 		"poster.jpg",
 	} {
-		path := filepath.Join(lib.getShowDir(episode), name)
+		path := filepath.Join(showDir, name)
 		content, err := ioutil.ReadFile(path)
 		if err != nil {
 			t.Fatalf("expected no error, got %q", err)
@@ -331,3 +336,78 @@ func TestDeleteEpisode(t *testing.T) {
 		t.Errorf("invalid show ids, expected %+v got %+v", expectedIDs, gotIDs)
 	}
 }
+
+func TestAddEpisodeOverMultiEpisodeFile(t *testing.T) {
+	lib, err := newMockLibrary()
+	defer lib.cleanup()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	show, err := lib.mockShow()
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	show.Episodes = nil
+
+	// Add a file holding the first two episodes
+	double, err := lib.mockEpisode(show, "episodeTest.S01E01E02.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	double.EpisodeEnd = 2
+
+	if err := lib.Add(double, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the episodes: %q", err)
+	}
+
+	// A file holding only the second episode is not added
+	single, err := lib.mockEpisode(show, "episodeTest.S01E02.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	single.Episode = 2
+
+	if err := lib.Add(single, mockLogEntry); err != ErrEpisodeInMultiEpisodeFile {
+		t.Fatalf("expected %q, got %q", ErrEpisodeInMultiEpisodeFile, err)
+	}
+
+	if !exists(double.Path) {
+		t.Errorf("expected the multi-episode file to be kept")
+	}
+
+	for _, eNum := range []int{1, 2} {
+		e, err := lib.GetIndexedEpisode(show.ImdbID, 1, eNum)
+		if err != nil {
+			t.Fatalf("expected the episode %d to be indexed, got %q", eNum, err)
+		}
+
+		if e.Path != double.Path {
+			t.Errorf("expected the episode %d in %q, got %q", eNum, double.Path, e.Path)
+		}
+	}
+
+	// A file holding both episodes replaces it
+	other, err := lib.mockEpisode(show, "episodeTest.S01E01-E02.mp4")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	other.EpisodeEnd = 2
+
+	if err := lib.Add(other, mockLogEntry); err != nil {
+		t.Fatalf("failed to add the episodes: %q", err)
+	}
+
+	if exists(double.Path) {
+		t.Errorf("expected the previous multi-episode file to be removed")
+	}
+
+	e, err := lib.GetIndexedEpisode(show.ImdbID, 1, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if e.Path != other.Path {
+		t.Errorf("expected the episode in %q, got %q", other.Path, e.Path)
+	}
+}
@@ -51,7 +51,20 @@ func (l *Library) RebuildIndex(log *logrus.Entry) error {
 
 func (l *Library) buildMovieIndex(log *logrus.Entry) error {
 	start := time.Now()
-	err := filepath.Walk(l.MovieDir, func(filePath string, file os.FileInfo, err error) error {
+	for _, root := range l.MovieRoots() {
+		if err := l.walkMovieRoot(root, log); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Index built in %s", time.Since(start))
+
+	return nil
+}
+
+// walkMovieRoot adds the movies of a root to the index
+func (l *Library) walkMovieRoot(root string, log *logrus.Entry) error {
+	return filepath.Walk(root, func(filePath string, file os.FileInfo, err error) error {
 		walkLog := log.WithField("path", filePath)
 		// Check err
 		if err != nil {
@@ -86,6 +99,11 @@ func (l *Library) buildMovieIndex(log *logrus.Entry) error {
 			return nil
 		}
 
+		// The movies split in several parts are indexed from their first part
+		if movie.Path != moviePath {
+			return nil
+		}
+
 		// Add the movie to the index
 		err = l.movieIndex.Add(movie)
 		if err != nil {
@@ -106,10 +124,6 @@ func (l *Library) buildMovieIndex(log *logrus.Entry) error {
 
 		return nil
 	})
-
-	log.Infof("Index built in %s", time.Since(start))
-
-	return err
 }
 
 // HasSubtitle returns true if the subtitle exists on the disk
@@ -123,11 +137,23 @@ func (l *Library) HasSubtitle(v polochon.Video, lang polochon.Language) bool {
 
 func (l *Library) buildShowIndex(log *logrus.Entry) error {
 	start := time.Now()
+	for _, root := range l.ShowRoots() {
+		if err := l.walkShowRoot(root, log); err != nil {
+			return err
+		}
+	}
+
+	log.Infof("Index built in %s", time.Since(start))
+
+	return nil
+}
 
+// walkShowRoot adds the shows of a root to the index
+func (l *Library) walkShowRoot(root string, log *logrus.Entry) error {
 	// used to catch if the first root folder has been walked
 	var rootWalked bool
 	// Get only the parent folders
-	err := filepath.Walk(l.ShowDir, func(filePath string, file os.FileInfo, err error) error {
+	return filepath.Walk(root, func(filePath string, file os.FileInfo, err error) error {
 		walkLog := log.WithField("path", filePath)
 		if err != nil {
 			walkLog.Errorf("library: failed to access path: %q", filePath)
@@ -162,14 +188,6 @@ func (l *Library) buildShowIndex(log *logrus.Entry) error {
 		// No need to go deeper, the tvshow.nfo is in the second root folder
 		return filepath.SkipDir
 	})
-	if err != nil {
-		return err
-	}
-
-	log.Infof("Index built in %s", time.Since(start))
-
-	return nil
-
 }
 
 func (l *Library) scanEpisodes(imdbID, showRootPath string, log *logrus.Entry) error {
@@ -236,3 +254,40 @@ func (l *Library) scanEpisodes(imdbID, showRootPath string, log *logrus.Entry) e
 
 	return nil
 }
+
+// IndexStats represents the number of videos and subtitles in the index
+type IndexStats struct {
+	Movies           int `json:"movies"`
+	MovieSubtitles   int `json:"movie_subtitles"`
+	Shows            int `json:"shows"`
+	Seasons          int `json:"seasons"`
+	Episodes         int `json:"episodes"`
+	EpisodeSubtitles int `json:"episode_subtitles"`
+}
+
+// IndexStats returns the stats of the library index
+func (l *Library) IndexStats() *IndexStats {
+	stats := &IndexStats{}
+
+	for _, m := range l.movieIndex.Index() {
+		stats.Movies++
+		stats.MovieSubtitles += len(m.Subtitles)
+	}
+
+	for _, show := range l.showIndex.Index() {
+		stats.Shows++
+		for _, season := range show.Seasons {
+			stats.Seasons++
+			for eNum, e := range season.Episodes {
+				stats.Episodes++
+
+				// The subtitles are shared by the episodes of a file
+				if e.IsFirst(eNum) {
+					stats.EpisodeSubtitles += len(e.Subtitles)
+				}
+			}
+		}
+	}
+
+	return stats
+}
@@ -3,10 +3,12 @@ package library
 import (
 	"io"
 	"os"
+	"sync"
 
 	"github.com/odwrtw/errors"
 	polochon "github.com/odwrtw/polochon/lib"
 	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/disk"
 	index "github.com/odwrtw/polochon/lib/media_index"
 	"github.com/sirupsen/logrus"
 )
@@ -18,6 +20,14 @@ var (
 	ErrMissingMovieImageURL       = errors.New("library: missing movie images URL")
 	ErrMissingShowImageURL        = errors.New("library: missing URL to download show images")
 	ErrMissingShowEpisodeFilePath = errors.New("library: missing file path")
+	ErrMissingShowEpisodeNumber   = errors.New("library: missing episode number")
+	ErrCorruptedFile              = errors.New("library: file corrupted while being copied")
+	ErrNoEpisodeNFO               = errors.New("library: no episode NFO found to get the show infos")
+	ErrMissingArtworkURL          = errors.New("library: no artwork URL in the NFO")
+	ErrInvalidGuess               = errors.New("library: failed to guess the video from its file")
+	ErrRenameTargetExists         = errors.New("library: rename target already exists")
+	ErrNotEnoughSpace             = errors.New("library: not enough space left on the library roots")
+	ErrEpisodeInMultiEpisodeFile  = errors.New("library: episode stored in a file holding other episodes")
 )
 
 // Library represents a collection of videos
@@ -29,7 +39,14 @@ type Library struct {
 	movieConfig       polochon.MovieConfig
 	fileConfig        polochon.FileConfig
 	downloaderConfig  configuration.DownloaderConfig
+	watcherConfig     configuration.WatcherConfig
 	SubtitleLanguages []polochon.Language
+	// diskUsage returns the usage of the filesystem of a root
+	diskUsage func(string) (*disk.Usage, error)
+	// renameMu keeps the videos from being added or deleted while the
+	// library is renamed or repaired, the rename and the repair hold it
+	// exclusively
+	renameMu sync.RWMutex
 }
 
 // New returns a list of videos
@@ -41,8 +58,10 @@ func New(config *configuration.Config) *Library {
 		movieConfig:       config.Movie,
 		fileConfig:        config.File,
 		downloaderConfig:  config.Downloader,
+		watcherConfig:     config.Watcher,
 		SubtitleLanguages: config.SubtitleLanguages,
 		LibraryConfig:     config.Library,
+		diskUsage:         disk.GetUsage,
 	}
 }
 
@@ -60,6 +79,9 @@ func (l *Library) HasVideo(video polochon.Video) (bool, error) {
 
 // Add video
 func (l *Library) Add(video polochon.Video, log *logrus.Entry) error {
+	l.renameMu.RLock()
+	defer l.renameMu.RUnlock()
+
 	switch v := video.(type) {
 	case *polochon.Movie:
 		return l.AddMovie(v, log)
@@ -72,6 +94,9 @@ func (l *Library) Add(video polochon.Video, log *logrus.Entry) error {
 
 // Delete will delete the video
 func (l *Library) Delete(video polochon.Video, log *logrus.Entry) error {
+	l.renameMu.RLock()
+	defer l.renameMu.RUnlock()
+
 	switch v := video.(type) {
 	case *polochon.Movie:
 		return l.DeleteMovie(v, log)
@@ -84,12 +109,22 @@ func (l *Library) Delete(video polochon.Video, log *logrus.Entry) error {
 
 // AddSubtitles gets and downloads subtitles of different languages
 func (l *Library) AddSubtitles(video polochon.Subtitlable, languages []polochon.Language, log *logrus.Entry) ([]polochon.Language, error) {
+	l.renameMu.RLock()
+	defer l.renameMu.RUnlock()
+
 	c := errors.NewCollector()
 	addedSubtitles := []polochon.Language{}
 
 	// We're going to ask subtitles in each language for each subtitles
 	for _, lang := range languages {
 		subtitlerLog := log.WithField("lang", lang)
+
+		// No need to download a subtitle already embedded in the video file
+		if v, ok := video.(polochon.Video); ok && v.GetMetadata().HasEmbeddedSubtitle(lang) {
+			subtitlerLog.Debug("subtitle embedded in the video file")
+			continue
+		}
+
 		// Ask all the subtitlers
 		for _, subtitler := range video.GetSubtitlers() {
 			subtitlerLog = subtitlerLog.WithField("subtitler", subtitler.Name())
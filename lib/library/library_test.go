@@ -16,6 +16,9 @@ type mockInvalidType string
 func (m *mockInvalidType) GetTorrents(log *logrus.Entry) error { return nil }
 func (m *mockInvalidType) SetFile(f *polochon.File)            {}
 func (m *mockInvalidType) GetFile() *polochon.File             { return &polochon.File{} }
+func (m *mockInvalidType) GetMetadata() *polochon.VideoMetadata {
+	return &polochon.VideoMetadata{}
+}
 func (m *mockInvalidType) GetSubtitlers() []polochon.Subtitler { return nil }
 func (m *mockInvalidType) GetDetailers() []polochon.Detailer   { return nil }
 func (m *mockInvalidType) GetTorrenters() []polochon.Torrenter { return nil }
@@ -32,17 +35,29 @@ func TestStoreMovieNoPath(t *testing.T) {
 	}
 }
 
+func TestStoreShowEpisodeNoNumber(t *testing.T) {
+	library := New(&configuration.Config{})
+	episode := &polochon.ShowEpisode{File: polochon.File{Path: "/downloads/Show.2026.10.15.mkv"}}
+
+	if err := library.Add(episode, mockLogEntry); err != ErrMissingShowEpisodeNumber {
+		t.Errorf("Expected %q, got %q", ErrMissingShowEpisodeNumber, err)
+	}
+}
+
 func TestGetMovieDir(t *testing.T) {
-	l := &Library{
-		LibraryConfig: configuration.LibraryConfig{
+	l := New(&configuration.Config{
+		Library: configuration.LibraryConfig{
 			MovieDir: "/",
 		},
-	}
+	})
 	m := &polochon.Movie{Title: "Test"}
 
 	// Without year
 	expected := "/Test"
-	got := l.getMovieDir(m)
+	got, err := l.getMovieDir(m)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
 	if got != expected {
 		t.Errorf("expected %q, got %q", expected, got)
 	}
@@ -50,7 +65,10 @@ func TestGetMovieDir(t *testing.T) {
 	// With year
 	m.Year = 2000
 	expected = "/Test (2000)"
-	got = l.getMovieDir(m)
+	got, err = l.getMovieDir(m)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
 	if got != expected {
 		t.Errorf("expected %q, got %q", expected, got)
 	}
@@ -0,0 +1,114 @@
+package library
+
+import (
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	videosDesc = prometheus.NewDesc(
+		"polochon_library_videos",
+		"Number of videos in the library by type and quality.",
+		[]string{"type", "quality"}, nil,
+	)
+	sizeDesc = prometheus.NewDesc(
+		"polochon_library_size_bytes",
+		"Size of the videos in the library by type and quality.",
+		[]string{"type", "quality"}, nil,
+	)
+	showsDesc = prometheus.NewDesc(
+		"polochon_library_shows",
+		"Number of shows in the library.",
+		nil, nil,
+	)
+	subtitlesDesc = prometheus.NewDesc(
+		"polochon_library_subtitles",
+		"Number of videos with a subtitle by type and language.",
+		[]string{"type", "language"}, nil,
+	)
+)
+
+// Collector exposes the content of the library index as prometheus metrics
+type Collector struct {
+	library *Library
+}
+
+// Collector returns a collector exposing the content of the library
+func (l *Library) Collector() *Collector {
+	return &Collector{library: l}
+}
+
+// Describe implements the prometheus Collector interface
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- videosDesc
+	ch <- sizeDesc
+	ch <- showsDesc
+	ch <- subtitlesDesc
+}
+
+// videoStats holds the stats of the videos of a type
+type videoStats struct {
+	count     map[polochon.Quality]int
+	size      map[polochon.Quality]int64
+	subtitles map[polochon.Language]int
+}
+
+func newVideoStats() *videoStats {
+	return &videoStats{
+		count:     map[polochon.Quality]int{},
+		size:      map[polochon.Quality]int64{},
+		subtitles: map[polochon.Language]int{},
+	}
+}
+
+func (s *videoStats) add(m polochon.VideoMetadata, subtitles []polochon.Language) {
+	quality := m.Quality
+	if quality == "" {
+		quality = "unknown"
+	}
+
+	s.count[quality]++
+	s.size[quality] += m.Size
+	for _, lang := range subtitles {
+		s.subtitles[lang]++
+	}
+}
+
+func (s *videoStats) collect(videoType string, ch chan<- prometheus.Metric) {
+	for q, count := range s.count {
+		ch <- prometheus.MustNewConstMetric(videosDesc, prometheus.GaugeValue, float64(count), videoType, string(q))
+		ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(s.size[q]), videoType, string(q))
+	}
+
+	for lang, count := range s.subtitles {
+		ch <- prometheus.MustNewConstMetric(subtitlesDesc, prometheus.GaugeValue, float64(count), videoType, string(lang))
+	}
+}
+
+// Collect implements the prometheus Collector interface
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	movies := newVideoStats()
+	for _, m := range c.library.movieIndex.Index() {
+		movies.add(m.VideoMetadata, m.Subtitles)
+	}
+
+	episodes := newVideoStats()
+	shows := 0
+	for _, show := range c.library.showIndex.Index() {
+		shows++
+		for _, season := range show.Seasons {
+			for eNum, e := range season.Episodes {
+				// The files holding several episodes are counted once
+				if !e.IsFirst(eNum) {
+					continue
+				}
+
+				episodes.add(e.VideoMetadata, e.Subtitles)
+			}
+		}
+	}
+
+	movies.collect("movie", ch)
+	episodes.collect("episode", ch)
+	ch <- prometheus.MustNewConstMetric(showsDesc, prometheus.GaugeValue, float64(shows))
+}
@@ -1,7 +1,6 @@
 package library
 
 import (
-	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -26,13 +25,6 @@ func (l *Library) HasMovie(imdbID string) (bool, error) {
 	return l.movieIndex.Has(imdbID)
 }
 
-func (l *Library) getMovieDir(movie *polochon.Movie) string {
-	if movie.Year != 0 {
-		return filepath.Join(l.MovieDir, fmt.Sprintf("%s (%d)", movie.Title, movie.Year))
-	}
-	return filepath.Join(l.MovieDir, movie.Title)
-}
-
 // AddMovie adds a movie to the store
 func (l *Library) AddMovie(movie *polochon.Movie, log *logrus.Entry) error {
 	if movie.Path == "" {
@@ -44,6 +36,7 @@ func (l *Library) AddMovie(movie *polochon.Movie, log *logrus.Entry) error {
 	if err != nil {
 		return err
 	}
+	part := movie.FilePart()
 	if ok {
 		// Get the old movie path from the index
 		oldMovie, err := l.GetMovie(movie.ImdbID)
@@ -51,13 +44,21 @@ func (l *Library) AddMovie(movie *polochon.Movie, log *logrus.Entry) error {
 			return err
 		}
 
+		// The parts of a movie are added one by one
+		if part > 0 && len(oldMovie.Parts) > 0 && oldMovie.Part(part) == nil {
+			return l.addMoviePart(oldMovie, movie, part, log)
+		}
+
 		// Delete it
 		if err := l.DeleteMovie(oldMovie, log); err != nil {
 			return err
 		}
 	}
 
-	storePath := l.getMovieDir(movie)
+	storePath, err := l.getMovieDir(movie)
+	if err != nil {
+		return err
+	}
 
 	// If the movie already in the right dir there is nothing to do
 	if path.Dir(movie.Path) == storePath {
@@ -79,27 +80,44 @@ func (l *Library) AddMovie(movie *polochon.Movie, log *logrus.Entry) error {
 	}
 
 	// Move the movie into the folder
-	newPath := filepath.Join(storePath, path.Base(movie.Path))
+	newPath, err := l.getMoviePath(movie, storePath, part)
+	if err != nil {
+		return err
+	}
 
 	// Save the old path
 	oldPath := movie.Path
 
+	// Hash the file before moving it
+	l.hashVideo(movie, log)
+
 	log.Debugf("Old path: %q, new path %q", movie.Path, newPath)
-	if err := os.Rename(movie.Path, newPath); err != nil {
+	mode := l.importMode(&movie.File)
+	if err := importFile(mode, movie.Path, newPath); err != nil {
 		return err
 	}
 
 	// Set the new movie path
 	movie.Path = newPath
 
+	// Make sure the file is intact
+	if err := l.checkCopiedVideo(movie, mode, oldPath); err != nil {
+		return err
+	}
+
 	// Create a symlink between the new and the old location
-	// Only if the downloader is enabled
-	if l.downloaderConfig.Enabled {
+	// Only if the file is imported as a symlink
+	if mode == polochon.ImportSymlink {
 		if err := os.Symlink(movie.Path, oldPath); err != nil {
 			log.Warnf("error while making symlink between %s and %s : %+v", oldPath, movie.Path, err)
 		}
 	}
 
+	// The other parts of the movie will be added next to this one
+	if part > 0 {
+		movie.AddPart(polochon.MoviePart{Number: part, Path: movie.Path, Size: movie.Size})
+	}
+
 	// Write NFO into the file
 	if err := writeNFOFile(movie.NfoPath(), movie); err != nil {
 		return err
@@ -136,6 +154,65 @@ func (l *Library) AddMovie(movie *polochon.Movie, log *logrus.Entry) error {
 	return nil
 }
 
+// addMoviePart adds a part to a movie already in the library, the details of
+// the stored movie are kept
+func (l *Library) addMoviePart(stored, movie *polochon.Movie, part int, log *logrus.Entry) error {
+	newPath, err := l.getMoviePath(movie, filepath.Dir(stored.Path), part)
+	if err != nil {
+		return err
+	}
+	oldPath := movie.Path
+	oldNfoPath := stored.NfoPath()
+
+	// Hash the file before moving it
+	l.hashVideo(movie, log)
+
+	log.Debugf("Adding part %d, old path: %q, new path %q", part, movie.Path, newPath)
+	mode := l.importMode(&movie.File)
+	if err := importFile(mode, movie.Path, newPath); err != nil {
+		return err
+	}
+
+	movie.Path = newPath
+
+	// Make sure the file is intact
+	if err := l.checkCopiedVideo(movie, mode, oldPath); err != nil {
+		return err
+	}
+
+	if mode == polochon.ImportSymlink {
+		if err := os.Symlink(newPath, oldPath); err != nil {
+			log.Warnf("error while making symlink between %s and %s : %+v", oldPath, newPath, err)
+		}
+	}
+
+	stored.AddPart(polochon.MoviePart{Number: part, Path: newPath, Size: movie.Size})
+
+	// The hashes of the movie are the ones of its first part
+	if stored.Path == newPath {
+		stored.Size = movie.Size
+		stored.OSDbHash = movie.OSDbHash
+		stored.SHA256 = movie.SHA256
+	}
+
+	if err := writeNFOFile(stored.NfoPath(), stored); err != nil {
+		return err
+	}
+
+	// The NFO is named after the first part
+	if oldNfoPath != stored.NfoPath() {
+		if err := os.Remove(oldNfoPath); err != nil {
+			log.Warnf("failed to remove the previous NFO: %q", err)
+		}
+	}
+
+	// The movie now represents all its parts
+	movie.Path = stored.Path
+	movie.Parts = stored.Parts
+
+	return l.movieIndex.Add(stored)
+}
+
 // GetMovie returns the video by its imdb ID
 func (l *Library) GetMovie(imdbID string) (*polochon.Movie, error) {
 	movieIndex, err := l.movieIndex.Movie(imdbID)
@@ -158,15 +235,33 @@ func (l *Library) DeleteMovie(m *polochon.Movie, log *logrus.Entry) error {
 	return l.movieIndex.Remove(m, log)
 }
 
-// NewMovieFromPath returns a new Movie from its path
+// NewMovieFromPath returns a new Movie from its path, the path of a movie
+// split in several parts is the path of its first part
 func (l *Library) newMovieFromPath(path string) (*polochon.Movie, error) {
 	file := polochon.NewFile(path)
 	m := polochon.NewMovieFromFile(l.movieConfig, *file)
 
-	if err := readNFOFile(file.NfoPath(), m); err != nil {
+	// The NFO of a movie split in several parts is named without the part
+	nfoPath := file.NfoPath()
+	if base, part := polochon.SplitPart(path); part > 0 && exists(base+".nfo") {
+		nfoPath = base + ".nfo"
+	}
+
+	if err := readNFOFile(nfoPath, m); err != nil {
 		return nil, err
 	}
 
+	if len(m.Parts) == 0 {
+		return m, nil
+	}
+
+	// The parts are stored relative to the movie directory
+	dir := filepath.Dir(path)
+	for i := range m.Parts {
+		m.Parts[i].Path = filepath.Join(dir, m.Parts[i].Path)
+	}
+	m.Path = m.Parts[0].Path
+
 	return m, nil
 }
 
@@ -0,0 +1,161 @@
+package library
+
+import (
+	"fmt"
+	"path/filepath"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/naming"
+)
+
+// namer returns the namer of the library, the default templates are used if
+// none is configured
+func (l *Library) namer() *naming.Namer {
+	if l.Naming != nil {
+		return l.Naming
+	}
+
+	return naming.Default()
+}
+
+// withImdbID returns the name suffixed with the imdb id, it's used to tell
+// apart the videos named the same way
+func withImdbID(name, imdbID string) string {
+	return naming.Sanitize(fmt.Sprintf("%s [%s]", name, imdbID))
+}
+
+// movieDirOwner returns the imdb id of the movie indexed in a directory
+func (l *Library) movieDirOwner(dir string) string {
+	for id, m := range l.movieIndex.Index() {
+		if filepath.Dir(m.Path) == dir {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// getMovieDir returns the directory storing a new movie, its root is chosen
+// according to the movie placement policy
+func (l *Library) getMovieDir(movie *polochon.Movie) (string, error) {
+	root, err := l.pickRoot(l.MovieRoots(), l.MoviePlacement, fileSize(movie.Path))
+	if err != nil {
+		return "", err
+	}
+
+	return l.movieDir(movie, root)
+}
+
+// movieDir returns the directory of a movie in a root, the imdb id is added
+// to the name if another movie is already stored in this directory
+func (l *Library) movieDir(movie *polochon.Movie, root string) (string, error) {
+	name, err := l.namer().MovieDir(movie)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(root, name)
+	if owner := l.movieDirOwner(dir); owner != "" && owner != movie.ImdbID {
+		dir = filepath.Join(root, withImdbID(name, movie.ImdbID))
+	}
+
+	return dir, nil
+}
+
+// getMoviePath returns the path of a movie file in its directory
+func (l *Library) getMoviePath(movie *polochon.Movie, dir string, part int) (string, error) {
+	name, err := l.namer().MovieFile(movie, part)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, name), nil
+}
+
+// showDirOwner returns the imdb id of the show stored in a directory
+func (l *Library) showDirOwner(dir string) string {
+	for id, s := range l.showIndex.Index() {
+		if s.Path == dir {
+			return id
+		}
+	}
+
+	show, err := l.newShowFromPath(l.showNFOPath(dir))
+	if err != nil {
+		return ""
+	}
+
+	return show.ImdbID
+}
+
+// newShowDir returns the directory of a show in a root named by the
+// templates, the imdb id is added to the name if another show is already
+// stored in this directory
+func (l *Library) newShowDir(ep *polochon.ShowEpisode, root string) (string, error) {
+	name, err := l.namer().ShowDir(ep)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(root, name)
+	if owner := l.showDirOwner(dir); owner != "" && owner != ep.ShowImdbID {
+		dir = filepath.Join(root, withImdbID(name, ep.ShowImdbID))
+	}
+
+	return dir, nil
+}
+
+// getShowDir returns the directory of the show storing a new season, the
+// shows already in the library keep their directory until they're renamed.
+// The root of a new season is chosen according to the show placement policy,
+// a show stored on several roots has a directory on each of them
+func (l *Library) getShowDir(ep *polochon.ShowEpisode) (string, error) {
+	showRoots := l.ShowRoots()
+	dir, err := l.showIndex.ShowPath(ep.ShowImdbID)
+	indexed := err == nil
+	if indexed && (l.ShowPlacement == configuration.PlacementSameRoot || len(showRoots) < 2) {
+		return dir, nil
+	}
+
+	root, err := l.pickRoot(showRoots, l.ShowPlacement, fileSize(ep.Path))
+	if err != nil {
+		return "", err
+	}
+
+	if indexed && rootOf(showRoots, dir) == root {
+		return dir, nil
+	}
+
+	return l.newShowDir(ep, root)
+}
+
+// getSeasonDir returns the directory of a season, the seasons already in the
+// library keep their directory until they're renamed
+func (l *Library) getSeasonDir(ep *polochon.ShowEpisode) (string, error) {
+	if dir, err := l.showIndex.SeasonPath(ep.ShowImdbID, ep.Season); err == nil {
+		return dir, nil
+	}
+
+	showDir, err := l.getShowDir(ep)
+	if err != nil {
+		return "", err
+	}
+
+	name, err := l.namer().SeasonDir(ep)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(showDir, name), nil
+}
+
+// getEpisodePath returns the path of an episode file in its season directory
+func (l *Library) getEpisodePath(ep *polochon.ShowEpisode, seasonDir string) (string, error) {
+	name, err := l.namer().EpisodeFile(ep)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(seasonDir, name), nil
+}
@@ -0,0 +1,459 @@
+package library
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	index "github.com/odwrtw/polochon/lib/media_index"
+	"github.com/sirupsen/logrus"
+)
+
+// Kinds of videos renamed
+const (
+	renameMovie   = "movie"
+	renameShow    = "show"
+	renameSeason  = "season"
+	renameEpisode = "episode"
+)
+
+// RenameOperation represents a file or a directory of the library moved to
+// the path given by the naming templates
+type RenameOperation struct {
+	Kind    string `json:"kind"`
+	OldPath string `json:"old_path"`
+	NewPath string `json:"new_path"`
+	Done    bool   `json:"done"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RenameReport represents the operations needed to rename the library
+type RenameReport struct {
+	DryRun     bool               `json:"dry_run"`
+	Operations []*RenameOperation `json:"operations"`
+}
+
+// renamer holds the state of a library rename
+type renamer struct {
+	library *Library
+	dryRun  bool
+	log     *logrus.Entry
+	report  *RenameReport
+}
+
+// Rename moves the videos of the library to the paths given by the naming
+// templates and updates the index. The symlinks of the watched directories
+// pointing to the moved files are updated, the videos are not added or
+// deleted until the rename is done. If dryRun is true nothing is changed and
+// the report shows what would be renamed
+func (l *Library) Rename(dryRun bool, log *logrus.Entry) (*RenameReport, error) {
+	// The videos added or deleted while the library is renamed would be
+	// lost or moved to stale paths
+	if !dryRun {
+		l.renameMu.Lock()
+		defer l.renameMu.Unlock()
+	}
+
+	r := &renamer{
+		library: l,
+		dryRun:  dryRun,
+		log:     log.WithField("function", "rename"),
+		report: &RenameReport{
+			DryRun:     dryRun,
+			Operations: []*RenameOperation{},
+		},
+	}
+
+	for _, id := range l.MovieIDs() {
+		r.renameMovie(id)
+	}
+
+	ids := []string{}
+	for id := range l.ShowIDs() {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		r.renameShow(id)
+	}
+
+	if dryRun || len(r.report.Operations) == 0 {
+		return r.report, nil
+	}
+
+	r.updateSymlinks()
+
+	// The files have moved, rebuild the index
+	if err := l.RebuildIndex(log); err != nil {
+		return nil, err
+	}
+
+	return r.report, nil
+}
+
+// move moves a file or a directory, the operation is only added to the report
+// in dry-run mode. It returns false if the path could not be moved
+func (r *renamer) move(kind, oldPath, newPath string) bool {
+	if oldPath == newPath {
+		return true
+	}
+
+	op := &RenameOperation{Kind: kind, OldPath: oldPath, NewPath: newPath}
+	r.report.Operations = append(r.report.Operations, op)
+
+	log := r.log.WithFields(logrus.Fields{
+		"kind":     kind,
+		"old_path": oldPath,
+		"new_path": newPath,
+	})
+
+	var err error
+	switch {
+	case exists(newPath):
+		err = ErrRenameTargetExists
+	case r.dryRun:
+		log.Info("library path to rename found")
+		return true
+	default:
+		err = os.Rename(oldPath, newPath)
+	}
+
+	if err != nil {
+		op.Error = err.Error()
+		log.Errorf("failed to rename library path: %q", err)
+		return false
+	}
+
+	op.Done = true
+	log.Info("library path renamed")
+	return true
+}
+
+// resolve returns the path of a file once moved by the operations of the
+// report
+func (r *renamer) resolve(path string) string {
+	for _, op := range r.report.Operations {
+		if op.Error != "" {
+			continue
+		}
+
+		if path == op.OldPath {
+			path = op.NewPath
+		} else if strings.HasPrefix(path, op.OldPath+string(filepath.Separator)) {
+			path = op.NewPath + strings.TrimPrefix(path, op.OldPath)
+		}
+	}
+
+	return path
+}
+
+// sidecarSuffix returns the end of the name of a file sharing the name of a
+// video: its NFO, subtitles and fanart
+func sidecarSuffix(name, base string) (string, bool) {
+	if !strings.HasPrefix(name, base) {
+		return "", false
+	}
+
+	switch {
+	case name == base+".nfo", name == base+"-fanart.jpg":
+	case strings.HasSuffix(name, ".srt"):
+		// The subtitles are named after the video and the language
+		trimmed := strings.TrimSuffix(name, ".srt")
+		if trimmed != base && polochon.NewFile(trimmed).PathWithoutExt() != base {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	return name[len(base):], true
+}
+
+// fileRenames returns the new names of the files of a directory holding a
+// video: the video files are renamed from the videos map and their sidecar
+// files from the name of the video without extension
+func fileRenames(dir string, videos map[string]string, base, newBase string) (map[string]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	renames := map[string]string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		name := e.Name()
+		newName, ok := videos[name]
+		if !ok {
+			suffix, ok := sidecarSuffix(name, base)
+			if !ok {
+				continue
+			}
+			newName = newBase + suffix
+		}
+
+		if newName != name {
+			renames[name] = newName
+		}
+	}
+
+	return renames, nil
+}
+
+// moveFiles renames the files of a directory
+func (r *renamer) moveFiles(kind, dir string, renames map[string]string) {
+	names := make([]string, 0, len(renames))
+	for name := range renames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.move(kind, filepath.Join(dir, name), filepath.Join(dir, renames[name]))
+	}
+}
+
+func (r *renamer) renameMovie(id string) {
+	l := r.library
+	log := r.log.WithField("imdb_id", id)
+
+	m, err := l.GetMovie(id)
+	if err != nil {
+		log.Errorf("failed to read the movie: %q", err)
+		return
+	}
+
+	// The movies are renamed on the root storing them
+	oldDir := filepath.Dir(m.Path)
+	newDir, err := l.movieDir(m, rootOf(l.MovieRoots(), oldDir))
+	if err != nil {
+		log.Errorf("failed to name the movie directory: %q", err)
+		return
+	}
+
+	// The movies split in parts share the name of the files without the part
+	videos := map[string]string{}
+	var base, newBase string
+	if len(m.Parts) == 0 {
+		newPath, err := l.getMoviePath(m, newDir, 0)
+		if err != nil {
+			log.Errorf("failed to name the movie file: %q", err)
+			return
+		}
+
+		videos[filepath.Base(m.Path)] = filepath.Base(newPath)
+		base = polochon.NewFile(filepath.Base(m.Path)).PathWithoutExt()
+		newBase = polochon.NewFile(filepath.Base(newPath)).PathWithoutExt()
+	} else {
+		for _, p := range m.Parts {
+			part := *m
+			part.Path = p.Path
+			newPath, err := l.getMoviePath(&part, newDir, p.Number)
+			if err != nil {
+				log.Errorf("failed to name the movie part: %q", err)
+				return
+			}
+
+			videos[filepath.Base(p.Path)] = filepath.Base(newPath)
+		}
+
+		base, _ = polochon.SplitPart(filepath.Base(m.Path))
+		newBase, _ = polochon.SplitPart(videos[filepath.Base(m.Path)])
+	}
+
+	renames, err := fileRenames(oldDir, videos, base, newBase)
+	if err != nil {
+		log.Errorf("failed to list the movie files: %q", err)
+		return
+	}
+
+	if !r.move(renameMovie, oldDir, newDir) {
+		return
+	}
+
+	r.moveFiles(renameMovie, r.resolve(oldDir), renames)
+
+	if r.dryRun || len(m.Parts) == 0 {
+		return
+	}
+
+	// The NFO of a movie split in parts holds the names of its parts
+	for i := range m.Parts {
+		m.Parts[i].Path = r.resolve(m.Parts[i].Path)
+	}
+	m.Path = m.Parts[0].Path
+
+	if err := writeNFOFile(m.NfoPath(), m); err != nil {
+		log.Errorf("failed to write the movie NFO: %q", err)
+	}
+}
+
+// renamedSeason holds the episodes of a season to rename
+type renamedSeason struct {
+	path     string
+	episodes []*polochon.ShowEpisode
+	// renames holds the new names of the files of the season
+	renames map[string]string
+}
+
+func (r *renamer) renameShow(id string) {
+	l := r.library
+	log := r.log.WithField("imdb_id", id)
+
+	show, err := l.GetIndexedShow(id)
+	if err != nil {
+		log.Errorf("failed to get the show: %q", err)
+		return
+	}
+
+	// Read the episodes before anything is moved
+	seasons := []*renamedSeason{}
+	for _, sNum := range show.SeasonList() {
+		season := show.Seasons[sNum]
+		s := &renamedSeason{path: season.Path, renames: map[string]string{}}
+
+		for _, e := range seasonEpisodes(season) {
+			ep, err := l.newEpisodeFromPath(e.Path)
+			if err != nil {
+				log.Errorf("failed to read the episode: %q", err)
+				continue
+			}
+			ep.ShowImdbID = id
+			ep.ShowConfig = l.showConfig
+
+			newPath, err := l.getEpisodePath(ep, season.Path)
+			if err != nil {
+				log.Errorf("failed to name the episode file: %q", err)
+				continue
+			}
+
+			name, newName := filepath.Base(ep.Path), filepath.Base(newPath)
+			renames, err := fileRenames(season.Path, map[string]string{name: newName},
+				polochon.NewFile(name).PathWithoutExt(),
+				polochon.NewFile(newName).PathWithoutExt(),
+			)
+			if err != nil {
+				log.Errorf("failed to list the episode files: %q", err)
+				continue
+			}
+
+			for oldName, newName := range renames {
+				s.renames[oldName] = newName
+			}
+			s.episodes = append(s.episodes, ep)
+		}
+
+		if len(s.episodes) != 0 {
+			seasons = append(seasons, s)
+		}
+	}
+
+	// The seasons of a show stored on several roots are renamed in the
+	// directory of the show on their root
+	showDirs := []string{}
+	showSeasons := map[string][]*renamedSeason{}
+	for _, s := range seasons {
+		dir := filepath.Dir(s.path)
+		if _, ok := showSeasons[dir]; !ok {
+			showDirs = append(showDirs, dir)
+		}
+		showSeasons[dir] = append(showSeasons[dir], s)
+	}
+
+	for _, dir := range showDirs {
+		r.renameShowDir(dir, showSeasons[dir], log)
+	}
+}
+
+// renameShowDir renames a directory of a show and its seasons
+func (r *renamer) renameShowDir(dir string, seasons []*renamedSeason, log *logrus.Entry) {
+	l := r.library
+
+	newShowDir, err := l.newShowDir(seasons[0].episodes[0], rootOf(l.ShowRoots(), dir))
+	if err != nil {
+		log.Errorf("failed to name the show directory: %q", err)
+		return
+	}
+
+	if !r.move(renameShow, dir, newShowDir) {
+		return
+	}
+
+	for _, s := range seasons {
+		name, err := l.namer().SeasonDir(s.episodes[0])
+		if err != nil {
+			log.Errorf("failed to name the season directory: %q", err)
+			continue
+		}
+
+		if !r.move(renameSeason, r.resolve(s.path), filepath.Join(newShowDir, name)) {
+			continue
+		}
+
+		r.moveFiles(renameEpisode, r.resolve(s.path), s.renames)
+	}
+}
+
+// seasonEpisodes returns the files of a season, the files holding several
+// episodes are only returned once
+func seasonEpisodes(season *index.Season) []*index.Episode {
+	numbers := make([]int, 0, len(season.Episodes))
+	for eNum := range season.Episodes {
+		numbers = append(numbers, eNum)
+	}
+	sort.Ints(numbers)
+
+	episodes := []*index.Episode{}
+	for _, eNum := range numbers {
+		if e := season.Episodes[eNum]; e.IsFirst(eNum) {
+			episodes = append(episodes, e)
+		}
+	}
+
+	return episodes
+}
+
+// updateSymlinks points the symlinks of the watched directories to the new
+// paths of the files they were pointing to
+func (r *renamer) updateSymlinks() {
+	for _, d := range r.library.watcherConfig.Dirs {
+		err := filepath.Walk(d.Dir, func(filePath string, file os.FileInfo, err error) error {
+			if err != nil || file.Mode()&os.ModeSymlink == 0 {
+				return nil
+			}
+
+			target, err := os.Readlink(filePath)
+			if err != nil {
+				return nil
+			}
+
+			newTarget := r.resolve(target)
+			if newTarget == target {
+				return nil
+			}
+
+			log := r.log.WithField("path", filePath)
+			if err := os.Remove(filePath); err != nil {
+				log.Errorf("failed to remove the symlink: %q", err)
+				return nil
+			}
+
+			if err := os.Symlink(newTarget, filePath); err != nil {
+				log.Errorf("failed to update the symlink: %q", err)
+				return nil
+			}
+
+			log.Debugf("symlink updated to %q", newTarget)
+			return nil
+		})
+		if err != nil {
+			r.log.Errorf("failed to walk the watched directory: %q", err)
+		}
+	}
+}
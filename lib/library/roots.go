@@ -0,0 +1,129 @@
+package library
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/disk"
+)
+
+// Types of roots
+const (
+	rootTypeMovie = "movie"
+	rootTypeShow  = "show"
+)
+
+// RootUsage represents the usage of a root of the library
+type RootUsage struct {
+	disk.Usage
+	Type   string `json:"type"`
+	Videos int    `json:"videos"`
+	Error  string `json:"error,omitempty"`
+}
+
+// rootOf returns the root holding a path, it's empty if the path is in none
+// of the roots
+func rootOf(roots []string, path string) string {
+	for _, r := range roots {
+		if path == r || strings.HasPrefix(path, r+string(filepath.Separator)) {
+			return r
+		}
+	}
+
+	return ""
+}
+
+// fileSize returns the size of a file, 0 if it can't be read
+func fileSize(path string) int64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+
+	return fi.Size()
+}
+
+// pickRoot returns the root storing a new file according to the placement
+// policy, the roots without enough free space for the file are skipped
+func (l *Library) pickRoot(roots []string, placement configuration.Placement, size int64) (string, error) {
+	switch len(roots) {
+	case 0:
+		return "", nil
+	case 1:
+		return roots[0], nil
+	}
+
+	var best *disk.Usage
+	for _, root := range roots {
+		u, err := l.diskUsage(root)
+		if err != nil || !u.Fits(size) {
+			continue
+		}
+
+		if placement == configuration.PlacementInOrder {
+			return root, nil
+		}
+
+		if best == nil || u.Free > best.Free {
+			best = u
+		}
+	}
+
+	if best == nil {
+		return "", ErrNotEnoughSpace
+	}
+
+	return best.Path, nil
+}
+
+// RootsUsage returns the usage of the roots of the library and the number of
+// videos they store
+func (l *Library) RootsUsage() []*RootUsage {
+	movies := map[string]int{}
+	movieRoots := l.MovieRoots()
+	for _, m := range l.movieIndex.Index() {
+		movies[rootOf(movieRoots, m.Path)]++
+	}
+
+	// The episodes of a file are counted once
+	shows := map[string]int{}
+	showRoots := l.ShowRoots()
+	for _, s := range l.showIndex.Index() {
+		for _, season := range s.Seasons {
+			root := rootOf(showRoots, season.Path)
+			for eNum, e := range season.Episodes {
+				if e.IsFirst(eNum) {
+					shows[root]++
+				}
+			}
+		}
+	}
+
+	usages := []*RootUsage{}
+	for _, r := range []struct {
+		kind   string
+		roots  []string
+		videos map[string]int
+	}{
+		{kind: rootTypeMovie, roots: movieRoots, videos: movies},
+		{kind: rootTypeShow, roots: showRoots, videos: shows},
+	} {
+		for _, root := range r.roots {
+			usage := &RootUsage{Type: r.kind, Videos: r.videos[root]}
+			usage.Path = root
+
+			u, err := l.diskUsage(root)
+			if err != nil {
+				usage.Error = err.Error()
+			} else {
+				usage.Usage = *u
+			}
+
+			usages = append(usages, usage)
+		}
+	}
+
+	return usages
+}
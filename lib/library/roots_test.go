@@ -0,0 +1,118 @@
+package library
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/odwrtw/polochon/lib/configuration"
+	"github.com/odwrtw/polochon/lib/disk"
+)
+
+// mockDiskUsage returns a disk usage function reading the free space of the
+// roots from a map
+func mockDiskUsage(free map[string]uint64) func(string) (*disk.Usage, error) {
+	return func(path string) (*disk.Usage, error) {
+		f, ok := free[path]
+		if !ok {
+			return nil, fmt.Errorf("no such root %q", path)
+		}
+
+		return &disk.Usage{Path: path, Total: 1000, Free: f, Used: 1000 - f}, nil
+	}
+}
+
+func TestPickRoot(t *testing.T) {
+	l := New(&configuration.Config{})
+	l.diskUsage = mockDiskUsage(map[string]uint64{
+		"/disk1": 100,
+		"/disk2": 500,
+		"/disk3": 300,
+	})
+
+	for _, test := range []struct {
+		name      string
+		roots     []string
+		placement configuration.Placement
+		size      int64
+		expected  string
+		err       error
+	}{
+		{
+			name:     "no root",
+			roots:    []string{},
+			expected: "",
+		},
+		{
+			name:     "single root",
+			roots:    []string{"/missing"},
+			size:     1000,
+			expected: "/missing",
+		},
+		{
+			name:      "most free",
+			roots:     []string{"/disk1", "/disk2", "/disk3"},
+			placement: configuration.PlacementMostFree,
+			size:      50,
+			expected:  "/disk2",
+		},
+		{
+			name:      "in order",
+			roots:     []string{"/disk1", "/disk2", "/disk3"},
+			placement: configuration.PlacementInOrder,
+			size:      50,
+			expected:  "/disk1",
+		},
+		{
+			name:      "in order skips the full roots",
+			roots:     []string{"/disk1", "/disk3", "/disk2"},
+			placement: configuration.PlacementInOrder,
+			size:      200,
+			expected:  "/disk3",
+		},
+		{
+			name:      "same root for a new show",
+			roots:     []string{"/disk1", "/disk2", "/disk3"},
+			placement: configuration.PlacementSameRoot,
+			expected:  "/disk2",
+		},
+		{
+			name:      "unreadable root",
+			roots:     []string{"/missing", "/disk1"},
+			placement: configuration.PlacementInOrder,
+			expected:  "/disk1",
+		},
+		{
+			name:      "not enough space",
+			roots:     []string{"/disk1", "/disk2", "/disk3"},
+			placement: configuration.PlacementMostFree,
+			size:      600,
+			err:       ErrNotEnoughSpace,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := l.pickRoot(test.roots, test.placement, test.size)
+			if err != test.err {
+				t.Fatalf("expected error %q, got %q", test.err, err)
+			}
+
+			if got != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRootOf(t *testing.T) {
+	roots := []string{"/movies", "/mnt/movies"}
+
+	for path, expected := range map[string]string{
+		"/movies/Movie (2000)/movie.mp4":     "/movies",
+		"/mnt/movies/Movie (2000)/movie.mp4": "/mnt/movies",
+		"/movies2/Movie (2000)/movie.mp4":    "",
+		"/mnt/movies":                        "/mnt/movies",
+	} {
+		if got := rootOf(roots, path); got != expected {
+			t.Errorf("root of %q: expected %q, got %q", path, expected, got)
+		}
+	}
+}
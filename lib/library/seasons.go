@@ -1,7 +1,6 @@
 package library
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 
@@ -36,6 +35,15 @@ func (l *Library) GetSeason(id string, season int) (*polochon.ShowSeason, error)
 
 // DeleteSeason deletes a season
 func (l *Library) DeleteSeason(id string, season int, log *logrus.Entry) error {
+	l.renameMu.RLock()
+	defer l.renameMu.RUnlock()
+
+	return l.deleteSeason(id, season, log)
+}
+
+// deleteSeason deletes a season, the show is deleted along with its last
+// season
+func (l *Library) deleteSeason(id string, season int, log *logrus.Entry) error {
 	path, err := l.showIndex.SeasonPath(id, season)
 	if err != nil {
 		return err
@@ -59,14 +67,31 @@ func (l *Library) DeleteSeason(id string, season int, log *logrus.Entry) error {
 	}
 	if ok {
 		// Delete the whole Show
-		if err := l.DeleteShow(id, log); err != nil {
-			return err
-		}
+		return l.deleteShow(id, log)
 	}
 
-	return nil
+	// The directory of the show on another root is removed with its last
+	// season
+	return l.deleteEmptyShowDir(id, filepath.Dir(path))
 }
 
-func (l *Library) getSeasonDir(ep *polochon.ShowEpisode) string {
-	return filepath.Join(l.ShowDir, ep.ShowTitle, fmt.Sprintf("Season %d", ep.Season))
+// deleteEmptyShowDir removes a directory of a show stored on several roots
+// once it holds no season
+func (l *Library) deleteEmptyShowDir(id, dir string) error {
+	show, err := l.showIndex.IndexedShow(id)
+	if err != nil {
+		return err
+	}
+
+	if dir == show.Path {
+		return nil
+	}
+
+	for _, season := range show.Seasons {
+		if filepath.Dir(season.Path) == dir {
+			return nil
+		}
+	}
+
+	return os.RemoveAll(dir)
 }
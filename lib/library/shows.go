@@ -33,18 +33,33 @@ func (l *Library) GetShow(id string) (*polochon.Show, error) {
 
 // DeleteShow deletes the whole show
 func (l *Library) DeleteShow(id string, log *logrus.Entry) error {
-	path, err := l.showIndex.ShowPath(id)
+	l.renameMu.RLock()
+	defer l.renameMu.RUnlock()
+
+	return l.deleteShow(id, log)
+}
+
+// deleteShow deletes the whole show and its seasons stored on several roots
+func (l *Library) deleteShow(id string, log *logrus.Entry) error {
+	show, err := l.showIndex.IndexedShow(id)
 	if err != nil {
 		return err
 	}
 
-	if err := os.RemoveAll(path); err != nil {
-		return err
+	// The seasons of a show may be stored on several roots
+	dirs := map[string]struct{}{show.Path: {}}
+	for _, season := range show.Seasons {
+		dirs[filepath.Dir(season.Path)] = struct{}{}
+	}
+
+	for dir := range dirs {
+		if err := os.RemoveAll(dir); err != nil {
+			return err
+		}
 	}
 
 	// Remove the show from the index
-	show := &polochon.Show{ImdbID: id}
-	return l.showIndex.RemoveShow(show, log)
+	return l.showIndex.RemoveShow(&polochon.Show{ImdbID: id}, log)
 }
 
 // GetIndexedShow returns an indexed Show from its id
@@ -57,8 +72,8 @@ func (l *Library) GetIndexedShow(id string) (*index.Show, error) {
 	return s, nil
 }
 
-func (l *Library) addShow(ep *polochon.ShowEpisode, log *logrus.Entry) error {
-	dir := l.getShowDir(ep)
+// addShow stores the NFO and the images of a show in its directory
+func (l *Library) addShow(ep *polochon.ShowEpisode, dir string, log *logrus.Entry) error {
 	nfoPath := l.showNFOPath(dir)
 	if exists(nfoPath) {
 		return nil
@@ -129,10 +144,6 @@ func (l *Library) newShowFromPath(path string) (*polochon.Show, error) {
 	return s, nil
 }
 
-func (l *Library) getShowDir(ep *polochon.ShowEpisode) string {
-	return filepath.Join(l.ShowDir, ep.ShowTitle)
-}
-
 func (l *Library) showNFOPath(showDir string) string {
 	return filepath.Join(showDir, "tvshow.nfo")
 }
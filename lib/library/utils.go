@@ -1,10 +1,14 @@
 package library
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"syscall"
 
+	polochon "github.com/odwrtw/polochon/lib"
 	"github.com/odwrtw/polochon/lib/nfo"
 )
 
@@ -64,3 +68,99 @@ func exists(path string) bool {
 	}
 	return false
 }
+
+// importMode returns the mode used to import a file in the library, the files
+// are symlinked back to their download location by default if the downloader
+// is enabled
+func (l *Library) importMode(f *polochon.File) polochon.ImportMode {
+	if f.ImportMode != "" {
+		return f.ImportMode
+	}
+
+	if l.downloaderConfig.Enabled {
+		return polochon.ImportSymlink
+	}
+
+	return polochon.ImportMove
+}
+
+// rename renames a file, it's replaced in the tests to move the files across
+// devices
+var rename = os.Rename
+
+// importFile imports a file into the library according to the import mode
+func importFile(mode polochon.ImportMode, oldPath, newPath string) error {
+	switch mode {
+	case polochon.ImportHardlink:
+		return os.Link(oldPath, newPath)
+	case polochon.ImportCopy:
+		return copyFile(oldPath, newPath)
+	default:
+		// The symlink to the old location is created once the file is moved
+		// and checked
+		err := rename(oldPath, newPath)
+		if errors.Is(err, syscall.EXDEV) {
+			return moveFile(oldPath, newPath)
+		}
+		return err
+	}
+}
+
+// moveFile moves a file to another device, the file is copied and only
+// removed once the copy is on the disk with the same size
+func moveFile(oldPath, newPath string) error {
+	if err := copyFile(oldPath, newPath); err != nil {
+		return err
+	}
+
+	src, err := os.Stat(oldPath)
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.Stat(newPath)
+	if err != nil {
+		return err
+	}
+
+	if src.Size() != dst.Size() {
+		os.Remove(newPath)
+		return fmt.Errorf("library: %s copied to %s with a different size", oldPath, newPath)
+	}
+
+	return os.Remove(oldPath)
+}
+
+// copyFile copies the content of a file to a new path
+func copyFile(oldPath, newPath string) error {
+	src, err := os.Open(oldPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	dst, err := os.OpenFile(newPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode())
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(newPath)
+		return err
+	}
+
+	// Make sure the copy is on the disk before the source is removed
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		os.Remove(newPath)
+		return err
+	}
+
+	return dst.Close()
+}
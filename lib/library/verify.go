@@ -0,0 +1,164 @@
+package library
+
+import (
+	"os"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	index "github.com/odwrtw/polochon/lib/media_index"
+	"github.com/sirupsen/logrus"
+)
+
+// Video types of the verification results
+const (
+	verifyTypeMovie   = "movie"
+	verifyTypeEpisode = "episode"
+)
+
+// VerifyResult represents a video file which failed the verification
+type VerifyResult struct {
+	Type    string                   `json:"type"`
+	ImdbID  string                   `json:"imdb_id"`
+	Season  int                      `json:"season,omitempty"`
+	Episode int                      `json:"episode,omitempty"`
+	Status  polochon.IntegrityStatus `json:"status"`
+}
+
+// IsMovie returns true if the result is about a movie
+func (r *VerifyResult) IsMovie() bool {
+	return r.Type == verifyTypeMovie
+}
+
+// hashVideo computes the hashes of the video file if they're not already
+// known
+func (l *Library) hashVideo(video polochon.Video, log *logrus.Entry) {
+	metadata := video.GetMetadata()
+	if metadata.OSDbHash != "" {
+		return
+	}
+
+	if err := metadata.ComputeHashes(video.GetFile().Path, l.Checksum); err != nil {
+		log.Warnf("failed to hash the video file: %q", err)
+	}
+}
+
+// checkCopiedVideo makes sure that the video file has not been altered while
+// being copied in the library, the copy is removed if it's not intact. Moved
+// and linked files keep their content and are not checked.
+func (l *Library) checkCopiedVideo(video polochon.Video, mode polochon.ImportMode, oldPath string) error {
+	// Nothing to compare with if the file could not be hashed before being
+	// copied
+	if mode != polochon.ImportCopy || video.GetMetadata().Size == 0 {
+		return nil
+	}
+
+	// The whole file has already been read to compute the checksum, only the
+	// size and the OSDb hash are checked here
+	file := video.GetFile()
+	status, err := video.GetMetadata().Verify(file.Path, false)
+	if err == nil && status != polochon.IntegrityOK {
+		err = ErrCorruptedFile
+	}
+	if err == nil {
+		return nil
+	}
+
+	// Roll back the import, the source file is left untouched
+	if rmErr := os.Remove(file.Path); rmErr != nil {
+		return rmErr
+	}
+	file.Path = oldPath
+
+	return err
+}
+
+// Verify checks all the video files of the library against their stored
+// size and hashes, the result is stored in the index and the videos failing
+// the verification are returned
+func (l *Library) Verify(log *logrus.Entry) ([]*VerifyResult, error) {
+	results := []*VerifyResult{}
+
+	for id, m := range l.MovieIndex() {
+		status, err := l.verifyMovie(m)
+		if err != nil {
+			log.WithField("imdb_id", id).Warnf("failed to verify movie: %q", err)
+			continue
+		}
+
+		if err := l.movieIndex.SetIntegrity(id, status); err != nil {
+			return nil, err
+		}
+
+		if status == polochon.IntegrityOK {
+			continue
+		}
+
+		results = append(results, &VerifyResult{
+			Type:   verifyTypeMovie,
+			ImdbID: id,
+			Status: status,
+		})
+	}
+
+	for id, show := range l.ShowIDs() {
+		for sNum, season := range show.Seasons {
+			for eNum, e := range season.Episodes {
+				// The files holding several episodes are verified once
+				if !e.IsFirst(eNum) {
+					continue
+				}
+
+				status, err := e.Verify(e.Path, l.Checksum)
+				if err != nil {
+					log.WithFields(logrus.Fields{
+						"imdb_id": id,
+						"season":  sNum,
+						"episode": eNum,
+					}).Warnf("failed to verify episode: %q", err)
+					continue
+				}
+
+				if err := l.showIndex.SetIntegrity(id, sNum, eNum, status); err != nil {
+					return nil, err
+				}
+
+				if status == polochon.IntegrityOK {
+					continue
+				}
+
+				results = append(results, &VerifyResult{
+					Type:    verifyTypeEpisode,
+					ImdbID:  id,
+					Season:  sNum,
+					Episode: eNum,
+					Status:  status,
+				})
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// verifyMovie verifies the files of a movie, the hashes of a movie split in
+// several parts are the ones of its first part, the other parts are checked
+// with their size
+func (l *Library) verifyMovie(m *index.Movie) (polochon.IntegrityStatus, error) {
+	status, err := m.Verify(m.Path, l.Checksum)
+	if err != nil || status != polochon.IntegrityOK {
+		return status, err
+	}
+
+	for _, p := range m.Parts {
+		if p.Path == m.Path {
+			continue
+		}
+
+		vm := polochon.VideoMetadata{Size: p.Size}
+		status, err := vm.Verify(p.Path, false)
+		if err != nil || status != polochon.IntegrityOK {
+			return status, err
+		}
+	}
+
+	return polochon.IntegrityOK, nil
+}
@@ -19,9 +19,11 @@ type MovieIndex struct {
 // Movie represents a Movie in the index
 type Movie struct {
 	polochon.VideoMetadata
-	Path      string              `json:"-"`
-	Title     string              `json:"title"`
-	Subtitles []polochon.Language `json:"subtitles"`
+	Path      string                   `json:"-"`
+	Title     string                   `json:"title"`
+	Subtitles []polochon.Language      `json:"subtitles"`
+	Integrity polochon.IntegrityStatus `json:"integrity,omitempty"`
+	Parts     []polochon.MoviePart     `json:"parts,omitempty"`
 }
 
 // NewMovieIndex returns a new movie index
@@ -62,6 +64,7 @@ func (mi *MovieIndex) Add(movie *polochon.Movie) error {
 		Path:          movie.Path,
 		Title:         movie.Title,
 		VideoMetadata: movie.VideoMetadata,
+		Parts:         movie.Parts,
 	}
 
 	return nil
@@ -89,6 +92,20 @@ func (mi *MovieIndex) AddSubtitle(movie *polochon.Movie, lang polochon.Language)
 	return nil
 }
 
+// SetIntegrity sets the integrity status of an indexed movie
+func (mi *MovieIndex) SetIntegrity(imdbID string, status polochon.IntegrityStatus) error {
+	mi.Lock()
+	defer mi.Unlock()
+
+	movie, ok := mi.ids[imdbID]
+	if !ok {
+		return ErrNotFound
+	}
+
+	movie.Integrity = status
+	return nil
+}
+
 // Remove will delete the movie from the index
 func (mi *MovieIndex) Remove(m *polochon.Movie, log *logrus.Entry) error {
 	if _, err := mi.Movie(m.ImdbID); err != nil {
@@ -115,7 +132,27 @@ func (mi *MovieIndex) Index() map[string]*Movie {
 	mi.RLock()
 	defer mi.RUnlock()
 
-	return mi.ids
+	// The movies are copied so that they can be read while the index is
+	// updated
+	ids := make(map[string]*Movie, len(mi.ids))
+	for id, m := range mi.ids {
+		ids[id] = m.copy()
+	}
+
+	return ids
+}
+
+// copy returns a copy of the indexed movie
+func (m *Movie) copy() *Movie {
+	c := *m
+	if m.Subtitles != nil {
+		c.Subtitles = append([]polochon.Language{}, m.Subtitles...)
+	}
+	if m.Parts != nil {
+		c.Parts = append([]polochon.MoviePart{}, m.Parts...)
+	}
+
+	return &c
 }
 
 // Has searches the movie index for an ImdbID and returns true if the movie is
@@ -230,3 +230,24 @@ func TestMovieIndexAddSubtitles(t *testing.T) {
 		t.Fatalf("the movie subtitle %q should be in the index", m.ImdbID)
 	}
 }
+
+func TestMovieIndexSetIntegrity(t *testing.T) {
+	idx := mockMovieIndex()
+
+	if err := idx.SetIntegrity("tt1234", polochon.IntegrityMissing); err != ErrNotFound {
+		t.Fatalf("expected %q, got %q", ErrNotFound, err)
+	}
+
+	if err := idx.SetIntegrity("tt12345", polochon.IntegrityTruncated); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m, err := idx.Movie("tt12345")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if m.Integrity != polochon.IntegrityTruncated {
+		t.Errorf("expected %q, got %q", polochon.IntegrityTruncated, m.Integrity)
+	}
+}
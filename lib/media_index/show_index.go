@@ -30,11 +30,21 @@ type Season struct {
 	Episodes map[int]*Episode `json:"episodes"`
 }
 
-// Episode represents an indexed episode
+// Episode represents an indexed episode, a file holding several episodes is
+// indexed once for each of them
 type Episode struct {
 	polochon.VideoMetadata
-	Path      string              `json:"-"`
-	Subtitles []polochon.Language `json:"subtitles"`
+	Path      string                   `json:"-"`
+	Subtitles []polochon.Language      `json:"subtitles"`
+	Integrity polochon.IntegrityStatus `json:"integrity,omitempty"`
+	// Episodes holds the episodes of a file holding several episodes
+	Episodes []int `json:"episodes,omitempty"`
+}
+
+// IsFirst returns true if the episode number is the first one of the file,
+// it's used to go through the files of the index only once
+func (e *Episode) IsFirst(eNum int) bool {
+	return len(e.Episodes) == 0 || e.Episodes[0] == eNum
 }
 
 // SeasonList returns the season numbers of the indexed show
@@ -60,7 +70,44 @@ func (si *ShowIndex) Clear() {
 func (si *ShowIndex) Index() map[string]*Show {
 	si.RLock()
 	defer si.RUnlock()
-	return si.shows
+
+	// The shows are copied so that they can be read while the index is
+	// updated
+	shows := make(map[string]*Show, len(si.shows))
+	for id, show := range si.shows {
+		shows[id] = show.copy()
+	}
+
+	return shows
+}
+
+// copy returns a copy of the indexed show with its seasons and episodes
+func (si *Show) copy() *Show {
+	c := *si
+	if si.Seasons == nil {
+		return &c
+	}
+
+	c.Seasons = make(map[int]*Season, len(si.Seasons))
+	for sNum, season := range si.Seasons {
+		sc := *season
+		if season.Episodes != nil {
+			sc.Episodes = make(map[int]*Episode, len(season.Episodes))
+			for eNum, e := range season.Episodes {
+				ec := *e
+				if e.Subtitles != nil {
+					ec.Subtitles = append([]polochon.Language{}, e.Subtitles...)
+				}
+				if e.Episodes != nil {
+					ec.Episodes = append([]int{}, e.Episodes...)
+				}
+				sc.Episodes[eNum] = &ec
+			}
+		}
+		c.Seasons[sNum] = &sc
+	}
+
+	return &c
 }
 
 // HasShow returns true if the show is already in the index
@@ -141,6 +188,20 @@ func (si *ShowIndex) Episode(imdbID string, sNum, eNum int) (*Episode, error) {
 	return episode, nil
 }
 
+// SetIntegrity sets the integrity status of an indexed episode
+func (si *ShowIndex) SetIntegrity(imdbID string, sNum, eNum int, status polochon.IntegrityStatus) error {
+	episode, err := si.Episode(imdbID, sNum, eNum)
+	if err != nil {
+		return err
+	}
+
+	si.Lock()
+	defer si.Unlock()
+
+	episode.Integrity = status
+	return nil
+}
+
 // IndexedSeason returns the indexed season from the index
 func (si *ShowIndex) IndexedSeason(imdbID string, sNum int) (*Season, error) {
 	si.RLock()
@@ -229,12 +290,21 @@ func (si *ShowIndex) Add(episode *polochon.ShowEpisode) error {
 		si.Unlock()
 	}
 
-	// Add the episode
-	si.Lock()
-	si.shows[episode.ShowImdbID].Seasons[episode.Season].Episodes[episode.Episode] = &Episode{
+	e := &Episode{
 		Path:          episode.Path,
 		VideoMetadata: episode.VideoMetadata,
 	}
+
+	episodes := episode.Episodes()
+	if len(episodes) > 1 {
+		e.Episodes = episodes
+	}
+
+	// Add the episode, each episode of the file points to the same entry
+	si.Lock()
+	for _, eNum := range episodes {
+		si.shows[episode.ShowImdbID].Seasons[episode.Season].Episodes[eNum] = e
+	}
 	si.Unlock()
 
 	return nil
@@ -310,10 +380,12 @@ func (si *ShowIndex) RemoveEpisode(episode *polochon.ShowEpisode, log *logrus.En
 		return err
 	}
 
-	// Delete the episode from the index
+	// Delete the episodes of the file from the index
 	si.Lock()
 	defer si.Unlock()
-	delete(si.shows[id].Seasons[sNum].Episodes, eNum)
+	for _, e := range episode.Episodes() {
+		delete(si.shows[id].Seasons[sNum].Episodes, e)
+	}
 
 	return nil
 }
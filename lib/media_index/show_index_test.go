@@ -362,6 +362,67 @@ func TestShowIndexAdd(t *testing.T) {
 	}
 }
 
+func TestShowIndexMultiEpisode(t *testing.T) {
+	idx := mockShowIndex()
+	e := &polochon.ShowEpisode{
+		File:       polochon.File{Path: "/home/shows/Game Of Thrones/Season 3/s03e01e03.mp4"},
+		ShowImdbID: "tt0944947",
+		Season:     3,
+		Episode:    1,
+		EpisodeEnd: 3,
+	}
+
+	if err := idx.Add(e); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	// All the episodes of the file are in the index
+	for eNum := 1; eNum <= 3; eNum++ {
+		indexed, err := idx.Episode("tt0944947", 3, eNum)
+		if err != nil {
+			t.Fatalf("expected the episode %d in the index, got %q", eNum, err)
+		}
+
+		if indexed.Path != e.Path {
+			t.Errorf("expected the path %q, got %q", e.Path, indexed.Path)
+		}
+
+		if !reflect.DeepEqual(indexed.Episodes, []int{1, 2, 3}) {
+			t.Errorf("expected the episodes of the file, got %v", indexed.Episodes)
+		}
+
+		if indexed.IsFirst(eNum) != (eNum == 1) {
+			t.Errorf("expected only the first episode to be the first of the file, got %d", eNum)
+		}
+	}
+
+	// The subtitles are shared by the episodes of the file
+	if err := idx.AddSubtitle(e, polochon.FR); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	has, err := idx.HasEpisodeSubtitle("tt0944947", 3, 2, polochon.FR)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if !has {
+		t.Error("expected the subtitle to be shared by the episodes of the file")
+	}
+
+	// All the episodes are removed with the file
+	if err := idx.RemoveEpisode(e, mockLogEntry); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	empty, err := idx.IsSeasonEmpty("tt0944947", 3)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+	if !empty {
+		t.Error("expected the season to be empty")
+	}
+}
+
 func TestEmptyShowIndex(t *testing.T) {
 	idx := NewShowIndex()
 	expected := map[string]*Show{}
@@ -0,0 +1,136 @@
+package mediainfo
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+// Custom errors
+var (
+	ErrUnknownFormat = errors.New("mediainfo: unknown file format")
+	ErrInvalidFile   = errors.New("mediainfo: invalid file")
+)
+
+// Containers
+const (
+	ContainerMKV = "mkv"
+	ContainerMP4 = "mp4"
+)
+
+// Info represents the streams found in the headers of a video file
+type Info struct {
+	Container  string
+	Width      int
+	Height     int
+	Duration   time.Duration
+	VideoCodec string
+	Audio      []polochon.AudioTrack
+	Subtitles  []polochon.SubtitleTrack
+}
+
+// Parse reads the headers of a MKV or MP4 file
+func Parse(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseReader(f)
+}
+
+// ParseReader reads the headers of a MKV or MP4 stream
+func ParseReader(r io.ReadSeeker) (*Info, error) {
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, ErrUnknownFormat
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case bytes.Equal(magic[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		return parseMKV(r)
+	case bytes.Equal(magic[4:], []byte("ftyp")):
+		return parseMP4(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// Update updates the video metadata with the infos read from the file, the
+// values guessed from the file name are only replaced when the file gives a
+// better answer
+func (i *Info) Update(m *polochon.VideoMetadata) {
+	m.Container = i.Container
+	m.Width = i.Width
+	m.Height = i.Height
+	m.Duration = int(i.Duration / time.Second)
+	m.AudioTracks = i.Audio
+	m.EmbeddedSubtitles = i.Subtitles
+
+	if i.VideoCodec != "" {
+		m.VideoCodec = guessitCodec(i.VideoCodec)
+	}
+
+	if len(i.Audio) > 0 && i.Audio[0].Codec != "" {
+		m.AudioCodec = guessitCodec(i.Audio[0].Codec)
+	}
+}
+
+// guessitCodecs holds the names given by the guesser to the codecs read from
+// the headers
+var guessitCodecs = map[string]string{
+	"h264":   "H.264",
+	"h265":   "H.265",
+	"mpeg2":  "MPEG-2",
+	"vp8":    "VP8",
+	"vp9":    "VP9",
+	"av1":    "AV1",
+	"aac":    "AAC",
+	"ac3":    "Dolby Digital",
+	"eac3":   "Dolby Digital Plus",
+	"dts":    "DTS",
+	"truehd": "Dolby TrueHD",
+	"flac":   "FLAC",
+	"opus":   "Opus",
+	"vorbis": "Vorbis",
+	"mp3":    "MP3",
+}
+
+// guessitCodec returns the name of a codec as given by the guesser, the video
+// codec of the metadata is either guessed from the file name or read from the
+// headers and both must use the same names
+func guessitCodec(codec string) string {
+	if name, ok := guessitCodecs[codec]; ok {
+		return name
+	}
+
+	return codec
+}
+
+// channelLayout returns the usual name of a channel layout
+func channelLayout(channels int) string {
+	switch channels {
+	case 0:
+		return ""
+	case 1:
+		return "1.0"
+	case 2:
+		return "2.0"
+	case 6:
+		return "5.1"
+	case 8:
+		return "7.1"
+	default:
+		return strconv.Itoa(channels)
+	}
+}
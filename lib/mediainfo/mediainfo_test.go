@@ -0,0 +1,238 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+// ebml builds an EBML element with a 8 bytes size
+func ebml(id uint64, children ...[]byte) []byte {
+	idBytes := []byte{}
+	for id > 0 {
+		idBytes = append([]byte{byte(id)}, idBytes...)
+		id >>= 8
+	}
+
+	data := bytes.Join(children, nil)
+	size := make([]byte, 8)
+	binary.BigEndian.PutUint64(size, uint64(len(data)))
+	size[0] = 0x01
+
+	return bytes.Join([][]byte{idBytes, size, data}, nil)
+}
+
+func ebmlUint(id uint64, v uint64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, v)
+	return ebml(id, data)
+}
+
+func ebmlFloat(id uint64, v float64) []byte {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, math.Float64bits(v))
+	return ebml(id, data)
+}
+
+func ebmlString(id uint64, v string) []byte {
+	return ebml(id, []byte(v))
+}
+
+// mp4Box builds an ISO BMFF box
+func mp4Box(kind string, children ...[]byte) []byte {
+	data := bytes.Join(children, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)+8))
+	copy(header[4:], kind)
+	return append(header, data...)
+}
+
+func mp4Track(handler, codec, lang string, entry []byte) []byte {
+	mdhd := make([]byte, 24)
+	packed := uint16(lang[0]-0x60)<<10 | uint16(lang[1]-0x60)<<5 | uint16(lang[2]-0x60)
+	binary.BigEndian.PutUint16(mdhd[20:], packed)
+
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:], handler)
+
+	stsd := make([]byte, 8)
+	binary.BigEndian.PutUint32(stsd[4:], 1)
+
+	return mp4Box("trak",
+		mp4Box("tkhd", make([]byte, 84)),
+		mp4Box("mdia",
+			mp4Box("mdhd", mdhd),
+			mp4Box("hdlr", hdlr),
+			mp4Box("minf",
+				mp4Box("stbl",
+					mp4Box("stsd", stsd, mp4Box(codec, entry)),
+				),
+			),
+		),
+	)
+}
+
+func TestParseMKV(t *testing.T) {
+	file := bytes.Join([][]byte{
+		ebml(idEBML, ebmlString(0x4282, "matroska")),
+		ebml(idSegment,
+			ebml(0x114D9B74, []byte("seek head")),
+			ebml(idInfo,
+				ebmlUint(idTimecodeScale, 1000000),
+				ebmlFloat(idDuration, 5400000),
+			),
+			ebml(idTracks,
+				ebml(idTrackEntry,
+					ebmlUint(idTrackType, mkvTrackVideo),
+					ebmlString(idCodecID, "V_MPEGH/ISO/HEVC"),
+					ebml(idVideo,
+						ebmlUint(idPixelWidth, 1920),
+						ebmlUint(idPixelHeight, 800),
+					),
+				),
+				ebml(idTrackEntry,
+					ebmlUint(idTrackType, mkvTrackAudio),
+					ebmlString(idCodecID, "A_DTS/MA"),
+					ebmlString(idLanguage, "fre"),
+					ebml(idAudio, ebmlUint(idChannels, 6)),
+				),
+				ebml(idTrackEntry,
+					ebmlUint(idTrackType, mkvTrackAudio),
+					ebmlString(idCodecID, "A_AC3"),
+					ebml(idAudio, ebmlUint(idChannels, 2)),
+				),
+				ebml(idTrackEntry,
+					ebmlUint(idTrackType, mkvTrackSubtitle),
+					ebmlString(idCodecID, "S_TEXT/UTF8"),
+					ebmlString(idLanguage, "fre"),
+					ebmlUint(idFlagForced, 1),
+				),
+				ebml(idTrackEntry,
+					ebmlUint(idTrackType, mkvTrackSubtitle),
+					ebmlString(idCodecID, "S_HDMV/PGS"),
+					ebmlString(idLanguage, "ger"),
+				),
+			),
+			ebml(idCluster, []byte("video data")),
+		),
+	}, nil)
+
+	got, err := ParseReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := &Info{
+		Container:  ContainerMKV,
+		Width:      1920,
+		Height:     800,
+		Duration:   90 * time.Minute,
+		VideoCodec: "h265",
+		Audio: []polochon.AudioTrack{
+			{Codec: "dts", Language: polochon.FR, Channels: "5.1"},
+			{Codec: "ac3", Language: polochon.EN, Channels: "2.0"},
+		},
+		Subtitles: []polochon.SubtitleTrack{
+			{Codec: "srt", Language: polochon.FR, Forced: true},
+			{Codec: "pgs", Language: polochon.Language("ger")},
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestParseMP4(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 2700000)
+
+	video := make([]byte, 78)
+	binary.BigEndian.PutUint16(video[24:], 1280)
+	binary.BigEndian.PutUint16(video[26:], 720)
+
+	audio := make([]byte, 28)
+	binary.BigEndian.PutUint16(audio[16:], 2)
+
+	file := bytes.Join([][]byte{
+		mp4Box("ftyp", []byte("isom")),
+		mp4Box("mdat", []byte("video data")),
+		mp4Box("moov",
+			mp4Box("mvhd", mvhd),
+			mp4Track("vide", "avc1", "und", video),
+			mp4Track("soun", "mp4a", "eng", audio),
+			mp4Track("sbtl", "tx3g", "fra", nil),
+		),
+	}, nil)
+
+	got, err := ParseReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := &Info{
+		Container:  ContainerMP4,
+		Width:      1280,
+		Height:     720,
+		Duration:   45 * time.Minute,
+		VideoCodec: "h264",
+		Audio: []polochon.AudioTrack{
+			{Codec: "aac", Language: polochon.EN, Channels: "2.0"},
+		},
+		Subtitles: []polochon.SubtitleTrack{
+			{Codec: "tx3g", Language: polochon.FR},
+		},
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
+
+func TestParseUnknownFormat(t *testing.T) {
+	_, err := ParseReader(bytes.NewReader([]byte("this is not a video file")))
+	if err != ErrUnknownFormat {
+		t.Errorf("expected %q, got %q", ErrUnknownFormat, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	m := &polochon.VideoMetadata{
+		AudioCodec: "AAC",
+		VideoCodec: "H.264",
+		Container:  "mkv",
+	}
+
+	info := &Info{
+		Container:  ContainerMKV,
+		Width:      1920,
+		Height:     1080,
+		Duration:   time.Hour,
+		VideoCodec: "h265",
+		Audio: []polochon.AudioTrack{
+			{Codec: "ac3", Language: polochon.EN, Channels: "5.1"},
+		},
+	}
+	info.Update(m)
+
+	// The codecs are named like the guessed ones
+	expected := &polochon.VideoMetadata{
+		AudioCodec:  "Dolby Digital",
+		VideoCodec:  "H.265",
+		Container:   ContainerMKV,
+		Width:       1920,
+		Height:      1080,
+		Duration:    3600,
+		AudioTracks: info.Audio,
+	}
+
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %+v, got %+v", expected, m)
+	}
+}
@@ -0,0 +1,380 @@
+package mediainfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+// EBML element IDs
+const (
+	idEBML          = 0x1A45DFA3
+	idSegment       = 0x18538067
+	idInfo          = 0x1549A966
+	idTimecodeScale = 0x2AD7B1
+	idDuration      = 0x4489
+	idTracks        = 0x1654AE6B
+	idTrackEntry    = 0xAE
+	idTrackType     = 0x83
+	idCodecID       = 0x86
+	idLanguage      = 0x22B59C
+	idFlagForced    = 0x55AA
+	idVideo         = 0xE0
+	idPixelWidth    = 0xB0
+	idPixelHeight   = 0xBA
+	idAudio         = 0xE1
+	idChannels      = 0x9F
+	idCluster       = 0x1F43B675
+)
+
+// Matroska track types
+const (
+	mkvTrackVideo    = 1
+	mkvTrackAudio    = 2
+	mkvTrackSubtitle = 0x11
+)
+
+// maxMasterSize is the maximum size of a master element read in memory
+const maxMasterSize = 16 << 20
+
+// unknownSize is returned for the elements with an unknown size
+const unknownSize = -1
+
+var mkvCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC":  "h264",
+	"V_MPEGH/ISO/HEVC": "h265",
+	"V_MPEG4/ISO/ASP":  "mpeg4",
+	"V_MPEG2":          "mpeg2",
+	"V_VP8":            "vp8",
+	"V_VP9":            "vp9",
+	"V_AV1":            "av1",
+	"A_AAC":            "aac",
+	"A_AC3":            "ac3",
+	"A_EAC3":           "eac3",
+	"A_DTS":            "dts",
+	"A_TRUEHD":         "truehd",
+	"A_FLAC":           "flac",
+	"A_OPUS":           "opus",
+	"A_VORBIS":         "vorbis",
+	"A_MPEG/L3":        "mp3",
+	"S_TEXT/UTF8":      "srt",
+	"S_TEXT/ASS":       "ass",
+	"S_TEXT/SSA":       "ssa",
+	"S_TEXT/WEBVTT":    "webvtt",
+	"S_HDMV/PGS":       "pgs",
+	"S_VOBSUB":         "vobsub",
+}
+
+// mkvCodec returns the short name of a matroska codec ID
+func mkvCodec(id string) string {
+	if c, ok := mkvCodecs[id]; ok {
+		return c
+	}
+
+	// Some codec IDs have a profile suffix, e.g. A_AAC/MPEG4/LC or A_DTS/MA
+	if i := strings.Index(id, "/"); i > 0 {
+		if c, ok := mkvCodecs[id[:i]]; ok {
+			return c
+		}
+	}
+
+	return strings.ToLower(id)
+}
+
+// element represents an EBML element read in memory
+type element struct {
+	id   uint64
+	data []byte
+}
+
+// readVint reads an EBML variable size integer, the length marker is kept
+// for the IDs and removed for the sizes
+func readVint(r io.Reader, keepMarker bool) (uint64, int, error) {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return 0, 0, err
+	}
+
+	length := 1
+	for mask := byte(0x80); length <= 8 && b[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > 8 {
+		return 0, 0, ErrInvalidFile
+	}
+
+	value := uint64(b[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> uint(length))
+	}
+
+	rest := make([]byte, length-1)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return 0, 0, err
+	}
+	for _, c := range rest {
+		value = value<<8 | uint64(c)
+	}
+
+	return value, length, nil
+}
+
+// readElementHeader reads the ID and the size of an element
+func readElementHeader(r io.Reader) (uint64, int64, error) {
+	id, _, err := readVint(r, true)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	size, length, err := readVint(r, false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// All the bits set means the size is unknown
+	if size == uint64(1)<<uint(7*length)-1 {
+		return id, unknownSize, nil
+	}
+
+	return id, int64(size), nil
+}
+
+// readElements reads all the children of a master element
+func readElements(data []byte) ([]element, error) {
+	r := bytes.NewBuffer(data)
+	elements := []element{}
+	for r.Len() > 0 {
+		id, size, err := readElementHeader(r)
+		if err != nil {
+			return nil, ErrInvalidFile
+		}
+
+		if size == unknownSize || size > int64(r.Len()) {
+			size = int64(r.Len())
+		}
+
+		elements = append(elements, element{id: id, data: r.Next(int(size))})
+	}
+
+	return elements, nil
+}
+
+func readUint(data []byte) uint64 {
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v
+}
+
+func readFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	default:
+		return 0
+	}
+}
+
+func readString(data []byte) string {
+	return strings.TrimRight(string(data), "\x00")
+}
+
+// readMaster reads a master element in memory
+func readMaster(r io.Reader, size int64) ([]byte, error) {
+	if size == unknownSize || size > maxMasterSize {
+		return nil, ErrInvalidFile
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, ErrInvalidFile
+	}
+
+	return data, nil
+}
+
+func parseMKV(r io.ReadSeeker) (*Info, error) {
+	id, size, err := readElementHeader(r)
+	if err != nil || id != idEBML {
+		return nil, ErrInvalidFile
+	}
+
+	// Skip the EBML header
+	if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+		return nil, ErrInvalidFile
+	}
+
+	id, _, err = readElementHeader(r)
+	if err != nil || id != idSegment {
+		return nil, ErrInvalidFile
+	}
+
+	info := &Info{Container: ContainerMKV}
+	var gotInfo, gotTracks bool
+	for !gotInfo || !gotTracks {
+		id, size, err := readElementHeader(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch id {
+		case idInfo:
+			data, err := readMaster(r, size)
+			if err != nil {
+				return nil, err
+			}
+			if err := info.parseMKVInfo(data); err != nil {
+				return nil, err
+			}
+			gotInfo = true
+		case idTracks:
+			data, err := readMaster(r, size)
+			if err != nil {
+				return nil, err
+			}
+			if err := info.parseMKVTracks(data); err != nil {
+				return nil, err
+			}
+			gotTracks = true
+		case idCluster:
+			// The tracks are always described before the first cluster,
+			// there is nothing more to read
+			if !gotTracks {
+				return nil, ErrInvalidFile
+			}
+			return info, nil
+		default:
+			if size == unknownSize {
+				return nil, ErrInvalidFile
+			}
+			if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if !gotTracks {
+		return nil, ErrInvalidFile
+	}
+
+	return info, nil
+}
+
+func (i *Info) parseMKVInfo(data []byte) error {
+	elements, err := readElements(data)
+	if err != nil {
+		return err
+	}
+
+	// The default timecode scale is 1ms
+	scale := uint64(1000000)
+	var duration float64
+	for _, e := range elements {
+		switch e.id {
+		case idTimecodeScale:
+			scale = readUint(e.data)
+		case idDuration:
+			duration = readFloat(e.data)
+		}
+	}
+
+	i.Duration = time.Duration(duration * float64(scale))
+	return nil
+}
+
+func (i *Info) parseMKVTracks(data []byte) error {
+	entries, err := readElements(data)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.id != idTrackEntry {
+			continue
+		}
+
+		elements, err := readElements(entry.data)
+		if err != nil {
+			return err
+		}
+
+		var trackType uint64
+		var codec string
+		var forced bool
+		var width, height, channels int
+		// The default language of a matroska track is english
+		lang := "eng"
+		for _, e := range elements {
+			switch e.id {
+			case idTrackType:
+				trackType = readUint(e.data)
+			case idCodecID:
+				codec = mkvCodec(readString(e.data))
+			case idLanguage:
+				lang = readString(e.data)
+			case idFlagForced:
+				forced = readUint(e.data) == 1
+			case idVideo:
+				children, err := readElements(e.data)
+				if err != nil {
+					return err
+				}
+				for _, c := range children {
+					switch c.id {
+					case idPixelWidth:
+						width = int(readUint(c.data))
+					case idPixelHeight:
+						height = int(readUint(c.data))
+					}
+				}
+			case idAudio:
+				children, err := readElements(e.data)
+				if err != nil {
+					return err
+				}
+				// The default number of channels is 1
+				channels = 1
+				for _, c := range children {
+					if c.id == idChannels {
+						channels = int(readUint(c.data))
+					}
+				}
+			}
+		}
+
+		switch trackType {
+		case mkvTrackVideo:
+			// Only the first video track is used
+			if i.VideoCodec == "" {
+				i.VideoCodec = codec
+				i.Width = width
+				i.Height = height
+			}
+		case mkvTrackAudio:
+			i.Audio = append(i.Audio, polochon.AudioTrack{
+				Codec:    codec,
+				Language: polochon.LanguageFromISO6392(lang),
+				Channels: channelLayout(channels),
+			})
+		case mkvTrackSubtitle:
+			i.Subtitles = append(i.Subtitles, polochon.SubtitleTrack{
+				Codec:    codec,
+				Language: polochon.LanguageFromISO6392(lang),
+				Forced:   forced,
+			})
+		}
+	}
+
+	return nil
+}
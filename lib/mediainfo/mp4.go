@@ -0,0 +1,256 @@
+package mediainfo
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+var mp4Codecs = map[string]string{
+	"avc1": "h264",
+	"avc3": "h264",
+	"hvc1": "h265",
+	"hev1": "h265",
+	"av01": "av1",
+	"vp09": "vp9",
+	"mp4v": "mpeg4",
+	"mp4a": "aac",
+	"ac-3": "ac3",
+	"ec-3": "eac3",
+	"Opus": "opus",
+	"fLaC": "flac",
+	"tx3g": "tx3g",
+	"wvtt": "webvtt",
+	"stpp": "ttml",
+	"c608": "cea608",
+}
+
+// box represents an ISO BMFF box read in memory
+type box struct {
+	kind string
+	data []byte
+}
+
+// readBoxHeader reads the type and the size of the payload of a box, -1 is
+// returned as size if the box extends to the end of the file
+func readBoxHeader(r io.Reader) (string, int64, error) {
+	header := make([]byte, 8)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", 0, err
+	}
+
+	kind := string(header[4:])
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	switch size {
+	case 0:
+		return kind, unknownSize, nil
+	case 1:
+		large := make([]byte, 8)
+		if _, err := io.ReadFull(r, large); err != nil {
+			return "", 0, err
+		}
+		size = int64(binary.BigEndian.Uint64(large)) - 16
+	default:
+		size -= 8
+	}
+
+	if size < 0 {
+		return "", 0, ErrInvalidFile
+	}
+
+	return kind, size, nil
+}
+
+// readBoxes reads all the children of a box
+func readBoxes(data []byte) []box {
+	boxes := []box{}
+	for len(data) >= 8 {
+		size := int(binary.BigEndian.Uint32(data[:4]))
+		kind := string(data[4:8])
+		header := 8
+		if size == 1 && len(data) >= 16 {
+			size = int(binary.BigEndian.Uint64(data[8:16]))
+			header = 16
+		}
+		if size == 0 || size > len(data) {
+			size = len(data)
+		}
+		if size < header {
+			break
+		}
+
+		boxes = append(boxes, box{kind: kind, data: data[header:size]})
+		data = data[size:]
+	}
+
+	return boxes
+}
+
+// findBox returns the first child box of the given type
+func findBox(data []byte, kind string) []byte {
+	for _, b := range readBoxes(data) {
+		if b.kind == kind {
+			return b.data
+		}
+	}
+	return nil
+}
+
+func parseMP4(r io.ReadSeeker) (*Info, error) {
+	for {
+		kind, size, err := readBoxHeader(r)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidFile
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if kind == "moov" {
+			data, err := readMaster(r, size)
+			if err != nil {
+				return nil, err
+			}
+			return parseMoov(data), nil
+		}
+
+		// The media data can be located before the movie box, skip it
+		if size == unknownSize {
+			return nil, ErrInvalidFile
+		}
+		if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func parseMoov(data []byte) *Info {
+	info := &Info{Container: ContainerMP4}
+
+	for _, b := range readBoxes(data) {
+		switch b.kind {
+		case "mvhd":
+			info.Duration = parseMvhd(b.data)
+		case "trak":
+			info.parseTrak(b.data)
+		}
+	}
+
+	return info
+}
+
+// parseMvhd returns the duration of the movie
+func parseMvhd(data []byte) time.Duration {
+	if len(data) < 4 {
+		return 0
+	}
+
+	var timescale, duration uint64
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return 0
+		}
+		timescale = uint64(binary.BigEndian.Uint32(data[20:24]))
+		duration = binary.BigEndian.Uint64(data[24:32])
+	} else {
+		if len(data) < 20 {
+			return 0
+		}
+		timescale = uint64(binary.BigEndian.Uint32(data[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(data[16:20]))
+	}
+
+	if timescale == 0 {
+		return 0
+	}
+
+	return time.Duration(duration) * time.Second / time.Duration(timescale)
+}
+
+// parseMdhdLanguage returns the ISO 639-2 language of a media
+func parseMdhdLanguage(data []byte) string {
+	offset := 20
+	if len(data) > 0 && data[0] == 1 {
+		offset = 32
+	}
+	if len(data) < offset+2 {
+		return "und"
+	}
+
+	packed := binary.BigEndian.Uint16(data[offset : offset+2])
+	if packed == 0 {
+		return "und"
+	}
+
+	// The language is packed as three 5 bits characters
+	return string([]byte{
+		byte(packed>>10&0x1F) + 0x60,
+		byte(packed>>5&0x1F) + 0x60,
+		byte(packed&0x1F) + 0x60,
+	})
+}
+
+func (i *Info) parseTrak(data []byte) {
+	mdia := findBox(data, "mdia")
+	if mdia == nil {
+		return
+	}
+
+	var handler, lang string
+	for _, b := range readBoxes(mdia) {
+		switch b.kind {
+		case "mdhd":
+			lang = parseMdhdLanguage(b.data)
+		case "hdlr":
+			if len(b.data) >= 12 {
+				handler = string(b.data[8:12])
+			}
+		}
+	}
+
+	stsd := findBox(findBox(findBox(mdia, "minf"), "stbl"), "stsd")
+	if len(stsd) < 8 {
+		return
+	}
+
+	// Skip the version, the flags and the entry count
+	entries := readBoxes(stsd[8:])
+	if len(entries) == 0 {
+		return
+	}
+	entry := entries[0]
+
+	codec, ok := mp4Codecs[entry.kind]
+	if !ok {
+		codec = entry.kind
+	}
+
+	switch handler {
+	case "vide":
+		if i.VideoCodec != "" {
+			return
+		}
+		i.VideoCodec = codec
+		if len(entry.data) >= 28 {
+			i.Width = int(binary.BigEndian.Uint16(entry.data[24:26]))
+			i.Height = int(binary.BigEndian.Uint16(entry.data[26:28]))
+		}
+	case "soun":
+		var channels int
+		if len(entry.data) >= 18 {
+			channels = int(binary.BigEndian.Uint16(entry.data[16:18]))
+		}
+		i.Audio = append(i.Audio, polochon.AudioTrack{
+			Codec:    codec,
+			Language: polochon.LanguageFromISO6392(lang),
+			Channels: channelLayout(channels),
+		})
+	case "sbtl", "subt", "text":
+		i.Subtitles = append(i.Subtitles, polochon.SubtitleTrack{
+			Codec:    codec,
+			Language: polochon.LanguageFromISO6392(lang),
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package polochon
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
 // MovieConfig represents the configuration for a movie
 type MovieConfig struct {
 	Torrenters []Torrenter
@@ -7,6 +14,9 @@ type MovieConfig struct {
 	Subtitlers []Subtitler
 	Explorers  []Explorer
 	Searchers  []Searcher
+	// DetailersMerge runs all the detailers and merges their results
+	// instead of stopping at the first one succeeding
+	DetailersMerge bool
 }
 
 // Movie represents a movie
@@ -30,6 +40,63 @@ type Movie struct {
 	Year          int       `json:"year"`
 	Genres        []string  `json:"genres"`
 	Torrents      []Torrent `json:"torrents"`
+	// Parts holds the ordered files of a movie split in several parts, the
+	// file of the movie is the first part
+	Parts []MoviePart `json:"parts,omitempty"`
+	// DetailSources holds the detailer which supplied each field when the
+	// details are merged
+	DetailSources map[string]string `json:"detail_sources,omitempty"`
+}
+
+// MoviePart represents a file of a movie split in several parts
+type MoviePart struct {
+	Number int    `json:"number"`
+	Path   string `json:"-"`
+	Size   int64  `json:"size"`
+}
+
+// partRegexp matches the part at the end of a file name such as "Movie.CD1"
+var partRegexp = regexp.MustCompile(`(?i)[ ._-]+(?:cd|dvd|disc|disk|part|pt)[ ._-]?(\d{1,2})$`)
+
+// SplitPart returns the path of a movie file without its extension and its
+// part, and the part number, it's 0 if the file is not a movie part
+func SplitPart(path string) (string, int) {
+	base := removeExt(path)
+
+	m := partRegexp.FindStringSubmatchIndex(base)
+	if m == nil {
+		return base, 0
+	}
+
+	n, err := strconv.Atoi(base[m[2]:m[3]])
+	if err != nil || n == 0 {
+		return base, 0
+	}
+
+	return base[:m[0]], n
+}
+
+// FilePart returns the part number of the movie file, it's 0 if the file is
+// not a movie part. The file of a movie whose title ends with a part such as
+// "Movie: Part 2" is not a movie part
+func (m *Movie) FilePart() int {
+	_, part := SplitPart(m.Path)
+	if part == 0 {
+		return 0
+	}
+
+	for _, title := range []string{m.Title, m.OriginalTitle} {
+		sm := partRegexp.FindStringSubmatch(title)
+		if sm == nil {
+			continue
+		}
+
+		if n, _ := strconv.Atoi(sm[1]); n == part {
+			return 0
+		}
+	}
+
+	return part
 }
 
 // NewMovie returns a new movie
@@ -81,3 +148,62 @@ func (m *Movie) SetFile(f *File) {
 func (m *Movie) GetFile() *File {
 	return &m.File
 }
+
+// GetMetadata implements the video interface
+func (m *Movie) GetMetadata() *VideoMetadata {
+	return &m.VideoMetadata
+}
+
+// Part returns the part of the movie, nil if the movie does not have it
+func (m *Movie) Part(n int) *MoviePart {
+	for i := range m.Parts {
+		if m.Parts[i].Number == n {
+			return &m.Parts[i]
+		}
+	}
+
+	return nil
+}
+
+// AddPart adds a part to the movie, the parts are kept ordered and the file
+// of the movie is the first part
+func (m *Movie) AddPart(part MoviePart) {
+	if p := m.Part(part.Number); p != nil {
+		*p = part
+	} else {
+		m.Parts = append(m.Parts, part)
+	}
+
+	sort.Slice(m.Parts, func(i, j int) bool {
+		return m.Parts[i].Number < m.Parts[j].Number
+	})
+
+	m.Path = m.Parts[0].Path
+}
+
+// pathWithoutPart returns the path of the movie without its extension, the
+// part is removed from the name of a movie split in several parts so that
+// its NFO, subtitles and fanart are shared by all the parts
+func (m *Movie) pathWithoutPart() string {
+	if len(m.Parts) == 0 {
+		return m.PathWithoutExt()
+	}
+
+	base, _ := SplitPart(m.Path)
+	return base
+}
+
+// NfoPath returns the path of the movie NFO
+func (m *Movie) NfoPath() string {
+	return m.pathWithoutPart() + ".nfo"
+}
+
+// SubtitlePath returns the path of the movie subtitle
+func (m *Movie) SubtitlePath(lang Language) string {
+	return fmt.Sprintf("%s.%s.srt", m.pathWithoutPart(), lang.ShortForm())
+}
+
+// MovieFanartPath returns the movie fanart path
+func (m *Movie) MovieFanartPath() string {
+	return m.pathWithoutPart() + "-fanart.jpg"
+}
@@ -0,0 +1,85 @@
+package polochon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitPart(t *testing.T) {
+	for path, expected := range map[string]struct {
+		base string
+		part int
+	}{
+		"/movies/Movie.2010.mkv":                  {"/movies/Movie.2010", 0},
+		"/movies/Movie.2010.CD1.mkv":              {"/movies/Movie.2010", 1},
+		"/movies/Movie.2010-cd2.avi":              {"/movies/Movie.2010", 2},
+		"/movies/Movie 2010 Part 3.mkv":           {"/movies/Movie 2010", 3},
+		"/movies/Movie.2010.disc02.mkv":           {"/movies/Movie.2010", 2},
+		"/movies/Movie.Part.2.2011.720p.mkv":      {"/movies/Movie.Part.2.2011.720p", 0},
+		"/movies/Movie.2010.part0.mkv":            {"/movies/Movie.2010.part0", 0},
+		"/movies/Movie.2010.720p.BluRay.x264.mkv": {"/movies/Movie.2010.720p.BluRay.x264", 0},
+	} {
+		base, part := SplitPart(path)
+		if base != expected.base || part != expected.part {
+			t.Errorf("%s: expected %q %d, got %q %d", path, expected.base, expected.part, base, part)
+		}
+	}
+}
+
+func TestMovieFilePart(t *testing.T) {
+	for _, tc := range []struct {
+		path     string
+		title    string
+		expected int
+	}{
+		{path: "/movies/Movie.2010.mkv", title: "Movie", expected: 0},
+		{path: "/movies/Movie.2010.CD2.mkv", title: "Movie", expected: 2},
+		{path: "/movies/Movie Part 2.mkv", title: "Movie", expected: 2},
+		{path: "/movies/Movie.Part.2.mkv", title: "Movie: Part 2", expected: 0},
+		{path: "/movies/Movie Part 2.mkv", title: "Movie Part 2", expected: 0},
+		{path: "/movies/Movie.Part.2.CD1.mkv", title: "Movie: Part 2", expected: 1},
+	} {
+		m := &Movie{File: File{Path: tc.path}, Title: tc.title}
+		if got := m.FilePart(); got != tc.expected {
+			t.Errorf("%s: expected part %d, got %d", tc.path, tc.expected, got)
+		}
+	}
+}
+
+func TestMovieParts(t *testing.T) {
+	m := &Movie{File: File{Path: "/movies/Movie (2010)/Movie.2010.CD2.mkv"}}
+	if got := m.NfoPath(); got != "/movies/Movie (2010)/Movie.2010.CD2.nfo" {
+		t.Errorf("expected the NFO of the file without parts, got %q", got)
+	}
+
+	m.AddPart(MoviePart{Number: 2, Path: "/movies/Movie (2010)/Movie.2010.CD2.mkv", Size: 20})
+	m.AddPart(MoviePart{Number: 1, Path: "/movies/Movie (2010)/Movie.2010.CD1.mkv", Size: 10})
+
+	expected := []MoviePart{
+		{Number: 1, Path: "/movies/Movie (2010)/Movie.2010.CD1.mkv", Size: 10},
+		{Number: 2, Path: "/movies/Movie (2010)/Movie.2010.CD2.mkv", Size: 20},
+	}
+	if !reflect.DeepEqual(m.Parts, expected) {
+		t.Errorf("expected the ordered parts %+v, got %+v", expected, m.Parts)
+	}
+
+	if m.Path != expected[0].Path {
+		t.Errorf("expected the movie file to be the first part, got %q", m.Path)
+	}
+
+	if m.Part(3) != nil {
+		t.Error("expected no third part")
+	}
+
+	for got, expected := range map[string]string{
+		m.NfoPath():             "/movies/Movie (2010)/Movie.2010.nfo",
+		m.MovieFanartPath():     "/movies/Movie (2010)/Movie.2010-fanart.jpg",
+		m.SubtitlePath(FR):      "/movies/Movie (2010)/Movie.2010.fr.srt",
+		m.File.NfoPath():        "/movies/Movie (2010)/Movie.2010.CD1.nfo",
+		m.File.MovieThumbPath(): "/movies/Movie (2010)/poster.jpg",
+	} {
+		if got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package naming
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+// Default templates
+const (
+	DefaultMovieDir  = `{{.Title}}{{if .Year}} ({{.Year}}){{end}}`
+	DefaultShowDir   = `{{.ShowTitle}}`
+	DefaultSeasonDir = `Season {{.Season}}`
+)
+
+// Templates holds the naming templates of the library, the movie templates
+// are executed with the movie and the show templates with the episode. The
+// files keep the name they are imported with if their template is empty
+type Templates struct {
+	MovieDir    string `yaml:"movie_dir"`
+	MovieFile   string `yaml:"movie_file"`
+	ShowDir     string `yaml:"show_dir"`
+	SeasonDir   string `yaml:"season_dir"`
+	EpisodeFile string `yaml:"episode_file"`
+}
+
+// Namer names the directories and the files of the library
+type Namer struct {
+	movieDir    *template.Template
+	movieFile   *template.Template
+	showDir     *template.Template
+	seasonDir   *template.Template
+	episodeFile *template.Template
+}
+
+// New returns a new namer from the templates, the templates are checked
+// against an empty movie and an empty episode
+func New(t Templates) (*Namer, error) {
+	n := &Namer{}
+	for _, tpl := range []struct {
+		name     string
+		text     string
+		fallback string
+		dest     **template.Template
+		data     interface{}
+	}{
+		{name: "movie_dir", text: t.MovieDir, fallback: DefaultMovieDir, dest: &n.movieDir, data: &polochon.Movie{}},
+		{name: "movie_file", text: t.MovieFile, dest: &n.movieFile, data: &polochon.Movie{}},
+		{name: "show_dir", text: t.ShowDir, fallback: DefaultShowDir, dest: &n.showDir, data: &polochon.ShowEpisode{}},
+		{name: "season_dir", text: t.SeasonDir, fallback: DefaultSeasonDir, dest: &n.seasonDir, data: &polochon.ShowEpisode{}},
+		{name: "episode_file", text: t.EpisodeFile, dest: &n.episodeFile, data: &polochon.ShowEpisode{}},
+	} {
+		text := tpl.text
+		if text == "" {
+			text = tpl.fallback
+		}
+
+		// The file names are kept without template
+		if text == "" {
+			continue
+		}
+
+		parsed, err := template.New(tpl.name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("naming: invalid %s template: %s", tpl.name, err)
+		}
+
+		if err := parsed.Execute(&bytes.Buffer{}, tpl.data); err != nil {
+			return nil, fmt.Errorf("naming: invalid %s template: %s", tpl.name, err)
+		}
+
+		*tpl.dest = parsed
+	}
+
+	return n, nil
+}
+
+// Default returns the namer using the default templates
+func Default() *Namer {
+	n, err := New(Templates{})
+	if err != nil {
+		panic(err)
+	}
+
+	return n
+}
+
+// execute executes a template and sanitizes its result
+func execute(t *template.Template, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return Sanitize(buf.String()), nil
+}
+
+// MovieDir returns the name of the directory of a movie
+func (n *Namer) MovieDir(m *polochon.Movie) (string, error) {
+	return execute(n.movieDir, m)
+}
+
+// MovieFile returns the name of the file of a movie, the part of the movies
+// split in several parts is added after the name
+func (n *Namer) MovieFile(m *polochon.Movie, part int) (string, error) {
+	if n.movieFile == nil {
+		return filepath.Base(m.Path), nil
+	}
+
+	name, err := execute(n.movieFile, m)
+	if err != nil {
+		return "", err
+	}
+
+	if part > 0 {
+		name = fmt.Sprintf("%s.part%d", name, part)
+	}
+
+	return name + filepath.Ext(m.Path), nil
+}
+
+// ShowDir returns the name of the directory of a show
+func (n *Namer) ShowDir(e *polochon.ShowEpisode) (string, error) {
+	return execute(n.showDir, e)
+}
+
+// SeasonDir returns the name of the directory of a season
+func (n *Namer) SeasonDir(e *polochon.ShowEpisode) (string, error) {
+	return execute(n.seasonDir, e)
+}
+
+// EpisodeFile returns the name of the file of an episode
+func (n *Namer) EpisodeFile(e *polochon.ShowEpisode) (string, error) {
+	if n.episodeFile == nil {
+		return filepath.Base(e.Path), nil
+	}
+
+	name, err := execute(n.episodeFile, e)
+	if err != nil {
+		return "", err
+	}
+
+	return name + filepath.Ext(e.Path), nil
+}
+
+// sanitizer replaces the characters forbidden in the file names of the
+// common filesystems
+var sanitizer = strings.NewReplacer(
+	": ", " - ",
+	":", "-",
+	"/", "-",
+	"\\", "-",
+	"*", "",
+	"?", "",
+	"\"", "",
+	"<", "",
+	">", "",
+	"|", "",
+)
+
+// Sanitize returns a name usable as a file name: the path separators and the
+// forbidden characters are replaced, and the spaces and dots around the name
+// are removed
+func Sanitize(name string) string {
+	name = sanitizer.Replace(name)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, name)
+
+	// Collapse the spaces left by the removed characters
+	name = strings.Join(strings.Fields(name), " ")
+	name = strings.Trim(name, " .")
+
+	if name == "" {
+		return "_"
+	}
+
+	return name
+}
@@ -0,0 +1,126 @@
+package naming
+
+import (
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+func TestSanitize(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Mission: Impossible": "Mission - Impossible",
+		"Face/Off":            "Face-Off",
+		"What?":               "What",
+		"  The  End. ":        "The End",
+		"A \"quoted\" <name>": "A quoted name",
+		"Tab\tand\nnewline":   "Tab and newline",
+		"..":                  "_",
+		"":                    "_",
+	} {
+		if got := Sanitize(name); got != expected {
+			t.Errorf("%q: expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestDefaultNamer(t *testing.T) {
+	n := Default()
+
+	m := &polochon.Movie{Title: "Face/Off", Year: 1997}
+	m.Path = "/downloads/Face.Off.1997.mkv"
+
+	for _, tc := range []struct {
+		name     string
+		f        func() (string, error)
+		expected string
+	}{
+		{name: "movie dir", f: func() (string, error) { return n.MovieDir(m) }, expected: "Face-Off (1997)"},
+		{name: "movie dir without year", f: func() (string, error) { return n.MovieDir(&polochon.Movie{Title: "Movie"}) }, expected: "Movie"},
+		{name: "movie file", f: func() (string, error) { return n.MovieFile(m, 0) }, expected: "Face.Off.1997.mkv"},
+	} {
+		got, err := tc.f()
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %q", tc.name, err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+
+	e := &polochon.ShowEpisode{ShowTitle: "Marvel's Agents of S.H.I.E.L.D.", Season: 2, Episode: 5}
+	e.Path = "/downloads/agents.of.shield.s02e05.mkv"
+
+	for _, tc := range []struct {
+		name     string
+		f        func() (string, error)
+		expected string
+	}{
+		{name: "show dir", f: func() (string, error) { return n.ShowDir(e) }, expected: "Marvel's Agents of S.H.I.E.L.D"},
+		{name: "season dir", f: func() (string, error) { return n.SeasonDir(e) }, expected: "Season 2"},
+		{name: "episode file", f: func() (string, error) { return n.EpisodeFile(e) }, expected: "agents.of.shield.s02e05.mkv"},
+	} {
+		got, err := tc.f()
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %q", tc.name, err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestNamerTemplates(t *testing.T) {
+	n, err := New(Templates{
+		MovieDir:    `{{.Title}} ({{.Year}}) [{{.ImdbID}}]`,
+		MovieFile:   `{{.Title}} ({{.Year}}) {{.Quality}}`,
+		ShowDir:     `{{.ShowTitle}} [{{.ShowImdbID}}]`,
+		SeasonDir:   `S{{printf "%02d" .Season}}`,
+		EpisodeFile: `{{.ShowTitle}} - S{{printf "%02d" .Season}}E{{printf "%02d" .Episode}}{{if .EpisodeEnd}}-E{{printf "%02d" .EpisodeEnd}}{{end}} - {{.Title}}`,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	m := &polochon.Movie{Title: "Mission: Impossible", Year: 1996, ImdbID: "tt0117060"}
+	m.Quality = polochon.Quality1080p
+	m.Path = "/downloads/mission.impossible.cd1.mkv"
+
+	e := &polochon.ShowEpisode{ShowTitle: "The Office", ShowImdbID: "tt0386676", Season: 3, Episode: 1, EpisodeEnd: 2, Title: "Gay Witch Hunt"}
+	e.Path = "/downloads/the.office.us.s03e01e02.mp4"
+
+	for _, tc := range []struct {
+		name     string
+		f        func() (string, error)
+		expected string
+	}{
+		{name: "movie dir", f: func() (string, error) { return n.MovieDir(m) }, expected: "Mission - Impossible (1996) [tt0117060]"},
+		{name: "movie file", f: func() (string, error) { return n.MovieFile(m, 0) }, expected: "Mission - Impossible (1996) 1080p.mkv"},
+		{name: "movie part", f: func() (string, error) { return n.MovieFile(m, 1) }, expected: "Mission - Impossible (1996) 1080p.part1.mkv"},
+		{name: "show dir", f: func() (string, error) { return n.ShowDir(e) }, expected: "The Office [tt0386676]"},
+		{name: "season dir", f: func() (string, error) { return n.SeasonDir(e) }, expected: "S03"},
+		{name: "episode file", f: func() (string, error) { return n.EpisodeFile(e) }, expected: "The Office - S03E01-E02 - Gay Witch Hunt.mp4"},
+	} {
+		got, err := tc.f()
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %q", tc.name, err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestNamerInvalidTemplates(t *testing.T) {
+	for _, tpl := range []Templates{
+		{MovieDir: `{{.Title`},
+		{MovieFile: `{{.Unknown}}`},
+		{EpisodeFile: `{{.Show.Year}}`},
+	} {
+		if _, err := New(tpl); err == nil {
+			t.Errorf("%+v: expected an error", tpl)
+		}
+	}
+}
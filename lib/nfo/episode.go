@@ -20,20 +20,23 @@ func NewEpisode(se *polochon.ShowEpisode) *Episode {
 type episodeFields struct {
 	Metadata Metadata `xml:"polochon"`
 
-	Title         string  `xml:"title"`
-	ShowTitle     string  `xml:"showtitle"`
-	Season        int     `xml:"season"`
-	Episode       int     `xml:"episode"`
-	TvdbID        int     `xml:"uniqueid"`
-	Aired         string  `xml:"aired"`
-	Premiered     string  `xml:"premiered"`
-	Plot          string  `xml:"plot"`
-	Runtime       int     `xml:"runtime"`
-	Thumb         string  `xml:"thumb"`
-	Rating        float32 `xml:"rating"`
-	ShowImdbID    string  `xml:"showimdbid"`
-	ShowTvdbID    int     `xml:"showtvdbid"`
-	EpisodeImdbID string  `xml:"episodeimdbid"`
+	Title         string        `xml:"title"`
+	ShowTitle     string        `xml:"showtitle"`
+	Season        int           `xml:"season"`
+	Episode       int           `xml:"episode"`
+	EpisodeEnd    int           `xml:"episode_end,omitempty"`
+	Absolute      int           `xml:"absolute_number,omitempty"`
+	TvdbID        int           `xml:"uniqueid"`
+	Aired         string        `xml:"aired"`
+	Premiered     string        `xml:"premiered"`
+	Plot          string        `xml:"plot"`
+	Runtime       int           `xml:"runtime"`
+	Thumb         string        `xml:"thumb"`
+	Rating        float32       `xml:"rating"`
+	ShowImdbID    string        `xml:"showimdbid"`
+	ShowTvdbID    int           `xml:"showtvdbid"`
+	EpisodeImdbID string        `xml:"episodeimdbid"`
+	Sources       *sourcesField `xml:"detail_sources,omitempty"`
 }
 
 // MarshalXML implements the XML Marshaler interface
@@ -46,6 +49,8 @@ func (e *Episode) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
 		ShowTitle:     e.ShowTitle,
 		Season:        e.Season,
 		Episode:       e.Episode,
+		EpisodeEnd:    e.EpisodeEnd,
+		Absolute:      e.AbsoluteNumber,
 		TvdbID:        e.TvdbID,
 		Aired:         e.Aired,
 		Premiered:     e.Aired,
@@ -56,6 +61,7 @@ func (e *Episode) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
 		ShowImdbID:    e.ShowImdbID,
 		ShowTvdbID:    e.ShowTvdbID,
 		EpisodeImdbID: e.EpisodeImdbID,
+		Sources:       newSourcesField(e.DetailSources),
 	}
 
 	return enc.EncodeElement(nfo, start)
@@ -73,6 +79,8 @@ func (e *Episode) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	e.ShowTitle = nfo.ShowTitle
 	e.Season = nfo.Season
 	e.Episode = nfo.Episode
+	e.EpisodeEnd = nfo.EpisodeEnd
+	e.AbsoluteNumber = nfo.Absolute
 	e.TvdbID = nfo.TvdbID
 	e.Aired = nfo.Aired
 	e.Plot = nfo.Plot
@@ -82,6 +90,7 @@ func (e *Episode) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	e.ShowImdbID = nfo.ShowImdbID
 	e.ShowTvdbID = nfo.ShowTvdbID
 	e.EpisodeImdbID = nfo.EpisodeImdbID
+	e.DetailSources = nfo.Sources.detailSources()
 
 	return nil
 }
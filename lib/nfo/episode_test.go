@@ -17,6 +17,16 @@ func mockEpisode() *polochon.ShowEpisode {
 		AudioCodec:   "Dolby Digital Plus",
 		VideoCodec:   "H.264",
 		Container:    "mp4",
+		Width:        1280,
+		Height:       720,
+		Duration:     1290,
+		AudioTracks: []polochon.AudioTrack{
+			{Codec: "eac3", Language: polochon.EN, Channels: "5.1"},
+		},
+		EmbeddedSubtitles: []polochon.SubtitleTrack{
+			{Codec: "srt", Language: polochon.FR, Forced: true},
+			{Codec: "srt", Language: polochon.EN},
+		},
 	}
 	s.Title = "Lost in Space"
 	s.ShowTitle = "American Dad!"
@@ -42,6 +52,16 @@ var episodeNFOContent = []byte(`<episodedetails>
     <audio_codec>Dolby Digital Plus</audio_codec>
     <video_codec>H.264</video_codec>
     <container>mp4</container>
+    <width>1280</width>
+    <height>720</height>
+    <duration>1290</duration>
+    <audio_tracks>
+      <track codec="eac3" language="en_US" channels="5.1"></track>
+    </audio_tracks>
+    <embedded_subtitles>
+      <track codec="srt" language="fr_FR" forced="true"></track>
+      <track codec="srt" language="en_US"></track>
+    </embedded_subtitles>
   </polochon>
   <title>Lost in Space</title>
   <showtitle>American Dad!</showtitle>
@@ -92,3 +112,26 @@ func TestEmptyEpisodeReadNFO(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestMultiEpisodeNFO(t *testing.T) {
+	s := mockEpisode()
+	s.EpisodeEnd = 19
+
+	var b bytes.Buffer
+	if err := Write(&b, s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Contains(b.Bytes(), []byte("<episode>18</episode>\n  <episode_end>19</episode_end>")) {
+		t.Errorf("expected the last episode in the NFO, got:\n%s", b.String())
+	}
+
+	got := &polochon.ShowEpisode{}
+	if err := Read(&b, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Episode != 18 || got.EpisodeEnd != 19 {
+		t.Errorf("expected the episodes 18 to 19, got %d to %d", got.Episode, got.EpisodeEnd)
+	}
+}
@@ -19,6 +19,37 @@ type metadataFields struct {
 	AudioCodec   string `xml:"audio_codec"`
 	VideoCodec   string `xml:"video_codec"`
 	Container    string `xml:"container"`
+
+	Width             int                  `xml:"width,omitempty"`
+	Height            int                  `xml:"height,omitempty"`
+	Duration          int                  `xml:"duration,omitempty"`
+	AudioTracks       *audioTracksField    `xml:"audio_tracks,omitempty"`
+	EmbeddedSubtitles *subtitleTracksField `xml:"embedded_subtitles,omitempty"`
+	Size              int64                `xml:"size,omitempty"`
+	OSDbHash          string               `xml:"osdb_hash,omitempty"`
+	SHA256            string               `xml:"sha256,omitempty"`
+}
+
+// The tracks lists are pointers so that the element is not written when the
+// video has no tracks
+type audioTracksField struct {
+	Tracks []audioTrackField `xml:"track"`
+}
+
+type subtitleTracksField struct {
+	Tracks []subtitleTrackField `xml:"track"`
+}
+
+type audioTrackField struct {
+	Codec    string `xml:"codec,attr"`
+	Language string `xml:"language,attr"`
+	Channels string `xml:"channels,attr,omitempty"`
+}
+
+type subtitleTrackField struct {
+	Codec    string `xml:"codec,attr"`
+	Language string `xml:"language,attr"`
+	Forced   bool   `xml:"forced,attr,omitempty"`
 }
 
 // Metadata represents polochon's metadata
@@ -37,12 +68,40 @@ func (m *Metadata) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
 	}
 
 	nfo := &metadataFields{
-		DateAdded:    now().UTC().Format(time.RFC3339),
+		DateAdded:    m.DateAdded.UTC().Format(time.RFC3339),
 		Quality:      string(m.Quality),
 		ReleaseGroup: m.ReleaseGroup,
 		AudioCodec:   m.AudioCodec,
 		VideoCodec:   m.VideoCodec,
 		Container:    m.Container,
+		Width:        m.Width,
+		Height:       m.Height,
+		Duration:     m.Duration,
+		Size:         m.Size,
+		OSDbHash:     m.OSDbHash,
+		SHA256:       m.SHA256,
+	}
+
+	if len(m.AudioTracks) > 0 {
+		nfo.AudioTracks = &audioTracksField{}
+	}
+	for _, a := range m.AudioTracks {
+		nfo.AudioTracks.Tracks = append(nfo.AudioTracks.Tracks, audioTrackField{
+			Codec:    a.Codec,
+			Language: string(a.Language),
+			Channels: a.Channels,
+		})
+	}
+
+	if len(m.EmbeddedSubtitles) > 0 {
+		nfo.EmbeddedSubtitles = &subtitleTracksField{}
+	}
+	for _, s := range m.EmbeddedSubtitles {
+		nfo.EmbeddedSubtitles.Tracks = append(nfo.EmbeddedSubtitles.Tracks, subtitleTrackField{
+			Codec:    s.Codec,
+			Language: string(s.Language),
+			Forced:   s.Forced,
+		})
 	}
 
 	return enc.EncodeElement(nfo, start)
@@ -74,6 +133,34 @@ func (m *Metadata) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	m.AudioCodec = nfo.AudioCodec
 	m.VideoCodec = nfo.VideoCodec
 	m.Container = nfo.Container
+	m.Width = nfo.Width
+	m.Height = nfo.Height
+	m.Duration = nfo.Duration
+	m.Size = nfo.Size
+	m.OSDbHash = nfo.OSDbHash
+	m.SHA256 = nfo.SHA256
+
+	m.AudioTracks = nil
+	if nfo.AudioTracks != nil {
+		for _, a := range nfo.AudioTracks.Tracks {
+			m.AudioTracks = append(m.AudioTracks, polochon.AudioTrack{
+				Codec:    a.Codec,
+				Language: polochon.Language(a.Language),
+				Channels: a.Channels,
+			})
+		}
+	}
+
+	m.EmbeddedSubtitles = nil
+	if nfo.EmbeddedSubtitles != nil {
+		for _, s := range nfo.EmbeddedSubtitles.Tracks {
+			m.EmbeddedSubtitles = append(m.EmbeddedSubtitles, polochon.SubtitleTrack{
+				Codec:    s.Codec,
+				Language: polochon.Language(s.Language),
+				Forced:   s.Forced,
+			})
+		}
+	}
 
 	return nil
 }
@@ -2,6 +2,7 @@ package nfo
 
 import (
 	"encoding/xml"
+	"path/filepath"
 
 	polochon "github.com/odwrtw/polochon/lib"
 )
@@ -18,21 +19,71 @@ func NewMovie(m *polochon.Movie) *Movie {
 
 // movieFields represents the fields in the NFO file
 type movieFields struct {
-	Metadata      Metadata `xml:"polochon"`
-	ImdbID        string   `xml:"id"`
-	OriginalTitle string   `xml:"originaltitle"`
-	Plot          string   `xml:"plot"`
-	Rating        float32  `xml:"rating"`
-	Runtime       int      `xml:"runtime"`
-	SortTitle     string   `xml:"sorttitle"`
-	Tagline       string   `xml:"tagline"`
-	Thumb         string   `xml:"thumb"`
-	Fanart        string   `xml:"customfanart"`
-	Title         string   `xml:"title"`
-	TmdbID        int      `xml:"tmdbid"`
-	Votes         int      `xml:"votes"`
-	Year          int      `xml:"year"`
-	Genres        []string `xml:"genre"`
+	Metadata      Metadata      `xml:"polochon"`
+	ImdbID        string        `xml:"id"`
+	OriginalTitle string        `xml:"originaltitle"`
+	Plot          string        `xml:"plot"`
+	Rating        float32       `xml:"rating"`
+	Runtime       int           `xml:"runtime"`
+	SortTitle     string        `xml:"sorttitle"`
+	Tagline       string        `xml:"tagline"`
+	Thumb         string        `xml:"thumb"`
+	Fanart        string        `xml:"customfanart"`
+	Title         string        `xml:"title"`
+	TmdbID        int           `xml:"tmdbid"`
+	Votes         int           `xml:"votes"`
+	Year          int           `xml:"year"`
+	Genres        []string      `xml:"genre"`
+	Parts         *partsField   `xml:"parts,omitempty"`
+	Sources       *sourcesField `xml:"detail_sources,omitempty"`
+}
+
+// partsField represents the parts of a movie, it's a pointer so that the
+// element is not written when the movie is a single file
+type partsField struct {
+	Parts []partField `xml:"part"`
+}
+
+// partField represents a part of a movie, the file name is relative to the
+// movie directory
+type partField struct {
+	Number int    `xml:"number,attr"`
+	Size   int64  `xml:"size,attr,omitempty"`
+	File   string `xml:",chardata"`
+}
+
+func newPartsField(parts []polochon.MoviePart) *partsField {
+	if len(parts) == 0 {
+		return nil
+	}
+
+	f := &partsField{}
+	for _, p := range parts {
+		f.Parts = append(f.Parts, partField{
+			Number: p.Number,
+			Size:   p.Size,
+			File:   filepath.Base(p.Path),
+		})
+	}
+
+	return f
+}
+
+func (f *partsField) movieParts() []polochon.MoviePart {
+	if f == nil {
+		return nil
+	}
+
+	parts := []polochon.MoviePart{}
+	for _, p := range f.Parts {
+		parts = append(parts, polochon.MoviePart{
+			Number: p.Number,
+			Size:   p.Size,
+			Path:   p.File,
+		})
+	}
+
+	return parts
 }
 
 // MarshalXML implements the XML Marshaler interface
@@ -55,6 +106,8 @@ func (m *Movie) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		Votes:         m.Votes,
 		Year:          m.Year,
 		Genres:        m.Genres,
+		Parts:         newPartsField(m.Parts),
+		Sources:       newSourcesField(m.DetailSources),
 	}
 
 	return e.EncodeElement(nfo, start)
@@ -82,6 +135,8 @@ func (m *Movie) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	m.Votes = nfo.Votes
 	m.Year = nfo.Year
 	m.Genres = nfo.Genres
+	m.Parts = nfo.Parts.movieParts()
+	m.DetailSources = nfo.Sources.detailSources()
 
 	return nil
 }
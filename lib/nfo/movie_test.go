@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"reflect"
 	"testing"
+	"time"
 
 	polochon "github.com/odwrtw/polochon/lib"
 )
@@ -17,6 +18,9 @@ func mockMovie() *polochon.Movie {
 		AudioCodec:   "Dolby Digital Plus",
 		VideoCodec:   "H.264",
 		Container:    "mp4",
+		Size:         1073741824,
+		OSDbHash:     "8e245d9679d31e12",
+		SHA256:       "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
 	}
 	m.ImdbID = "tt2562232"
 	m.OriginalTitle = "Birdman"
@@ -43,6 +47,9 @@ var movieNFOContent = []byte(`<movie>
     <audio_codec>Dolby Digital Plus</audio_codec>
     <video_codec>H.264</video_codec>
     <container>mp4</container>
+    <size>1073741824</size>
+    <osdb_hash>8e245d9679d31e12</osdb_hash>
+    <sha256>e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855</sha256>
   </polochon>
   <id>tt2562232</id>
   <originaltitle>Birdman</originaltitle>
@@ -88,6 +95,29 @@ func TestMovieReadNFO(t *testing.T) {
 	}
 }
 
+func TestMovieNFOKeepsDateAdded(t *testing.T) {
+	added := time.Date(2018, time.January, 2, 3, 4, 5, 0, time.UTC)
+	m := mockMovie()
+	m.DateAdded = added
+
+	// The date is kept when the NFO is written again
+	for i := 0; i < 2; i++ {
+		var b bytes.Buffer
+		if err := Write(&b, m); err != nil {
+			t.Fatal(err)
+		}
+
+		m = &polochon.Movie{}
+		if err := Read(&b, m); err != nil {
+			t.Fatal(err)
+		}
+
+		if !m.DateAdded.Equal(added) {
+			t.Fatalf("write %d: expected the date %s, got %s", i+1, added, m.DateAdded)
+		}
+	}
+}
+
 func TestEmptyMovieReadNFO(t *testing.T) {
 	buf := bytes.NewBuffer([]byte(`<movie></movie>`))
 	got := &polochon.Movie{}
@@ -95,3 +125,38 @@ func TestEmptyMovieReadNFO(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestMoviePartsNFO(t *testing.T) {
+	m := mockMovie()
+	m.Parts = []polochon.MoviePart{
+		{Number: 1, Path: "/movies/Birdman (2014)/Birdman.CD1.mp4", Size: 10},
+		{Number: 2, Path: "/movies/Birdman (2014)/Birdman.CD2.mp4", Size: 20},
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, m); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedParts := []byte(`  <parts>
+    <part number="1" size="10">Birdman.CD1.mp4</part>
+    <part number="2" size="20">Birdman.CD2.mp4</part>
+  </parts>`)
+	if !bytes.Contains(b.Bytes(), expectedParts) {
+		t.Errorf("expected the parts in the NFO, got:\n%s", b.String())
+	}
+
+	got := &polochon.Movie{}
+	if err := Read(&b, got); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file names of the parts are relative to the movie directory
+	expected := []polochon.MoviePart{
+		{Number: 1, Path: "Birdman.CD1.mp4", Size: 10},
+		{Number: 2, Path: "Birdman.CD2.mp4", Size: 20},
+	}
+	if !reflect.DeepEqual(got.Parts, expected) {
+		t.Errorf("expected the parts %+v, got %+v", expected, got.Parts)
+	}
+}
@@ -19,15 +19,16 @@ func NewShow(s *polochon.Show) *Show {
 
 // showFields represents the show fileds in the NFO file
 type showFields struct {
-	Title     string  `xml:"title"`
-	ShowTitle string  `xml:"showtitle"`
-	Rating    float32 `xml:"rating"`
-	Plot      string  `xml:"plot"`
-	URL       string  `xml:"episodeguide>url"`
-	TvdbID    int     `xml:"tvdbid"`
-	ImdbID    string  `xml:"imdbid"`
-	Year      int     `xml:"year"`
-	Premiered string  `xml:"premiered"`
+	Title     string        `xml:"title"`
+	ShowTitle string        `xml:"showtitle"`
+	Rating    float32       `xml:"rating"`
+	Plot      string        `xml:"plot"`
+	URL       string        `xml:"episodeguide>url"`
+	TvdbID    int           `xml:"tvdbid"`
+	ImdbID    string        `xml:"imdbid"`
+	Year      int           `xml:"year"`
+	Premiered string        `xml:"premiered"`
+	Sources   *sourcesField `xml:"detail_sources,omitempty"`
 }
 
 // MarshalXML implements the XML Marshaler interface
@@ -43,6 +44,7 @@ func (s *Show) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 		TvdbID:    s.TvdbID,
 		ImdbID:    s.ImdbID,
 		Year:      s.Year,
+		Sources:   newSourcesField(s.DetailSources),
 	}
 
 	if s.FirstAired != nil {
@@ -66,6 +68,7 @@ func (s *Show) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	s.TvdbID = nfo.TvdbID
 	s.ImdbID = nfo.ImdbID
 	s.Year = nfo.Year
+	s.DetailSources = nfo.Sources.detailSources()
 
 	if nfo.Premiered != "" {
 		firstAired, err := time.Parse("2006-01-02", nfo.Premiered)
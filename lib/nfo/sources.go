@@ -0,0 +1,48 @@
+package nfo
+
+import "sort"
+
+// sourcesField holds the detailer which supplied each field, it's a pointer
+// in the NFO fields so that the element is not written when the details were
+// not merged
+type sourcesField struct {
+	Sources []sourceField `xml:"source"`
+}
+
+type sourceField struct {
+	Field    string `xml:"field,attr"`
+	Detailer string `xml:",chardata"`
+}
+
+// newSourcesField returns the sources field from the detail sources
+func newSourcesField(sources map[string]string) *sourcesField {
+	if len(sources) == 0 {
+		return nil
+	}
+
+	f := &sourcesField{}
+	for field, detailer := range sources {
+		f.Sources = append(f.Sources, sourceField{Field: field, Detailer: detailer})
+	}
+
+	// Sort the sources to always write the same NFO
+	sort.Slice(f.Sources, func(i, j int) bool {
+		return f.Sources[i].Field < f.Sources[j].Field
+	})
+
+	return f
+}
+
+// detailSources returns the detail sources from the sources field
+func (f *sourcesField) detailSources() map[string]string {
+	if f == nil {
+		return nil
+	}
+
+	sources := map[string]string{}
+	for _, s := range f.Sources {
+		sources[s.Field] = s.Detailer
+	}
+
+	return sources
+}
@@ -0,0 +1,40 @@
+package nfo
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+func TestDetailSourcesNFO(t *testing.T) {
+	m := mockMovie()
+	m.DetailSources = map[string]string{
+		"title":  "tmdb",
+		"fanart": "trakttv",
+	}
+
+	var b bytes.Buffer
+	if err := Write(&b, m); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `  <detail_sources>
+    <source field="fanart">trakttv</source>
+    <source field="title">tmdb</source>
+  </detail_sources>`
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("expected the NFO to contain the detail sources, got\n%s", b.String())
+	}
+
+	got := polochon.NewMovie(polochon.MovieConfig{})
+	if err := Read(&b, got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got.DetailSources, m.DetailSources) {
+		t.Errorf("expected detail sources %v, got %v", m.DetailSources, got.DetailSources)
+	}
+}
@@ -9,3 +9,18 @@ type Notifier interface {
 	Module
 	Notify(interface{}, *logrus.Entry) error
 }
+
+// Disk space events sent to the notifiers
+const (
+	DiskSpaceLow       = "low"
+	DiskSpaceRecovered = "recovered"
+)
+
+// DiskSpaceEvent represents a change of the free space of a directory
+// checked by the disk guard, the sizes are in bytes
+type DiskSpaceEvent struct {
+	Event   string `json:"event"`
+	Path    string `json:"path"`
+	Free    uint64 `json:"free"`
+	MinFree uint64 `json:"min_free"`
+}
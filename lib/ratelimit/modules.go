@@ -0,0 +1,111 @@
+package ratelimit
+
+import (
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/sirupsen/logrus"
+)
+
+// Detailer returns a detailer limited by the limiter
+func Detailer(d polochon.Detailer, l *Limiter) polochon.Detailer {
+	return &detailer{Detailer: d, limiter: l}
+}
+
+type detailer struct {
+	polochon.Detailer
+	limiter *Limiter
+}
+
+func (d *detailer) GetDetails(i interface{}, log *logrus.Entry) error {
+	defer d.limiter.Acquire()()
+	return d.Detailer.GetDetails(i, log)
+}
+
+// Torrenter returns a torrenter limited by the limiter
+func Torrenter(t polochon.Torrenter, l *Limiter) polochon.Torrenter {
+	return &torrenter{Torrenter: t, limiter: l}
+}
+
+type torrenter struct {
+	polochon.Torrenter
+	limiter *Limiter
+}
+
+func (t *torrenter) GetTorrents(i interface{}, log *logrus.Entry) error {
+	defer t.limiter.Acquire()()
+	return t.Torrenter.GetTorrents(i, log)
+}
+
+func (t *torrenter) SearchTorrents(s string) ([]*polochon.Torrent, error) {
+	defer t.limiter.Acquire()()
+	return t.Torrenter.SearchTorrents(s)
+}
+
+// Subtitler returns a subtitler limited by the limiter
+func Subtitler(s polochon.Subtitler, l *Limiter) polochon.Subtitler {
+	return &subtitler{Subtitler: s, limiter: l}
+}
+
+type subtitler struct {
+	polochon.Subtitler
+	limiter *Limiter
+}
+
+func (s *subtitler) GetSubtitle(i interface{}, lang polochon.Language, log *logrus.Entry) (polochon.Subtitle, error) {
+	defer s.limiter.Acquire()()
+	return s.Subtitler.GetSubtitle(i, lang, log)
+}
+
+// Calendar returns a calendar limited by the limiter
+func Calendar(c polochon.Calendar, l *Limiter) polochon.Calendar {
+	return &calendar{Calendar: c, limiter: l}
+}
+
+type calendar struct {
+	polochon.Calendar
+	limiter *Limiter
+}
+
+func (c *calendar) GetShowCalendar(s *polochon.Show, log *logrus.Entry) (*polochon.ShowCalendar, error) {
+	defer c.limiter.Acquire()()
+	return c.Calendar.GetShowCalendar(s, log)
+}
+
+// Searcher returns a searcher limited by the limiter
+func Searcher(s polochon.Searcher, l *Limiter) polochon.Searcher {
+	return &searcher{Searcher: s, limiter: l}
+}
+
+type searcher struct {
+	polochon.Searcher
+	limiter *Limiter
+}
+
+func (s *searcher) SearchMovie(key string, log *logrus.Entry) ([]*polochon.Movie, error) {
+	defer s.limiter.Acquire()()
+	return s.Searcher.SearchMovie(key, log)
+}
+
+func (s *searcher) SearchShow(key string, log *logrus.Entry) ([]*polochon.Show, error) {
+	defer s.limiter.Acquire()()
+	return s.Searcher.SearchShow(key, log)
+}
+
+// Explorer returns an explorer limited by the limiter
+func Explorer(e polochon.Explorer, l *Limiter) polochon.Explorer {
+	return &explorer{Explorer: e, limiter: l}
+}
+
+type explorer struct {
+	polochon.Explorer
+	limiter *Limiter
+}
+
+func (e *explorer) GetMovieList(option string, log *logrus.Entry) ([]*polochon.Movie, error) {
+	defer e.limiter.Acquire()()
+	return e.Explorer.GetMovieList(option, log)
+}
+
+func (e *explorer) GetShowList(option string, log *logrus.Entry) ([]*polochon.Show, error) {
+	defer e.limiter.Acquire()()
+	return e.Explorer.GetShowList(option, log)
+}
@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate and the number of concurrent calls to a module. The
+// rate is enforced with a token bucket
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// slots holds a token for each running call, it's nil if the number of
+	// concurrent calls is not limited
+	slots chan struct{}
+	// done is closed to stop waiting for the tokens
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// now and sleep can be replaced in the tests
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// New returns a new limiter allowing rate calls per second with bursts of
+// burst calls and at most concurrency calls at the same time. A rate or a
+// concurrency of 0 means no limit
+func New(rate float64, burst, concurrency int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	l := &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		done:   make(chan struct{}),
+		now:    time.Now,
+	}
+	l.sleep = l.wait
+
+	if concurrency > 0 {
+		l.slots = make(chan struct{}, concurrency)
+	}
+
+	l.last = l.now()
+	return l
+}
+
+// Acquire waits until a call is allowed, the returned function must be called
+// once the call is done
+func (l *Limiter) Acquire() func() {
+	if l.slots != nil {
+		l.slots <- struct{}{}
+	}
+
+	if wait := l.reserve(); wait > 0 {
+		l.sleep(wait)
+	}
+
+	return func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+}
+
+// Close stops the limiter, the calls waiting for a token are let through and
+// the next ones are not rate limited anymore
+func (l *Limiter) Close() {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+}
+
+// wait waits for the given duration or until the limiter is closed
+func (l *Limiter) wait(d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-l.done:
+	}
+}
+
+// reserve takes a token from the bucket and returns the time to wait for it
+// to be available
+func (l *Limiter) reserve() time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+
+	select {
+	case <-l.done:
+		return 0
+	default:
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Refill the bucket
+	now := l.now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// The token might be borrowed from the future, the caller will wait for
+	// it
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
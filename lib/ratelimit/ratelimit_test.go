@@ -0,0 +1,138 @@
+package ratelimit
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock only moving when sleeping
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+func newFakeLimiter(rate float64, burst, concurrency int) (*Limiter, *fakeClock) {
+	clock := &fakeClock{now: time.Now()}
+	l := New(rate, burst, concurrency)
+	l.now = clock.Now
+	l.sleep = clock.Sleep
+	l.last = clock.now
+	return l, clock
+}
+
+func TestLimiterRate(t *testing.T) {
+	l, clock := newFakeLimiter(2, 3, 0)
+
+	// The burst is allowed right away, then a call every half second
+	for i := 0; i < 5; i++ {
+		l.Acquire()()
+	}
+
+	expected := []time.Duration{500 * time.Millisecond, 500 * time.Millisecond}
+	if len(clock.sleeps) != len(expected) {
+		t.Fatalf("expected %d waits, got %v", len(expected), clock.sleeps)
+	}
+
+	for i, d := range expected {
+		if clock.sleeps[i] != d {
+			t.Errorf("expected wait %d to be %s, got %s", i, d, clock.sleeps[i])
+		}
+	}
+
+	// The bucket is refilled after some time
+	clock.now = clock.now.Add(10 * time.Second)
+	clock.sleeps = nil
+	for i := 0; i < 3; i++ {
+		l.Acquire()()
+	}
+
+	if len(clock.sleeps) != 0 {
+		t.Errorf("expected no wait, got %v", clock.sleeps)
+	}
+}
+
+func TestLimiterNoRate(t *testing.T) {
+	l, clock := newFakeLimiter(0, 0, 0)
+	for i := 0; i < 100; i++ {
+		l.Acquire()()
+	}
+
+	if len(clock.sleeps) != 0 {
+		t.Errorf("expected no wait, got %v", clock.sleeps)
+	}
+}
+
+func TestLimiterConcurrency(t *testing.T) {
+	l := New(0, 0, 2)
+
+	var mu sync.Mutex
+	var running, max int
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release := l.Acquire()
+			defer release()
+
+			mu.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if max != 2 {
+		t.Errorf("expected at most 2 concurrent calls, got %d", max)
+	}
+}
+
+func TestLimiterClose(t *testing.T) {
+	l := New(0.001, 1, 0)
+
+	// The first call takes the only token, the second one waits for a long
+	// time
+	l.Acquire()()
+
+	done := make(chan struct{})
+	go func() {
+		l.Acquire()()
+		close(done)
+	}()
+
+	l.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the waiting call to be let through")
+	}
+
+	// The next calls don't wait anymore
+	start := time.Now()
+	l.Acquire()()
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("expected no wait after the limiter is closed")
+	}
+
+	// Closing twice is fine
+	l.Close()
+}
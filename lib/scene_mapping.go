@@ -0,0 +1,167 @@
+package polochon
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSceneEpisodePart is returned when a scene release holds only some of the
+// parts of an episode released in several parts by the scene, the parts would
+// replace each other in the library
+var ErrSceneEpisodePart = errors.New("polochon: the scene release holds only a part of the episode")
+
+// EpisodeNumber represents the season and the episode of an episode
+type EpisodeNumber struct {
+	Season  int
+	Episode int
+}
+
+// String implements the Stringer interface
+func (n EpisodeNumber) String() string {
+	return fmt.Sprintf("S%02dE%02d", n.Season, n.Episode)
+}
+
+// UnmarshalYAML implements the yaml Unmarshaler interface, the numbers are
+// written as "S01E02"
+func (n *EpisodeNumber) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Sscanf(s, "S%dE%d", &n.Season, &n.Episode); err != nil {
+		return fmt.Errorf("polochon: invalid episode number %q", s)
+	}
+
+	return nil
+}
+
+// SceneMappingEpisode maps the number of a scene release to the number of the
+// metadata providers
+type SceneMappingEpisode struct {
+	Scene    EpisodeNumber `yaml:"scene"`
+	Metadata EpisodeNumber `yaml:"metadata"`
+}
+
+// SceneMapping holds the episodes of a show numbered differently by the scene
+// releases and the metadata providers, the other episodes have the same
+// numbers
+type SceneMapping struct {
+	ImdbID   string
+	Episodes []*SceneMappingEpisode
+}
+
+// ToScene returns the scene number of an episode numbered by the metadata
+// providers, the first scene release is used for an episode released in
+// several parts
+func (m *SceneMapping) ToScene(n EpisodeNumber) EpisodeNumber {
+	if m == nil {
+		return n
+	}
+
+	for _, e := range m.Episodes {
+		if e.Metadata == n {
+			return e.Scene
+		}
+	}
+
+	return n
+}
+
+// FromScene returns the number given by the metadata providers to a scene
+// release
+func (m *SceneMapping) FromScene(n EpisodeNumber) EpisodeNumber {
+	if m == nil {
+		return n
+	}
+
+	for _, e := range m.Episodes {
+		if e.Scene == n {
+			return e.Metadata
+		}
+	}
+
+	return n
+}
+
+// GetSceneMapping returns the scene mapping of a show, it's nil if the show
+// has none
+func (s *ShowConfig) GetSceneMapping(show *Show) *SceneMapping {
+	return s.SceneMappings[show.ImdbID]
+}
+
+// sceneMapping returns the scene mapping of the show of an episode
+func (s *ShowEpisode) sceneMapping() *SceneMapping {
+	show := NewShowFromEpisode(s)
+	if show.ImdbID == "" && s.Show != nil {
+		show.ImdbID = s.Show.ImdbID
+		show.TvdbID = s.Show.TvdbID
+	}
+
+	if show.ImdbID == "" {
+		return nil
+	}
+
+	return s.GetSceneMapping(show)
+}
+
+// SceneNumber returns the scene number of the episode to search its releases
+func (s *ShowEpisode) SceneNumber() EpisodeNumber {
+	n := EpisodeNumber{Season: s.Season, Episode: s.Episode}
+	return s.sceneMapping().ToScene(n)
+}
+
+// sceneParts returns the scene numbers of an episode numbered by the metadata
+// providers, an episode released in several parts has several scene numbers
+func (m *SceneMapping) sceneParts(n EpisodeNumber) []EpisodeNumber {
+	parts := []EpisodeNumber{}
+	for _, e := range m.Episodes {
+		if e.Metadata == n {
+			parts = append(parts, e.Scene)
+		}
+	}
+
+	return parts
+}
+
+// ResolveSceneNumber translates the scene number guessed from the release of
+// an episode to the number of the metadata providers, it returns true if the
+// number has changed. The releases holding only some of the parts of an
+// episode released in several parts are rejected with ErrSceneEpisodePart
+func ResolveSceneNumber(e *ShowEpisode) (bool, error) {
+	m := e.sceneMapping()
+	if m == nil {
+		return false, nil
+	}
+
+	// Every part of the episodes of the release must be in the release
+	episodes := e.Episodes()
+	first, last := episodes[0], episodes[len(episodes)-1]
+	for _, eNum := range episodes {
+		n := m.FromScene(EpisodeNumber{Season: e.Season, Episode: eNum})
+		for _, part := range m.sceneParts(n) {
+			if part.Season != e.Season || part.Episode < first || part.Episode > last {
+				return false, ErrSceneEpisodePart
+			}
+		}
+	}
+
+	start := m.FromScene(EpisodeNumber{Season: e.Season, Episode: e.Episode})
+	end := 0
+	if e.EpisodeEnd != 0 {
+		// The episodes released in several parts are a single episode
+		last := m.FromScene(EpisodeNumber{Season: e.Season, Episode: e.EpisodeEnd})
+		if last.Season == start.Season && last.Episode > start.Episode {
+			end = last.Episode
+		}
+	}
+
+	if start.Season == e.Season && start.Episode == e.Episode && end == e.EpisodeEnd {
+		return false, nil
+	}
+
+	e.Season = start.Season
+	e.Episode = start.Episode
+	e.EpisodeEnd = end
+	return true, nil
+}
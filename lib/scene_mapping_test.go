@@ -0,0 +1,172 @@
+package polochon
+
+import (
+	"testing"
+)
+
+// doubleEpisodeMapping is the mapping of a show whose first episode is
+// released in two parts by the scene
+func doubleEpisodeMapping() *SceneMapping {
+	return &SceneMapping{
+		ImdbID: "tt0285403",
+		Episodes: []*SceneMappingEpisode{
+			{Scene: EpisodeNumber{1, 1}, Metadata: EpisodeNumber{1, 1}},
+			{Scene: EpisodeNumber{1, 2}, Metadata: EpisodeNumber{1, 1}},
+			{Scene: EpisodeNumber{1, 3}, Metadata: EpisodeNumber{1, 2}},
+		},
+	}
+}
+
+func TestEpisodeNumberUnmarshalYAML(t *testing.T) {
+	var n EpisodeNumber
+	err := n.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*string)) = "S02E13"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if n.String() != "S02E13" {
+		t.Errorf("expected S02E13, got %s", n)
+	}
+
+	err = n.UnmarshalYAML(func(v interface{}) error {
+		*(v.(*string)) = "2x13"
+		return nil
+	})
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestSceneMapping(t *testing.T) {
+	m := doubleEpisodeMapping()
+
+	for _, tc := range []struct {
+		scene    EpisodeNumber
+		metadata EpisodeNumber
+	}{
+		{scene: EpisodeNumber{1, 2}, metadata: EpisodeNumber{1, 1}},
+		{scene: EpisodeNumber{1, 3}, metadata: EpisodeNumber{1, 2}},
+		{scene: EpisodeNumber{2, 1}, metadata: EpisodeNumber{2, 1}},
+	} {
+		if got := m.FromScene(tc.scene); got != tc.metadata {
+			t.Errorf("%s: expected %s, got %s", tc.scene, tc.metadata, got)
+		}
+	}
+
+	for _, tc := range []struct {
+		metadata EpisodeNumber
+		scene    EpisodeNumber
+	}{
+		{metadata: EpisodeNumber{1, 1}, scene: EpisodeNumber{1, 1}},
+		{metadata: EpisodeNumber{1, 2}, scene: EpisodeNumber{1, 3}},
+		{metadata: EpisodeNumber{2, 1}, scene: EpisodeNumber{2, 1}},
+	} {
+		if got := m.ToScene(tc.metadata); got != tc.scene {
+			t.Errorf("%s: expected %s, got %s", tc.metadata, tc.scene, got)
+		}
+	}
+
+	// Without mapping the numbers are the same
+	var none *SceneMapping
+	if got := none.ToScene(EpisodeNumber{1, 2}); got != (EpisodeNumber{1, 2}) {
+		t.Errorf("expected S01E02, got %s", got)
+	}
+}
+
+func TestResolveSceneNumber(t *testing.T) {
+	conf := ShowConfig{
+		SceneMappings: map[string]*SceneMapping{"tt0285403": doubleEpisodeMapping()},
+	}
+
+	// The double episode is a single episode
+	e := NewShowEpisode(conf)
+	e.ShowImdbID = "tt0285403"
+	e.Season = 1
+	e.Episode = 1
+	e.EpisodeEnd = 2
+	if changed, err := ResolveSceneNumber(e); err != nil || !changed {
+		t.Errorf("expected the number to change, got %t %v", changed, err)
+	}
+	if e.Season != 1 || e.Episode != 1 || e.EpisodeEnd != 0 {
+		t.Errorf("expected S01E01, got S%02dE%02d-%d", e.Season, e.Episode, e.EpisodeEnd)
+	}
+
+	// The show is found from the guessed show
+	e = NewShowEpisode(conf)
+	e.Show = &Show{ImdbID: "tt0285403"}
+	e.Season = 1
+	e.Episode = 3
+	if changed, err := ResolveSceneNumber(e); err != nil || !changed {
+		t.Errorf("expected the number to change, got %t %v", changed, err)
+	}
+	if e.Season != 1 || e.Episode != 2 {
+		t.Errorf("expected S01E02, got S%02dE%02d", e.Season, e.Episode)
+	}
+	if n := e.SceneNumber(); n != (EpisodeNumber{1, 3}) {
+		t.Errorf("expected the scene number S01E03, got %s", n)
+	}
+
+	// The episodes of the other shows are unchanged
+	e = NewShowEpisode(conf)
+	e.ShowImdbID = "tt0944947"
+	e.Season = 1
+	e.Episode = 3
+	if changed, err := ResolveSceneNumber(e); err != nil || changed {
+		t.Errorf("expected the number not to change, got %t %v", changed, err)
+	}
+
+	// The parts of the double episode would replace each other
+	for _, n := range []int{1, 2} {
+		e = NewShowEpisode(conf)
+		e.ShowImdbID = "tt0285403"
+		e.Season = 1
+		e.Episode = n
+		if _, err := ResolveSceneNumber(e); err != ErrSceneEpisodePart {
+			t.Errorf("part %d: expected %q, got %v", n, ErrSceneEpisodePart, err)
+		}
+		if e.Season != 1 || e.Episode != n {
+			t.Errorf("part %d: expected the number not to change, got S%02dE%02d", n, e.Season, e.Episode)
+		}
+	}
+
+	// The parts of the double episode along with the next episode
+	e = NewShowEpisode(conf)
+	e.ShowImdbID = "tt0285403"
+	e.Season = 1
+	e.Episode = 1
+	e.EpisodeEnd = 3
+	if changed, err := ResolveSceneNumber(e); err != nil || !changed {
+		t.Errorf("expected the number to change, got %t %v", changed, err)
+	}
+	if e.Season != 1 || e.Episode != 1 || e.EpisodeEnd != 2 {
+		t.Errorf("expected S01E01-2, got S%02dE%02d-%d", e.Season, e.Episode, e.EpisodeEnd)
+	}
+
+	// A release starting with the second part is rejected
+	e = NewShowEpisode(conf)
+	e.ShowImdbID = "tt0285403"
+	e.Season = 1
+	e.Episode = 2
+	e.EpisodeEnd = 3
+	if _, err := ResolveSceneNumber(e); err != ErrSceneEpisodePart {
+		t.Errorf("expected %q, got %v", ErrSceneEpisodePart, err)
+	}
+}
+
+func TestGetSceneMapping(t *testing.T) {
+	local := doubleEpisodeMapping()
+	conf := ShowConfig{
+		SceneMappings: map[string]*SceneMapping{"tt0285403": local},
+	}
+
+	if got := conf.GetSceneMapping(&Show{ImdbID: "tt0285403"}); got != local {
+		t.Errorf("expected the local mapping, got %+v", got)
+	}
+
+	if got := conf.GetSceneMapping(&Show{ImdbID: "tt0944947"}); got != nil {
+		t.Errorf("expected no mapping, got %+v", got)
+	}
+}
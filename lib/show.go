@@ -22,6 +22,9 @@ type Show struct {
 	Fanart     string         `json:"-"`
 	Poster     string         `json:"-"`
 	Episodes   []*ShowEpisode `json:"-"`
+	// DetailSources holds the detailer which supplied each field when the
+	// details are merged
+	DetailSources map[string]string `json:"detail_sources,omitempty"`
 }
 
 // NewShow returns a new show
@@ -59,3 +62,47 @@ func NewShowFromEpisode(e *ShowEpisode) *Show {
 		},
 	}
 }
+
+// AbsoluteEpisode returns the episode of the show with the given absolute
+// number, nil if the show has no such episode
+func (s *Show) AbsoluteEpisode(absolute int) *ShowEpisode {
+	if absolute <= 0 {
+		return nil
+	}
+
+	for _, e := range s.Episodes {
+		// The specials share the absolute numbers of the regular episodes
+		if e.Season == 0 {
+			continue
+		}
+
+		if e.AbsoluteNumber == absolute {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// GetEpisode returns an episode of the show, nil if the show has no such
+// episode
+func (s *Show) GetEpisode(season, episode int) *ShowEpisode {
+	for _, e := range s.Episodes {
+		if e.Season == season && e.Episode == episode {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// AbsoluteNumber returns the absolute number of an episode of the show, 0 if
+// the show has no such episode
+func (s *Show) AbsoluteNumber(season, episode int) int {
+	e := s.GetEpisode(season, episode)
+	if e == nil {
+		return 0
+	}
+
+	return e.AbsoluteNumber
+}
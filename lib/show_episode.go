@@ -8,6 +8,17 @@ type ShowConfig struct {
 	Torrenters []Torrenter
 	Explorers  []Explorer
 	Searchers  []Searcher
+	// DetailersMerge runs all the detailers and merges their results
+	// instead of stopping at the first one succeeding
+	DetailersMerge bool
+	// AnimeShows holds the imdb ids of the shows released with an absolute
+	// episode numbering
+	AnimeShows []string
+	// DailyShows holds the imdb ids of the shows released with the air date
+	// of their episodes
+	DailyShows []string
+	// SceneMappings holds the local scene mappings by imdb id
+	SceneMappings map[string]*SceneMapping
 }
 
 // ShowEpisode represents a tvshow episode
@@ -31,6 +42,14 @@ type ShowEpisode struct {
 	EpisodeImdbID string    `json:"imdb_id"`
 	Torrents      []Torrent `json:"torrents"`
 	Show          *Show     `json:"-"`
+	// EpisodeEnd is the last episode of a file holding several episodes
+	EpisodeEnd int `json:"episode_end,omitempty"`
+	// AbsoluteNumber is the number of the episode counted from the first
+	// episode of the show
+	AbsoluteNumber int `json:"absolute_number,omitempty"`
+	// DetailSources holds the detailer which supplied each field when the
+	// details are merged
+	DetailSources map[string]string `json:"detail_sources,omitempty"`
 }
 
 // NewShowEpisode returns a new show episode
@@ -73,6 +92,30 @@ func (s *ShowConfig) GetSearchers() []Searcher {
 	return s.Searchers
 }
 
+// IsAnime returns true if the show is released with an absolute episode
+// numbering
+func (s *ShowConfig) IsAnime(imdbID string) bool {
+	for _, id := range s.AnimeShows {
+		if id == imdbID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsDaily returns true if the show is released with the air date of its
+// episodes
+func (s *ShowConfig) IsDaily(imdbID string) bool {
+	for _, id := range s.DailyShows {
+		if id == imdbID {
+			return true
+		}
+	}
+
+	return false
+}
+
 // GetFile implements the video interface
 func (s *ShowEpisode) GetFile() *File {
 	return &s.File
@@ -82,3 +125,23 @@ func (s *ShowEpisode) GetFile() *File {
 func (s *ShowEpisode) SetFile(f *File) {
 	s.File = *f
 }
+
+// GetMetadata implements the video interface
+func (s *ShowEpisode) GetMetadata() *VideoMetadata {
+	return &s.VideoMetadata
+}
+
+// IsDateBased returns true if the episode is only identified by its air date
+func (s *ShowEpisode) IsDateBased() bool {
+	return s.Season == 0 && s.Episode == 0 && s.Aired != ""
+}
+
+// Episodes returns the episodes held by the episode file
+func (s *ShowEpisode) Episodes() []int {
+	episodes := []int{s.Episode}
+	for e := s.Episode + 1; e <= s.EpisodeEnd; e++ {
+		episodes = append(episodes, e)
+	}
+
+	return episodes
+}
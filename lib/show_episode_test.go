@@ -0,0 +1,62 @@
+package polochon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShowEpisodeEpisodes(t *testing.T) {
+	for _, tc := range []struct {
+		episode  *ShowEpisode
+		expected []int
+	}{
+		{episode: &ShowEpisode{Episode: 3}, expected: []int{3}},
+		{episode: &ShowEpisode{Episode: 3, EpisodeEnd: 3}, expected: []int{3}},
+		{episode: &ShowEpisode{Episode: 1, EpisodeEnd: 3}, expected: []int{1, 2, 3}},
+	} {
+		if got := tc.episode.Episodes(); !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("expected %v, got %v", tc.expected, got)
+		}
+	}
+}
+
+func TestShowConfigIsAnime(t *testing.T) {
+	conf := ShowConfig{AnimeShows: []string{"tt0388629"}}
+
+	if !conf.IsAnime("tt0388629") {
+		t.Error("expected the show to be an anime")
+	}
+
+	if conf.IsAnime("tt0944947") {
+		t.Error("expected the show not to be an anime")
+	}
+}
+
+func TestShowAbsoluteEpisode(t *testing.T) {
+	special := &ShowEpisode{Season: 0, Episode: 1, AbsoluteNumber: 1}
+	first := &ShowEpisode{Season: 1, Episode: 1, AbsoluteNumber: 1}
+	last := &ShowEpisode{Season: 2, Episode: 3, AbsoluteNumber: 27}
+	show := &Show{Episodes: []*ShowEpisode{special, first, last}}
+
+	for _, tc := range []struct {
+		absolute int
+		expected *ShowEpisode
+	}{
+		{absolute: 0, expected: nil},
+		{absolute: 1, expected: first},
+		{absolute: 27, expected: last},
+		{absolute: 28, expected: nil},
+	} {
+		if got := show.AbsoluteEpisode(tc.absolute); got != tc.expected {
+			t.Errorf("%d: expected %+v, got %+v", tc.absolute, tc.expected, got)
+		}
+	}
+
+	if got := show.AbsoluteNumber(2, 3); got != 27 {
+		t.Errorf("expected the absolute number 27, got %d", got)
+	}
+
+	if got := show.AbsoluteNumber(2, 4); got != 0 {
+		t.Errorf("expected no absolute number, got %d", got)
+	}
+}
@@ -0,0 +1,142 @@
+package stability
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// State represents the state of a path on the filesystem
+type State struct {
+	Size    int64
+	ModTime time.Time
+}
+
+// equal returns true if the states are the same
+func (s State) equal(o State) bool {
+	return s.Size == o.Size && s.ModTime.Equal(o.ModTime)
+}
+
+// Stat returns the state of a path, the state of a directory is the total
+// size of its files and the latest modification time of its content
+func Stat(path string) (State, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return State{}, err
+	}
+
+	if !fi.IsDir() {
+		return State{Size: fi.Size(), ModTime: fi.ModTime()}, nil
+	}
+
+	state := State{}
+	err = filepath.Walk(path, func(_ string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if fi.ModTime().After(state.ModTime) {
+			state.ModTime = fi.ModTime()
+		}
+
+		if fi.Mode().IsRegular() {
+			state.Size += fi.Size()
+		}
+
+		return nil
+	})
+
+	return state, err
+}
+
+// entry represents a tracked path
+type entry struct {
+	state     State
+	changedAt time.Time
+	emitted   bool
+}
+
+// Tracker keeps the states of paths to tell when they stop changing
+type Tracker struct {
+	sync.Mutex
+	quiet time.Duration
+	paths map[string]*entry
+
+	// now can be replaced in the tests
+	now func() time.Time
+}
+
+// NewTracker returns a new tracker, a path is stable once its state did not
+// change during the quiet period
+func NewTracker(quiet time.Duration) *Tracker {
+	return &Tracker{
+		quiet: quiet,
+		paths: map[string]*entry{},
+		now:   time.Now,
+	}
+}
+
+// Update records the state of a path, it returns true only once when the path
+// becomes stable
+func (t *Tracker) Update(path string, s State) bool {
+	t.Lock()
+	defer t.Unlock()
+
+	now := t.now()
+	e, ok := t.paths[path]
+	if !ok || !e.state.equal(s) {
+		e = &entry{state: s, changedAt: now}
+		t.paths[path] = e
+	}
+
+	if e.emitted || now.Sub(e.changedAt) < t.quiet {
+		return false
+	}
+
+	e.emitted = true
+	return true
+}
+
+// Seen records the state of a path as already stable
+func (t *Tracker) Seen(path string, s State) {
+	t.Lock()
+	defer t.Unlock()
+
+	t.paths[path] = &entry{state: s, changedAt: t.now(), emitted: true}
+}
+
+// Remove stops tracking a path
+func (t *Tracker) Remove(path string) {
+	t.Lock()
+	defer t.Unlock()
+
+	delete(t.paths, path)
+}
+
+// Pending returns the tracked paths not stable yet
+func (t *Tracker) Pending() []string {
+	t.Lock()
+	defer t.Unlock()
+
+	paths := []string{}
+	for path, e := range t.paths {
+		if !e.emitted {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// Retain stops tracking the paths not in the given ones
+func (t *Tracker) Retain(paths map[string]bool) {
+	t.Lock()
+	defer t.Unlock()
+
+	for path := range t.paths {
+		if !paths[path] {
+			delete(t.paths, path)
+		}
+	}
+}
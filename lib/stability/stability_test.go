@@ -0,0 +1,96 @@
+package stability
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTracker(t *testing.T) {
+	now := time.Now()
+	tracker := NewTracker(time.Minute)
+
+	modTime := now.Add(-time.Hour)
+	for _, step := range []struct {
+		name     string
+		elapsed  time.Duration
+		state    State
+		expected bool
+	}{
+		{name: "new path", state: State{Size: 10, ModTime: modTime}, expected: false},
+		{name: "still written", elapsed: 30 * time.Second, state: State{Size: 20, ModTime: modTime.Add(time.Second)}, expected: false},
+		{name: "quiet", elapsed: 80 * time.Second, state: State{Size: 20, ModTime: modTime.Add(time.Second)}, expected: false},
+		{name: "stable", elapsed: 90 * time.Second, state: State{Size: 20, ModTime: modTime.Add(time.Second)}, expected: true},
+		{name: "already emitted", elapsed: 10 * time.Minute, state: State{Size: 20, ModTime: modTime.Add(time.Second)}, expected: false},
+		{name: "changed again", elapsed: 11 * time.Minute, state: State{Size: 30, ModTime: modTime.Add(time.Minute)}, expected: false},
+		{name: "stable again", elapsed: 12 * time.Minute, state: State{Size: 30, ModTime: modTime.Add(time.Minute)}, expected: true},
+	} {
+		tracker.now = func() time.Time { return now.Add(step.elapsed) }
+
+		if got := tracker.Update("/downloads/movie.mkv", step.state); got != step.expected {
+			t.Errorf("%s: expected %t, got %t", step.name, step.expected, got)
+		}
+	}
+
+	tracker.Seen("/downloads/other.mkv", State{Size: 1})
+	tracker.Update("/downloads/new.mkv", State{Size: 1})
+	if pending := tracker.Pending(); len(pending) != 1 || pending[0] != "/downloads/new.mkv" {
+		t.Errorf("expected only the new file to be pending, got %v", pending)
+	}
+
+	tracker.Retain(map[string]bool{"/downloads/other.mkv": true})
+	if len(tracker.paths) != 1 {
+		t.Errorf("expected only one path to be tracked, got %d", len(tracker.paths))
+	}
+}
+
+func TestStat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-stability")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sub := filepath.Join(dir, "Movie.2020.720p")
+	if err := os.Mkdir(sub, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Date(2020, time.May, 1, 12, 0, 0, 0, time.UTC)
+	for name, size := range map[string]int{"movie.mkv": 10, "movie.srt": 3} {
+		path := filepath.Join(sub, name)
+		if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		modTime = modTime.Add(time.Hour)
+	}
+
+	if err := os.Chtimes(sub, modTime.Add(-3*time.Hour), modTime.Add(-3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := Stat(sub)
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	expected := State{Size: 13, ModTime: modTime.Add(-time.Hour)}
+	if !state.equal(expected) {
+		t.Errorf("expected %+v, got %+v", expected, state)
+	}
+
+	state, err = Stat(filepath.Join(sub, "movie.srt"))
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if state.Size != 3 {
+		t.Errorf("expected a size of 3, got %d", state.Size)
+	}
+}
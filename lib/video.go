@@ -52,4 +52,5 @@ type Video interface {
 	Torrentable
 	SetFile(f *File)
 	GetFile() *File
+	GetMetadata() *VideoMetadata
 }
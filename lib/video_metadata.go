@@ -10,4 +10,52 @@ type VideoMetadata struct {
 	AudioCodec   string    `json:"audio_codec"`
 	VideoCodec   string    `json:"video_codec"`
 	Container    string    `json:"container"`
+
+	// The following fields are read from the video file headers
+	Width             int             `json:"width,omitempty"`
+	Height            int             `json:"height,omitempty"`
+	Duration          int             `json:"duration,omitempty"` // In seconds
+	AudioTracks       []AudioTrack    `json:"audio_tracks,omitempty"`
+	EmbeddedSubtitles []SubtitleTrack `json:"embedded_subtitles,omitempty"`
+
+	// The following fields are used to verify the integrity of the file
+	Size     int64  `json:"size,omitempty"`
+	OSDbHash string `json:"osdb_hash,omitempty"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// AudioTrack represents an audio stream of a video file
+type AudioTrack struct {
+	Codec    string   `json:"codec"`
+	Language Language `json:"language"`
+	Channels string   `json:"channels"`
+}
+
+// SubtitleTrack represents a subtitle stream embedded in a video file
+type SubtitleTrack struct {
+	Codec    string   `json:"codec"`
+	Language Language `json:"language"`
+	Forced   bool     `json:"forced"`
+}
+
+// HasEmbeddedSubtitle returns true if the video file contains a subtitle
+// track in the given language, forced subtitles are not taken into account
+func (vm *VideoMetadata) HasEmbeddedSubtitle(lang Language) bool {
+	for _, s := range vm.EmbeddedSubtitles {
+		if s.Language == lang && !s.Forced {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAudioLanguage returns true if the video file contains an audio track in
+// the given language
+func (vm *VideoMetadata) HasAudioLanguage(lang Language) bool {
+	for _, a := range vm.AudioTracks {
+		if a.Language == lang {
+			return true
+		}
+	}
+	return false
 }
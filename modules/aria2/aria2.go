@@ -79,7 +79,12 @@ func (c *Client) Name() string {
 
 // Status implements the Module interface
 func (c *Client) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	// Ask the version of aria2 to check the connection and the secret
+	if _, err := c.protocol.GetVersion(); err != nil {
+		return polochon.StatusFail, err
+	}
+
+	return polochon.StatusOK, nil
 }
 
 // Download implements the downloader interface
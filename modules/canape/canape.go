@@ -79,7 +79,12 @@ func (w *Wishlist) Name() string {
 
 // Status implements the Module interface
 func (w *Wishlist) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	// Get the movie wishlists to check the urls and the tokens
+	if _, err := w.getMovieWishlists(); err != nil {
+		return polochon.StatusFail, err
+	}
+
+	return polochon.StatusOK, nil
 }
 
 // GetMovieWishlist gets the movies wishlist
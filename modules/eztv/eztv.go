@@ -46,7 +46,7 @@ var eztvGetEpisode = func(imdbID string, season, episode int) (*eztv.ShowEpisode
 }
 
 // Get the show infos from eztv
-func (e *Eztv) getShowEpisodeDetails(s *polochon.ShowEpisode) error {
+func (e *Eztv) getShowEpisodeDetails(s *polochon.ShowEpisode, log *logrus.Entry) error {
 	if s.ShowImdbID == "" {
 		return ErrMissingShowImdbID
 	}
@@ -55,7 +55,9 @@ func (e *Eztv) getShowEpisodeDetails(s *polochon.ShowEpisode) error {
 		return ErrInvalidShowEpisode
 	}
 
-	episode, err := eztvGetEpisode(s.ShowImdbID, s.Season, s.Episode)
+	// The releases are numbered by the scene
+	n := s.SceneNumber()
+	episode, err := eztvGetEpisode(s.ShowImdbID, n.Season, n.Episode)
 	switch err {
 	case nil:
 		// continue
@@ -119,7 +121,7 @@ func (e *Eztv) Status() (polochon.ModuleStatus, error) {
 func (e *Eztv) GetTorrents(i interface{}, log *logrus.Entry) error {
 	switch v := i.(type) {
 	case *polochon.ShowEpisode:
-		return e.getShowEpisodeDetails(v)
+		return e.getShowEpisodeDetails(v, log)
 	default:
 		return ErrInvalidArgument
 	}
@@ -1,12 +1,16 @@
 package fsnotify
 
 import (
+	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/stability"
 	"github.com/sirupsen/logrus"
 	"gopkg.in/fsnotify.v1"
+	"gopkg.in/yaml.v2"
 )
 
 // Make sure that the module is a subtitler
@@ -21,13 +25,29 @@ const (
 	// Module name
 	moduleName = "fsnotify"
 
-	// DELAY represents the time to wait before sending an event
-	DELAY time.Duration = 100 * time.Millisecond
+	// DefaultQuietPeriod is the default time during which a file must not
+	// change before being sent.
+	// Transmission creates the folder and move the files afterwards, the
+	// files can also be copied slowly in the directory.
+	DefaultQuietPeriod = 5 * time.Second
+
+	// maxCheckInterval is the max time between two checks of the files not
+	// stable yet
+	maxCheckInterval = time.Second
 )
 
-// FsNotify is a fsNotifier watching a directory
+// Params represents the module params
+type Params struct {
+	QuietPeriod time.Duration `yaml:"quiet_period"`
+}
+
+// FsNotify is a fsNotifier watching directories
 type FsNotify struct {
-	watcher *fsnotify.Watcher
+	sync.Mutex
+	*Params
+	// dirs holds the watched directories
+	dirs       map[string]bool
+	configured bool
 }
 
 // Name implements the Module interface
@@ -37,65 +57,162 @@ func (fs *FsNotify) Name() string {
 
 // Status implements the Module interface
 func (fs *FsNotify) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	fs.Lock()
+	dirs := []string{}
+	for dir := range fs.dirs {
+		dirs = append(dirs, dir)
+	}
+	fs.Unlock()
+
+	if len(dirs) == 0 {
+		return polochon.StatusFail, fmt.Errorf("fsnotify: not watching any directory")
+	}
+
+	// Ensure that the watched directories still exist
+	for _, dir := range dirs {
+		fi, err := os.Stat(dir)
+		if err != nil {
+			return polochon.StatusFail, err
+		}
+
+		if !fi.IsDir() {
+			return polochon.StatusFail, fmt.Errorf("fsnotify: %s is not a directory", dir)
+		}
+	}
+
+	return polochon.StatusOK, nil
 }
 
 // Init implements the Module interface
-func (fs *FsNotify) Init([]byte) error {
+func (fs *FsNotify) Init(data []byte) error {
+	if fs.configured {
+		return nil
+	}
+
+	params := &Params{}
+	if err := yaml.Unmarshal(data, params); err != nil {
+		return err
+	}
+
+	return fs.InitWithParams(params)
+}
+
+// InitWithParams configures the module
+func (fs *FsNotify) InitWithParams(params *Params) error {
+	if params.QuietPeriod == 0 {
+		params.QuietPeriod = DefaultQuietPeriod
+	}
+
+	if params.QuietPeriod < 0 {
+		return fmt.Errorf("fsnotify: invalid quiet period")
+	}
+
+	fs.Params = params
+	fs.configured = true
+
 	return nil
 }
 
 // Watch implements the modules fsNotifier interface
 func (fs *FsNotify) Watch(watchPath string, ctx polochon.FsNotifierCtx, log *logrus.Entry) error {
-	// Create a new watcher
+	// Ensure that the watch path exists
+	if _, err := os.Stat(watchPath); os.IsNotExist(err) {
+		return err
+	}
+
+	// Create a new watcher, each directory has its own
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return err
 	}
-	fs.watcher = watcher
 
-	// Ensure that the watch path exists
-	if _, err := os.Stat(watchPath); os.IsNotExist(err) {
+	// Watch the path
+	if err := watcher.Add(watchPath); err != nil {
+		watcher.Close()
 		return err
 	}
 
-	log = log.WithField("module", moduleName)
+	fs.Lock()
+	if fs.dirs == nil {
+		fs.dirs = map[string]bool{}
+	}
+	fs.dirs[watchPath] = true
+	fs.Unlock()
+
+	log = log.WithFields(logrus.Fields{
+		"module":     moduleName,
+		"watch_path": watchPath,
+	})
 
 	// Run the event handler
-	go fs.eventHandler(ctx, log)
+	ctx.Wg.Add(1)
+	go fs.eventHandler(watchPath, watcher, ctx, log)
 
-	// Watch the path
-	return fs.watcher.Add(watchPath)
+	return nil
 }
 
-func (fs *FsNotify) eventHandler(ctx polochon.FsNotifierCtx, log *logrus.Entry) {
+func (fs *FsNotify) eventHandler(watchPath string, watcher *fsnotify.Watcher, ctx polochon.FsNotifierCtx, log *logrus.Entry) {
 	// Notify the waitgroup
-	ctx.Wg.Add(1)
 	defer ctx.Wg.Done()
 
 	// Close the watcher when done
-	defer fs.watcher.Close()
+	defer func() {
+		watcher.Close()
+
+		fs.Lock()
+		delete(fs.dirs, watchPath)
+		fs.Unlock()
+	}()
+
+	// The paths are only sent once they stop changing
+	tracker := stability.NewTracker(fs.QuietPeriod)
+	interval := fs.QuietPeriod
+	if interval > maxCheckInterval {
+		interval = maxCheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done:
 			log.Debug("fsnotify is done watching")
 			return
-		case ev := <-fs.watcher.Events:
+		case ev := <-watcher.Events:
 			if ev.Op != fsnotify.Create && ev.Op != fsnotify.Chmod {
 				continue
 			}
 
-			// Wait for the delay time before sending an event.
-			// Transmission creates the folder and move the files afterwards.
-			// We need to wait for the file to be moved in before sending the
-			// event. Delay is the estimated time to wait.
-			go func() {
-				time.Sleep(DELAY)
-				ctx.Event <- ev.Name
-			}()
-		case err := <-fs.watcher.Errors:
+			fs.check(ev.Name, tracker, ctx, log)
+		case <-ticker.C:
+			for _, path := range tracker.Pending() {
+				fs.check(path, tracker, ctx, log)
+			}
+		case err := <-watcher.Errors:
 			log.Error(err)
 		}
 	}
 }
+
+// check updates the state of the path and sends it once stable
+func (fs *FsNotify) check(path string, tracker *stability.Tracker, ctx polochon.FsNotifierCtx, log *logrus.Entry) {
+	state, err := stability.Stat(path)
+	if err != nil {
+		// The path might have been removed or renamed
+		log.WithField("path", path).Debugf("failed to stat the path: %q", err)
+		tracker.Remove(path)
+		return
+	}
+
+	if !tracker.Update(path, state) {
+		return
+	}
+
+	// The path will be tracked again on its next event
+	tracker.Remove(path)
+
+	select {
+	case ctx.Event <- path:
+	case <-ctx.Done:
+	}
+}
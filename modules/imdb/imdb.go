@@ -62,7 +62,12 @@ func (w *Wishlist) Name() string {
 
 // Status implements the Module interface
 func (w *Wishlist) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	// Get the movie watchlists to check the user ids
+	if _, err := w.getList(getMoviesFromImdb); err != nil {
+		return polochon.StatusFail, err
+	}
+
+	return polochon.StatusOK, nil
 }
 
 // wrapper function to be overwritten during the tests
@@ -1,6 +1,7 @@
 package imdb
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
@@ -90,3 +91,32 @@ func TestEmptyWishlist(t *testing.T) {
 		t.Errorf("Expected %+v, got %+v", expected, got)
 	}
 }
+
+func TestStatus(t *testing.T) {
+	getMoviesFromImdb = func(userID string) (*[]string, error) {
+		ids := testData[userID]["movies"]
+		return &ids, nil
+	}
+
+	status, err := testWishlist.Status()
+	if err != nil {
+		t.Fatalf("Expected no error, got %q", err)
+	}
+
+	if status != polochon.StatusOK {
+		t.Errorf("Expected status %q, got %q", polochon.StatusOK, status)
+	}
+
+	getMoviesFromImdb = func(userID string) (*[]string, error) {
+		return nil, errors.New("imdb is down")
+	}
+
+	status, err = testWishlist.Status()
+	if err == nil {
+		t.Errorf("Expected an error")
+	}
+
+	if status != polochon.StatusFail {
+		t.Errorf("Expected status %q, got %q", polochon.StatusFail, status)
+	}
+}
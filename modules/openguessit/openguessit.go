@@ -3,7 +3,10 @@ package openguessit
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/odwrtw/guessit"
 	polochon "github.com/odwrtw/polochon/lib"
@@ -42,7 +45,17 @@ func (og *OpenGuessit) Name() string {
 
 // Status implements the Module interface
 func (og *OpenGuessit) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	// Guess a well known movie
+	guess, err := og.GuessitClient.Guess("The.Matrix.1999.720p.BluRay.x264.mkv")
+	if err != nil {
+		return polochon.StatusFail, err
+	}
+
+	if guess.Type != "movie" || guess.Year != 1999 {
+		return polochon.StatusFail, fmt.Errorf("openguessit: unexpected guess %s %d", guess.Type, guess.Year)
+	}
+
+	return polochon.StatusOK, nil
 }
 
 // Guess implements the Guesser interface
@@ -64,7 +77,22 @@ func (og *OpenGuessit) Guess(file polochon.File, movieConf polochon.MovieConfig,
 		Container:    guess.Container,
 	}
 
-	switch guess.Type {
+	// The episodes of the daily shows are named after their air date
+	aired := dailyAiredDate(filename, guess.Season, showConf)
+
+	// The type hint of the file resolves the ambiguous names
+	guessType := guess.Type
+	switch file.TypeHint {
+	case polochon.VideoTypeMovie:
+		guessType = "movie"
+	case polochon.VideoTypeShow:
+		if guess.Season == 0 && guess.Episode == 0 && aired == "" {
+			return nil, fmt.Errorf("openguessit: no season or episode found in %s", filename)
+		}
+		guessType = "episode"
+	}
+
+	switch guessType {
 	case "movie":
 		return &polochon.Movie{
 			VideoMetadata: metadata,
@@ -77,20 +105,121 @@ func (og *OpenGuessit) Guess(file polochon.File, movieConf polochon.MovieConfig,
 		show := polochon.NewShow(showConf)
 		show.Year = guess.Year
 		show.Title = guess.Title
-		return &polochon.ShowEpisode{
+		episode := &polochon.ShowEpisode{
 			VideoMetadata: metadata,
 			ShowConfig:    showConf,
 			Show:          show,
 			File:          file,
 			ShowTitle:     guess.Title,
 			Episode:       guess.Episode,
+			EpisodeEnd:    episodeEnd(filename, guess.Season, guess.Episode),
 			Season:        guess.Season,
-		}, nil
+		}
+
+		// The air date identifies the episode, the year of the date is not
+		// the year of the show
+		if aired != "" {
+			show.Year = 0
+			episode.Episode = 0
+			episode.EpisodeEnd = 0
+			episode.Aired = aired
+			return episode, nil
+		}
+
+		// The releases numbered from the first episode of the show such as
+		// "[Group] Show - 137" have no season, the detailers find it from the
+		// absolute number
+		if absoluteNumbered(filename, episode.Season, episode.Episode) {
+			episode.AbsoluteNumber = episode.Episode
+			episode.Episode = 0
+		}
+
+		return episode, nil
 	default:
 		return nil, fmt.Errorf("openguessit: invalid guess type: %s", guess.Type)
 	}
 }
 
+// seasonRegexp matches the explicit seasons such as "S00E05" or "0x05"
+var seasonRegexp = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:s\d{1,2}[ ._-]?e\d{1,3}|\d{1,2}x\d{1,3}(?:[^0-9]|$)|season(?:[^a-z]|$))`)
+
+// absoluteNumbered returns true if the episode is numbered from the first
+// episode of the show, the specials of the season 0 are not
+func absoluteNumbered(filename string, season, episode int) bool {
+	if season != 0 || episode == 0 {
+		return false
+	}
+
+	return !seasonRegexp.MatchString(filename)
+}
+
+// episodeRangeRegexp matches the files holding several episodes such as
+// "S01E01E02", "S01E01-E03" or "S01E01-03"
+var (
+	episodeRangeRegexp = regexp.MustCompile(`(?i)s(\d{1,2})e(\d{1,3})((?:[-.]?e\d{1,3})+|-\d{1,3}\b)`)
+	numberRegexp       = regexp.MustCompile(`\d+`)
+)
+
+// episodeEnd returns the last episode held by a file, it's 0 if the file holds
+// a single episode
+func episodeEnd(filename string, season, episode int) int {
+	m := episodeRangeRegexp.FindStringSubmatch(filename)
+	if m == nil {
+		return 0
+	}
+
+	// Make sure the range starts with the guessed episode
+	if s, _ := strconv.Atoi(m[1]); s != season {
+		return 0
+	}
+	if e, _ := strconv.Atoi(m[2]); e != episode {
+		return 0
+	}
+
+	end := 0
+	for _, n := range numberRegexp.FindAllString(m[3], -1) {
+		if e, _ := strconv.Atoi(n); e > end {
+			end = e
+		}
+	}
+
+	if end <= episode {
+		return 0
+	}
+
+	return end
+}
+
+// airedDateRegexp matches the air dates such as "2026.10.15" or "2026-10-15"
+var airedDateRegexp = regexp.MustCompile(`(?:^|\D)((?:19|20)\d{2})[ ._-](\d{2})[ ._-](\d{2})(?:\D|$)`)
+
+// dailyAiredDate returns the air date identifying an episode, it's empty if
+// the file has a season or if no daily show is configured. The show of the
+// episode is checked once the air date is resolved
+func dailyAiredDate(filename string, season int, showConf polochon.ShowConfig) string {
+	if season != 0 || len(showConf.DailyShows) == 0 {
+		return ""
+	}
+
+	return airedDate(filename)
+}
+
+// airedDate returns the air date found in a filename, it's empty if there is
+// none
+func airedDate(filename string) string {
+	m := airedDateRegexp.FindStringSubmatch(filename)
+	if m == nil {
+		return ""
+	}
+
+	date := strings.Join(m[1:], "-")
+	if _, err := time.Parse(polochon.AiredDateFormat, date); err != nil {
+		return ""
+	}
+
+	return date
+}
+
 // toUpperCaseFirst is an helper to get the uppercase first of a string
 func toUpperCaseFirst(s string) string {
 	retStr := []string{}
@@ -0,0 +1,88 @@
+package openguessit
+
+import (
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+func TestEpisodeEnd(t *testing.T) {
+	for _, tc := range []struct {
+		filename string
+		season   int
+		episode  int
+		expected int
+	}{
+		{filename: "Show.S01E01.720p.mkv", season: 1, episode: 1, expected: 0},
+		{filename: "Show.S01E01E02.720p.mkv", season: 1, episode: 1, expected: 2},
+		{filename: "Show.s02e05-e07.mkv", season: 2, episode: 5, expected: 7},
+		{filename: "Show.S02E05.E06.mkv", season: 2, episode: 5, expected: 6},
+		{filename: "Show.S01E09-10.mkv", season: 1, episode: 9, expected: 10},
+		{filename: "Show.S01E09-720p.mkv", season: 1, episode: 9, expected: 0},
+		{filename: "Show.S01E03E02.mkv", season: 1, episode: 3, expected: 0},
+		{filename: "Show.S01E01E02.mkv", season: 2, episode: 1, expected: 0},
+	} {
+		if got := episodeEnd(tc.filename, tc.season, tc.episode); got != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.filename, tc.expected, got)
+		}
+	}
+}
+
+func TestAbsoluteNumbered(t *testing.T) {
+	for _, tc := range []struct {
+		filename string
+		season   int
+		episode  int
+		expected bool
+	}{
+		{filename: "[Group] Show - 137 [1080p].mkv", episode: 137, expected: true},
+		{filename: "Show.S00E05.720p.mkv", episode: 5, expected: false},
+		{filename: "Show.0x05.720p.mkv", episode: 5, expected: false},
+		{filename: "Show.Season.0.Episode.5.mkv", episode: 5, expected: false},
+		{filename: "Show_S00E05.mkv", episode: 5, expected: false},
+		{filename: "[Group] Show - 137 [1920x1080].mkv", episode: 137, expected: true},
+		{filename: "Show.S01E05.720p.mkv", season: 1, episode: 5, expected: false},
+		{filename: "Show.720p.mkv", expected: false},
+	} {
+		if got := absoluteNumbered(tc.filename, tc.season, tc.episode); got != tc.expected {
+			t.Errorf("%s: expected %t, got %t", tc.filename, tc.expected, got)
+		}
+	}
+}
+
+func TestAiredDate(t *testing.T) {
+	for _, tc := range []struct {
+		filename string
+		expected string
+	}{
+		{filename: "Show.2026.10.15.720p.mkv", expected: "2026-10-15"},
+		{filename: "Show 2026-10-15 Guest.mkv", expected: "2026-10-15"},
+		{filename: "Show_2026_10_15.mkv", expected: "2026-10-15"},
+		{filename: "Show.2026.13.15.mkv", expected: ""},
+		{filename: "Show.S01E01.2026.mkv", expected: ""},
+		{filename: "Show.12026.10.15.mkv", expected: ""},
+	} {
+		if got := airedDate(tc.filename); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.filename, tc.expected, got)
+		}
+	}
+}
+
+func TestDailyAiredDate(t *testing.T) {
+	daily := polochon.ShowConfig{DailyShows: []string{"tt0115147"}}
+
+	for _, tc := range []struct {
+		name     string
+		season   int
+		conf     polochon.ShowConfig
+		expected string
+	}{
+		{name: "daily shows", conf: daily, expected: "2026-10-15"},
+		{name: "season", season: 2026, conf: daily, expected: ""},
+		{name: "no daily show", conf: polochon.ShowConfig{}, expected: ""},
+	} {
+		if got := dailyAiredDate("Show.2026.10.15.720p.mkv", tc.season, tc.conf); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
@@ -282,6 +282,32 @@ func (osp *osProxy) checkConnAndExec(f func(v interface{}, lang string, filePath
 
 // searchSubtitlesByHash will make a hash of the file, and check for corresponding subtitles
 func (osp *osProxy) searchSubtitlesByHash(v interface{}, lang string, filePath string) (osdb.Subtitles, error) {
+	// Use the hash stored in the metadata if known
+	var metadata polochon.VideoMetadata
+	switch video := v.(type) {
+	case polochon.ShowEpisode:
+		metadata = video.VideoMetadata
+	case polochon.Movie:
+		metadata = video.VideoMetadata
+	}
+
+	if metadata.OSDbHash != "" && metadata.Size > 0 {
+		innerParams := []map[string]string{
+			{
+				"moviehash":     metadata.OSDbHash,
+				"moviebytesize": strconv.FormatInt(metadata.Size, 10),
+				"sublanguageid": lang,
+			},
+		}
+
+		params := []interface{}{
+			osp.client.Token,
+			innerParams,
+		}
+
+		return searchOsdbSubtitles(osp.client, params)
+	}
+
 	// Set the languages
 	languages := []string{lang}
 	// Hash movie file, and search...
@@ -285,3 +285,39 @@ func TestSearchSubtitles(t *testing.T) {
 		}
 	}
 }
+
+func TestSearchSubtitlesByStoredHash(t *testing.T) {
+	proxy := fakeProxy
+	proxy.client = fakeClient
+
+	// The file must not be hashed again
+	fileSearchSubtitles = fakeFileSearchSubtitlesError
+
+	var got map[string]string
+	searchOsdbSubtitles = func(c *osdb.Client, params []interface{}) (osdb.Subtitles, error) {
+		got = params[1].([]map[string]string)[0]
+		return fakeSubtitles, nil
+	}
+
+	movie := fakeMovie
+	movie.OSDbHash = "8e245d9679d31e12"
+	movie.Size = 1073741824
+
+	subs, err := proxy.searchSubtitlesByHash(movie, "fre", "fakePath")
+	if err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if !reflect.DeepEqual(subs, fakeSubtitles) {
+		t.Errorf("expected %+v, got %+v", fakeSubtitles, subs)
+	}
+
+	expected := map[string]string{
+		"moviehash":     "8e245d9679d31e12",
+		"moviebytesize": "1073741824",
+		"sublanguageid": "fre",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %+v, got %+v", expected, got)
+	}
+}
@@ -88,5 +88,10 @@ func (p *Pam) Name() string {
 
 // Status implements the Module interface
 func (p *Pam) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	// List the movies to check the endpoint and the credentials
+	if _, err := p.client.GetMovies(); err != nil {
+		return polochon.StatusFail, err
+	}
+
+	return polochon.StatusOK, nil
 }
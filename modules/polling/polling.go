@@ -0,0 +1,202 @@
+package polling
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/stability"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// Make sure that the module is a fsnotifier
+var _ polochon.FsNotifier = (*Polling)(nil)
+
+// Register polling as a FsNotifier
+func init() {
+	polochon.RegisterModule(&Polling{})
+}
+
+// Module constants
+const (
+	moduleName = "polling"
+
+	// DefaultInterval is the default time between two polls
+	DefaultInterval = 10 * time.Second
+	// DefaultQuietPeriod is the default time during which a file must not
+	// change before being sent
+	DefaultQuietPeriod = 30 * time.Second
+)
+
+// Params represents the module params
+type Params struct {
+	Interval    time.Duration `yaml:"interval"`
+	QuietPeriod time.Duration `yaml:"quiet_period"`
+}
+
+// Polling is a fsNotifier polling directories, it works on the network
+// filesystems where the inotify events are not sent
+type Polling struct {
+	sync.Mutex
+	*Params
+	// dirs holds the watched directories
+	dirs       map[string]bool
+	configured bool
+}
+
+// Init implements the Module interface
+func (p *Polling) Init(data []byte) error {
+	if p.configured {
+		return nil
+	}
+
+	params := &Params{}
+	if err := yaml.Unmarshal(data, params); err != nil {
+		return err
+	}
+
+	return p.InitWithParams(params)
+}
+
+// InitWithParams configures the module
+func (p *Polling) InitWithParams(params *Params) error {
+	if params.Interval == 0 {
+		params.Interval = DefaultInterval
+	}
+
+	if params.QuietPeriod == 0 {
+		params.QuietPeriod = DefaultQuietPeriod
+	}
+
+	if params.Interval < 0 || params.QuietPeriod < 0 {
+		return fmt.Errorf("polling: invalid interval or quiet period")
+	}
+
+	p.Params = params
+	p.configured = true
+
+	return nil
+}
+
+// Name implements the Module interface
+func (p *Polling) Name() string {
+	return moduleName
+}
+
+// Status implements the Module interface
+func (p *Polling) Status() (polochon.ModuleStatus, error) {
+	p.Lock()
+	dirs := []string{}
+	for dir := range p.dirs {
+		dirs = append(dirs, dir)
+	}
+	p.Unlock()
+
+	if len(dirs) == 0 {
+		return polochon.StatusFail, fmt.Errorf("polling: not watching any directory")
+	}
+
+	// Ensure that the watched directories can still be read
+	for _, dir := range dirs {
+		if _, err := ioutil.ReadDir(dir); err != nil {
+			return polochon.StatusFail, err
+		}
+	}
+
+	return polochon.StatusOK, nil
+}
+
+// Watch implements the modules fsNotifier interface
+func (p *Polling) Watch(watchPath string, ctx polochon.FsNotifierCtx, log *logrus.Entry) error {
+	// Ensure that the watch path exists
+	if _, err := os.Stat(watchPath); err != nil {
+		return err
+	}
+
+	p.Lock()
+	if p.dirs == nil {
+		p.dirs = map[string]bool{}
+	}
+	p.dirs[watchPath] = true
+	p.Unlock()
+
+	log = log.WithFields(logrus.Fields{
+		"module":     moduleName,
+		"watch_path": watchPath,
+	})
+
+	// The files already in the directory are organized when the organizer
+	// starts, they're only sent again if they change
+	tracker := stability.NewTracker(p.QuietPeriod)
+	seen := func(path string, s stability.State) bool {
+		tracker.Seen(path, s)
+		return false
+	}
+	p.poll(watchPath, tracker, seen, func(string) {}, log)
+
+	ctx.Wg.Add(1)
+	go func() {
+		defer ctx.Wg.Done()
+		defer func() {
+			p.Lock()
+			delete(p.dirs, watchPath)
+			p.Unlock()
+		}()
+
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done:
+				log.Debug("polling is done watching")
+				return
+			case <-ticker.C:
+				p.poll(watchPath, tracker, tracker.Update, func(path string) {
+					select {
+					case ctx.Event <- path:
+					case <-ctx.Done:
+					}
+				}, log)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// poll gets the state of each entry of the directory, the entries are sent
+// once stable
+func (p *Polling) poll(dir string, tracker *stability.Tracker, update func(string, stability.State) bool, send func(string), log *logrus.Entry) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.Errorf("failed to read the directory: %q", err)
+		return
+	}
+
+	paths := map[string]bool{}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+
+		state, err := stability.Stat(path)
+		if err != nil {
+			// The entry might be removed or renamed while reading it
+			log.WithField("path", path).Debugf("failed to stat the path: %q", err)
+			continue
+		}
+
+		paths[path] = true
+		if update(path, state) {
+			log.WithField("path", path).Debug("path is stable")
+			send(path)
+		}
+	}
+
+	// Forget the paths not there anymore
+	tracker.Retain(paths)
+}
@@ -0,0 +1,89 @@
+package polling
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "polochon-polling")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	existing := filepath.Join(dir, "existing.mkv")
+	if err := ioutil.WriteFile(existing, []byte("video"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &Polling{}
+	if err := p.InitWithParams(&Params{
+		Interval:    10 * time.Millisecond,
+		QuietPeriod: 100 * time.Millisecond,
+	}); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	done := make(chan struct{})
+	ctx := polochon.FsNotifierCtx{
+		Event: make(chan string),
+		Done:  done,
+		Wg:    &sync.WaitGroup{},
+	}
+	defer ctx.Wg.Wait()
+	defer close(done)
+
+	log := logrus.NewEntry(logrus.New())
+	if err := p.Watch(dir, ctx, log); err != nil {
+		t.Fatalf("expected no error, got %q", err)
+	}
+
+	if status, err := p.Status(); status != polochon.StatusOK {
+		t.Errorf("expected status ok, got %q %v", status, err)
+	}
+
+	// Write a file slowly, it should only be sent once not written anymore
+	path := filepath.Join(dir, "new.mkv")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if _, err := f.Write([]byte("chunk")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(40 * time.Millisecond)
+	}
+	f.Close()
+	written := time.Since(start)
+
+	select {
+	case got := <-ctx.Event:
+		if got != path {
+			t.Errorf("expected an event for %q, got %q", path, got)
+		}
+
+		if elapsed := time.Since(start); elapsed < written {
+			t.Errorf("expected the event after the file is written, got it after %s", elapsed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected an event for the new file")
+	}
+
+	// The existing file and the new file should not be sent again
+	select {
+	case got := <-ctx.Event:
+		t.Errorf("expected no more events, got %q", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/gregdel/pushover"
 	"github.com/nfnt/resize"
 	polochon "github.com/odwrtw/polochon/lib"
+	"github.com/odwrtw/polochon/lib/disk"
 	"github.com/sirupsen/logrus"
 )
 
@@ -42,8 +43,14 @@ const (
 type Params struct {
 	Key       string `yaml:"key"`
 	Recipient string `yaml:"recipient"`
+	// Events are the types of the notifications sent, the videos added to
+	// the library by default
+	Events []string `yaml:"events"`
 }
 
+// defaultEvents are the events sent without an events list
+var defaultEvents = []string{"movie", "episode"}
+
 // IsValid checks if the given params are valid
 func (p *Params) IsValid() bool {
 	if p.Key == "" || p.Recipient == "" {
@@ -56,9 +63,21 @@ func (p *Params) IsValid() bool {
 type Pushover struct {
 	app        *pushover.Pushover
 	recipient  *pushover.Recipient
+	events     []string
 	configured bool
 }
 
+// wants returns true if the notifications of this type should be sent
+func (p *Pushover) wants(dataType string) bool {
+	for _, e := range p.events {
+		if e == dataType {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Init implements the module interface
 func (p *Pushover) Init(data []byte) error {
 	if p.configured {
@@ -79,6 +98,19 @@ func (p *Pushover) InitWithParams(params *Params) error {
 		return ErrMissingArgument
 	}
 
+	for _, e := range params.Events {
+		switch e {
+		case "movie", "episode", "download", "disk_space":
+		default:
+			return fmt.Errorf("pushover: invalid event %q", e)
+		}
+	}
+
+	p.events = params.Events
+	if len(p.events) == 0 {
+		p.events = defaultEvents
+	}
+
 	p.app = pushover.New(params.Key)
 	p.recipient = pushover.NewRecipient(params.Recipient)
 	p.configured = true
@@ -93,16 +125,37 @@ func (p *Pushover) Name() string {
 
 // Status implements the Module interface
 func (p *Pushover) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	// Validate the recipient to check the application key
+	if _, err := p.app.GetRecipientDetails(p.recipient); err != nil {
+		return polochon.StatusFail, err
+	}
+
+	return polochon.StatusOK, nil
 }
 
 // Notify sends a notification to the recipient
 func (p *Pushover) Notify(i interface{}, log *logrus.Entry) error {
 	switch v := i.(type) {
 	case *polochon.ShowEpisode:
+		if !p.wants("episode") {
+			return nil
+		}
 		return p.notifyShowEpisode(v)
 	case *polochon.Movie:
+		if !p.wants("movie") {
+			return nil
+		}
 		return p.notifyMovie(v)
+	case *polochon.DownloadEvent:
+		if !p.wants("download") {
+			return nil
+		}
+		return p.notifyDownloadEvent(v)
+	case *polochon.DiskSpaceEvent:
+		if !p.wants("disk_space") {
+			return nil
+		}
+		return p.notifyDiskSpaceEvent(v)
 	default:
 		return ErrInvalidArgument
 	}
@@ -169,3 +222,35 @@ func (p *Pushover) notifyShowEpisode(show *polochon.ShowEpisode) error {
 
 	return nil
 }
+
+// notifyDownloadEvent sends a download event notification
+func (p *Pushover) notifyDownloadEvent(event *polochon.DownloadEvent) error {
+	message := &pushover.Message{
+		Title:   fmt.Sprintf("Canapé (Download %s)", event.Event),
+		Message: event.Torrent,
+	}
+
+	if event.Reason != "" {
+		message.Message += "\n" + event.Reason
+	}
+
+	if event.Metadata != nil {
+		message.URL = fmt.Sprintf("imdb:///title/%s/", event.Metadata.ImdbID)
+		message.URLTitle = "Open on imdb"
+	}
+
+	_, err := p.app.SendMessage(message, p.recipient)
+	return err
+}
+
+// notifyDiskSpaceEvent sends a disk space event notification
+func (p *Pushover) notifyDiskSpaceEvent(event *polochon.DiskSpaceEvent) error {
+	message := &pushover.Message{
+		Title: fmt.Sprintf("Canapé (Disk space %s)", event.Event),
+		Message: fmt.Sprintf("%s: %s free, %s required", event.Path,
+			disk.Size(event.Free), disk.Size(event.MinFree)),
+	}
+
+	_, err := p.app.SendMessage(message, p.recipient)
+	return err
+}
@@ -12,17 +12,41 @@ import (
 type showSearcher struct {
 	Episode *polochon.ShowEpisode
 	Users   []string
+	// Number is the scene number of the episode
+	Number polochon.EpisodeNumber
 }
 
 func (sS *showSearcher) key() string {
+	// The daily shows releases are named after the air date
+	if sS.daily() {
+		return fmt.Sprintf(
+			"%s %s",
+			sS.Episode.ShowTitle,
+			strings.Replace(sS.Episode.Aired, "-", " ", -1),
+		)
+	}
+
+	// The anime releases are named after the absolute number
+	if sS.Episode.AbsoluteNumber != 0 {
+		return fmt.Sprintf(
+			"%s %02d",
+			sS.Episode.ShowTitle,
+			sS.Episode.AbsoluteNumber,
+		)
+	}
+
 	return fmt.Sprintf(
-		"%s S%02dE%02d",
+		"%s %s",
 		sS.Episode.ShowTitle,
-		sS.Episode.Season,
-		sS.Episode.Episode,
+		sS.Number,
 	)
 }
 
+// daily returns true if the episode is searched by its air date
+func (sS *showSearcher) daily() bool {
+	return sS.Episode.Aired != "" && sS.Episode.IsDaily(sS.Episode.ShowImdbID)
+}
+
 func (sS *showSearcher) users() []string {
 	return sS.Users
 }
@@ -50,9 +74,24 @@ func (sS *showSearcher) isValidGuess(guess *guessit.Response, log *logrus.Entry)
 		log.Debugf("skipping bad show title %s != %s", guess.Title, sS.Episode.ShowTitle)
 		return false
 	}
+	// The daily shows releases have no season nor episode, the air date is
+	// in the search query
+	if sS.daily() && guess.Season == 0 && guess.Episode == 0 {
+		return true
+	}
+
+	// The anime releases have no season
+	if sS.Episode.AbsoluteNumber != 0 && guess.Season == 0 {
+		if guess.Episode != sS.Episode.AbsoluteNumber {
+			log.Debugf("skipping bad show absolute number %d != %d", guess.Episode, sS.Episode.AbsoluteNumber)
+			return false
+		}
+		return true
+	}
+
 	// Check if the data matches the episode
-	if guess.Season != sS.Episode.Season || guess.Episode != sS.Episode.Episode {
-		log.Debugf("skipping bad show episode/season S%dE%d != S%dE%d", guess.Season, guess.Episode, sS.Episode.Season, sS.Episode.Episode)
+	if guess.Season != sS.Number.Season || guess.Episode != sS.Number.Episode {
+		log.Debugf("skipping bad show episode/season S%dE%d != S%dE%d", guess.Season, guess.Episode, sS.Number.Season, sS.Number.Episode)
 		return false
 	}
 	return true
@@ -114,12 +114,13 @@ type searcher interface {
 }
 
 // newSearcher will return a new Searcher
-func (t *TPB) newSearcher(i interface{}) (searcher, error) {
+func (t *TPB) newSearcher(i interface{}, log *logrus.Entry) (searcher, error) {
 	switch v := i.(type) {
 	case *polochon.ShowEpisode:
 		return &showSearcher{
 			Episode: v,
 			Users:   t.ShowUsers,
+			Number:  v.SceneNumber(),
 		}, nil
 	case *polochon.Movie:
 		return &movieSearcher{
@@ -146,7 +147,7 @@ func (t *TPB) search(s string) ([]*tpb.Torrent, error) {
 // GetTorrents implements the Torrenter interface
 func (t *TPB) GetTorrents(i interface{}, log *logrus.Entry) error {
 	// Create a new Searcher
-	searcher, err := t.newSearcher(i)
+	searcher, err := t.newSearcher(i, log)
 	if err != nil {
 		return err
 	}
@@ -1,10 +1,13 @@
 package transmission
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -23,6 +26,11 @@ func init() {
 // Module constants
 const (
 	moduleName = "transmission"
+
+	// sessionHeader is the header holding the id of the RPC session
+	sessionHeader = "X-Transmission-Session-Id"
+	// pingTimeout is the timeout of the status request
+	pingTimeout = 10 * time.Second
 )
 
 // Params represents the module params
@@ -38,6 +46,7 @@ type Params struct {
 type Client struct {
 	*Params
 	tClient    *transmission.Client
+	httpClient *http.Client
 	configured bool
 }
 
@@ -108,6 +117,7 @@ func (c *Client) setTransmissionClient() error {
 	}
 
 	c.tClient = t
+	c.httpClient = &httpClient
 
 	return nil
 }
@@ -119,7 +129,52 @@ func (c *Client) Name() string {
 
 // Status implements the Module interface
 func (c *Client) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	if err := c.ping(); err != nil {
+		return polochon.StatusFail, err
+	}
+
+	return polochon.StatusOK, nil
+}
+
+// ping sends a session-get request to the RPC server, transmission answers
+// the first request with a conflict giving the session id to use
+func (c *Client) ping() error {
+	sessionID := ""
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+
+		body := strings.NewReader(`{"method":"session-get"}`)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, body)
+		if err != nil {
+			cancel()
+			return err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(sessionHeader, sessionID)
+		if c.BasicAuth {
+			req.SetBasicAuth(c.Username, c.Password)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			cancel()
+			return err
+		}
+		resp.Body.Close()
+		cancel()
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return nil
+		case http.StatusConflict:
+			sessionID = resp.Header.Get(sessionHeader)
+		default:
+			return fmt.Errorf("transmission: invalid http code %q", resp.Status)
+		}
+	}
+
+	return fmt.Errorf("transmission: no session id")
 }
 
 // Download implements the downloader interface
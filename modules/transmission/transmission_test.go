@@ -0,0 +1,52 @@
+package transmission
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	polochon "github.com/odwrtw/polochon/lib"
+)
+
+func TestStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "user" || password != "password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Header.Get(sessionHeader) != "session" {
+			w.Header().Set(sessionHeader, "session")
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		w.Write([]byte(`{"result":"success"}`))
+	}))
+	defer ts.Close()
+
+	for _, tc := range []struct {
+		name           string
+		password       string
+		expectedStatus polochon.ModuleStatus
+	}{
+		{name: "ok", password: "password", expectedStatus: polochon.StatusOK},
+		{name: "invalid credentials", password: "nope", expectedStatus: polochon.StatusFail},
+	} {
+		c := &Client{
+			Params: &Params{
+				URL:       ts.URL,
+				BasicAuth: true,
+				Username:  "user",
+				Password:  tc.password,
+			},
+			httpClient: ts.Client(),
+		}
+
+		status, err := c.Status()
+		if status != tc.expectedStatus {
+			t.Errorf("%s: expected status %q, got %q (%v)", tc.name, tc.expectedStatus, status, err)
+		}
+	}
+}
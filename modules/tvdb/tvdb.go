@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/agnivade/levenshtein"
@@ -51,7 +52,10 @@ type Params struct {
 
 // TvDB implents the Detailer interface
 type TvDB struct {
-	client           *tvdb.Client
+	client *tvdb.Client
+	// mu protects the token refresh, the module is called concurrently by
+	// the downloader workers
+	mu               sync.Mutex
 	lastTokenRefresh *time.Time
 	configured       bool
 }
@@ -88,6 +92,9 @@ func (t *TvDB) Name() string {
 
 // login handles the token refresh
 func (t *TvDB) login() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if t.lastTokenRefresh != nil && time.Since(*t.lastTokenRefresh) < tokenExpiration/2 {
 		// The token is still valid
 		return nil
@@ -285,6 +292,7 @@ func (t *TvDB) getShowEpisodes(s *polochon.Show, show *tvdb.Series, params url.V
 		episode.ShowTitle = s.Title
 		episode.Season = e.AiredSeason
 		episode.Episode = e.AiredEpisodeNumber
+		episode.AbsoluteNumber = e.AbsoluteNumber
 		episode.TvdbID = e.ID
 		episode.Aired = e.FirstAired
 		episode.Plot = e.Overview
@@ -403,8 +411,9 @@ func (t *TvDB) getShowDetails(s *polochon.Show, params url.Values) error {
 }
 
 func (t *TvDB) getEpisodeDetails(s *polochon.ShowEpisode) error {
-	// The season / episode infos are needed
-	if s.Season == 0 || s.Episode == 0 {
+	// The season / episode infos or the absolute number are needed
+	absolute := s.Season == 0 && s.AbsoluteNumber != 0
+	if !absolute && (s.Season == 0 || s.Episode == 0) {
 		return ErrMissingShowEpisodeInformations
 	}
 
@@ -432,12 +441,28 @@ func (t *TvDB) getEpisodeDetails(s *polochon.ShowEpisode) error {
 		"airedSeason":  {strconv.Itoa(s.Season)},
 		"airedEpisode": {strconv.Itoa(s.Episode)},
 	}
+	if absolute {
+		params = url.Values{
+			"absoluteNumber": {strconv.Itoa(s.AbsoluteNumber)},
+		}
+	}
 
 	err := t.getShowDetails(show, params)
 	if err != nil {
 		return err
 	}
 
+	// Find the season and episode of the absolute number
+	if absolute {
+		e := show.AbsoluteEpisode(s.AbsoluteNumber)
+		if e == nil {
+			return ErrFailedToUpdateEpisode
+		}
+
+		s.Season = e.Season
+		s.Episode = e.Episode
+	}
+
 	var updated bool
 	for _, e := range show.Episodes {
 		if e.Season == s.Season && e.Episode == s.Episode {
@@ -445,6 +470,7 @@ func (t *TvDB) getEpisodeDetails(s *polochon.ShowEpisode) error {
 			s.ShowTitle = e.ShowTitle
 			s.Season = e.Season
 			s.Episode = e.Episode
+			s.AbsoluteNumber = e.AbsoluteNumber
 			s.TvdbID = e.TvdbID
 			s.Aired = e.Aired
 			s.Plot = e.Plot
@@ -490,9 +516,10 @@ func (t *TvDB) GetShowCalendar(show *polochon.Show, log *logrus.Entry) (*polocho
 		}
 
 		calendar.Episodes = append(calendar.Episodes, &polochon.ShowCalendarEpisode{
-			Season:    e.Season,
-			Episode:   e.Episode,
-			AiredDate: &aired,
+			Season:         e.Season,
+			Episode:        e.Episode,
+			AbsoluteNumber: e.AbsoluteNumber,
+			AiredDate:      &aired,
 		})
 	}
 
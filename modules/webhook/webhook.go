@@ -7,7 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"html/template"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -38,10 +40,32 @@ type Params struct {
 	Hooks []*Hook `yaml:"hooks"`
 }
 
+// defaultEvents are the events sent to the hooks without an events list
+var defaultEvents = []string{"movie", "episode"}
+
 // Hook represents a Hook
 type Hook struct {
 	URLTemplate *template.Template `yaml:"-"`
 	URL         string             `yaml:"url"`
+	// Events are the types of the notifications sent to the hook, the videos
+	// added to the library by default
+	Events []string `yaml:"events"`
+}
+
+// wants returns true if the hook is interested in this type of notification
+func (h *Hook) wants(dataType string) bool {
+	events := h.Events
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+
+	for _, e := range events {
+		if e == dataType {
+			return true
+		}
+	}
+
+	return false
 }
 
 // WebHook stores the webhook configs
@@ -73,6 +97,14 @@ func (w *WebHook) InitWithParams(params *Params) error {
 			return err
 		}
 		h.URLTemplate = url
+
+		for _, e := range h.Events {
+			switch e {
+			case "movie", "episode", "download", "disk_space":
+			default:
+				return fmt.Errorf("webhook: invalid event %q", e)
+			}
+		}
 	}
 
 	w.hooks = params.Hooks
@@ -89,27 +121,59 @@ func (w *WebHook) Name() string {
 
 // Status implements the Module interface
 func (w *WebHook) Status() (polochon.ModuleStatus, error) {
-	return polochon.StatusNotImplemented, nil
+	for _, h := range w.hooks {
+		if err := h.check(); err != nil {
+			return polochon.StatusFail, err
+		}
+	}
+
+	return polochon.StatusOK, nil
+}
+
+// check renders the URL of the hook with an empty movie and makes sure its
+// host can be resolved
+func (h *Hook) check() error {
+	var URL bytes.Buffer
+	if err := h.URLTemplate.Execute(&URL, &polochon.Movie{}); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(URL.String())
+	if err != nil {
+		return err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("webhook: invalid url %q", h.URL)
+	}
+
+	_, err = net.LookupHost(u.Hostname())
+	return err
 }
 
 // Notify sends a notification to the recipient
 func (w *WebHook) Notify(i interface{}, log *logrus.Entry) error {
-	var videoType string
-	var video polochon.Video
+	var dataType string
 
-	switch v := i.(type) {
+	switch i.(type) {
 	case *polochon.ShowEpisode:
-		videoType = "episode"
-		video = v
+		dataType = "episode"
 	case *polochon.Movie:
-		videoType = "movie"
-		video = v
+		dataType = "movie"
+	case *polochon.DownloadEvent:
+		dataType = "download"
+	case *polochon.DiskSpaceEvent:
+		dataType = "disk_space"
 	default:
 		return ErrInvalidArgument
 	}
 
 	for _, h := range w.hooks {
-		err := w.notify(h, video, videoType)
+		if !h.wants(dataType) {
+			continue
+		}
+
+		err := w.notify(h, i, dataType)
 		if err != nil {
 			log.Warnf(err.Error())
 		}
@@ -118,9 +182,9 @@ func (w *WebHook) Notify(i interface{}, log *logrus.Entry) error {
 	return nil
 }
 
-func (w *WebHook) notify(hook *Hook, video polochon.Video, videoType string) error {
+func (w *WebHook) notify(hook *Hook, data interface{}, dataType string) error {
 	var URL bytes.Buffer
-	err := hook.URLTemplate.Execute(&URL, video)
+	err := hook.URLTemplate.Execute(&URL, data)
 	if err != nil {
 		return err
 	}
@@ -130,8 +194,8 @@ func (w *WebHook) notify(hook *Hook, video polochon.Video, videoType string) err
 		Type string      `json:"type"`
 		Data interface{} `json:"data"`
 	}{
-		Type: videoType,
-		Data: video,
+		Type: dataType,
+		Data: data,
 	})
 
 	req, err := http.NewRequest("POST", URL.String(), b)